@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	LastEventIDHeader = "Last-Event-ID"
+	EventStreamType   = "text/event-stream"
+	KeepAliveInterval = 15 * time.Second
+)
+
+// HandleItemEvents streams item changes as server-sent events, resuming after the Last-Event-ID header when set.
+func (h *Handler) HandleItemEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		h.problemResponse(w, r, http.StatusInternalServerError, "streaming is not supported")
+		return
+	}
+
+	var lastEventID uint64
+	if header := r.Header.Get(LastEventIDHeader); header != "" {
+		var err error
+		lastEventID, err = strconv.ParseUint(header, 10, 64)
+		if err != nil {
+			h.problemResponse(w, r, http.StatusBadRequest, LastEventIDHeader+" header must be an event ID")
+			return
+		}
+	}
+
+	sub, err := h.service.Subscribe(lastEventID)
+	if err != nil {
+		h.serviceErrorResponse(w, r, err)
+		return
+	}
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", EventStreamType)
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(KeepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				slog.ErrorContext(r.Context(), err.Error())
+				return
+			}
+			flusher.Flush()
+		case event, ok := <-sub.Events:
+			if !ok {
+				return
+			}
+
+			data, err := json.Marshal(event)
+			if err != nil {
+				slog.ErrorContext(r.Context(), err.Error())
+				return
+			}
+
+			if _, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data); err != nil {
+				slog.ErrorContext(r.Context(), err.Error())
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
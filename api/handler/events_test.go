@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"bufio"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"to-do-app-v2/internal/store"
+)
+
+// newTestStore returns a store working in a temporary directory so its files do not leak into the package.
+func newTestStore(t *testing.T) *store.Store {
+	itemStore := store.NewStore(store.WithDir(t.TempDir()), store.WithJanitorInterval(0))
+	t.Cleanup(itemStore.Close)
+	return itemStore
+}
+
+func readEvent(t *testing.T, reader *bufio.Reader) map[string]string {
+	fields := make(map[string]string)
+	for {
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			return fields
+		}
+		name, value, _ := strings.Cut(line, ": ")
+		fields[name] = value
+	}
+}
+
+func Test_HandleItemEvents_StreamsChanges(t *testing.T) {
+	itemStore := newTestStore(t)
+	server := httptest.NewServer(http.HandlerFunc(NewHandler(itemStore).HandleItemEvents))
+	t.Cleanup(server.Close)
+
+	resp, err := http.Get(server.URL)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = resp.Body.Close() })
+	assert.Equal(t, EventStreamType, resp.Header.Get("Content-Type"))
+
+	item, err := itemStore.Create(store.Item{Name: "name1"})
+	require.NoError(t, err)
+
+	event := readEvent(t, bufio.NewReader(resp.Body))
+
+	assert.Equal(t, store.EventCreate, event["event"])
+	assert.NotEmpty(t, event["id"])
+	assert.Contains(t, event["data"], item.ID)
+}
+
+func Test_HandleItemEvents_ResumesFromLastEventID(t *testing.T) {
+	itemStore := newTestStore(t)
+	server := httptest.NewServer(http.HandlerFunc(NewHandler(itemStore).HandleItemEvents))
+	t.Cleanup(server.Close)
+
+	sub, err := itemStore.Subscribe(0)
+	require.NoError(t, err)
+	_, err = itemStore.Create(store.Item{Name: "name1"})
+	require.NoError(t, err)
+	first := <-sub.Events
+	sub.Close()
+	_, err = itemStore.Create(store.Item{Name: "name2"})
+	require.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+	req.Header.Set(LastEventIDHeader, strconv.FormatUint(first.ID, 10))
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = resp.Body.Close() })
+
+	event := readEvent(t, bufio.NewReader(resp.Body))
+
+	assert.Contains(t, event["data"], `"name":"name2"`)
+}
@@ -2,23 +2,71 @@ package handler
 
 import (
 	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
 	"html/template"
 	"log/slog"
 	"net/http"
+	"strconv"
+	"time"
+	"to-do-app-v2/api/middleware"
 	"to-do-app-v2/internal/store"
 )
 
 type Service interface {
 	Create(item store.Item) (store.Item, error)
+	CreateIdempotent(user, key string, item store.Item) (store.Item, bool, error)
 	ReadAll() ([]store.Item, error)
 	Read(id store.ItemID) (store.Item, error)
 	Update(id store.ItemID, item store.Item) (store.Item, error)
 	Delete(id store.ItemID) error
+	ReadAssigned(assignee string) ([]store.Item, error)
+	AddComment(id store.ItemID, comment store.Comment) (store.Comment, error)
+	ReadComments(id store.ItemID) ([]store.Comment, error)
+	ReadHistory(id store.ItemID) ([]store.Activity, error)
+	Restore(at time.Time) ([]store.Item, error)
+	ReadTrash() ([]store.TrashedItem, error)
+	RestoreDeleted(id store.ItemID) (store.Item, error)
+	ReadArchived() ([]store.Item, error)
+	Unarchive(id store.ItemID) (store.Item, error)
+	Batch(operations []store.Operation) ([]store.OperationResult, error)
+	Subscribe(lastEventID uint64) (*store.Subscription, error)
+	AddWebhook(webhook store.Webhook) (store.Webhook, error)
+	ReadWebhooks() ([]store.Webhook, error)
+	DeleteWebhook(id string) error
+	ReadDeliveries(id string) ([]store.Delivery, error)
 }
 
-type Error struct {
-	StatusCode int    `json:"status_code"`
-	Error      string `json:"error"`
+type BatchRequest struct {
+	Operations []store.Operation `json:"operations"`
+}
+
+type BatchResponse struct {
+	Results []store.OperationResult `json:"results"`
+}
+
+const (
+	IdempotencyKeyHeader     = "Idempotency-Key"
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+)
+
+const (
+	ProblemContentType             = "application/problem+json"
+	ProblemTypeDefault             = "about:blank"
+	ProblemTypeValidation          = "urn:to-do-app:problem:validation-error"
+	ProblemTypeVersionConflict     = "urn:to-do-app:problem:version-conflict"
+	ProblemTypeIdempotencyConflict = "urn:to-do-app:problem:idempotency-conflict"
+	ProblemTypeAlreadyExists       = "urn:to-do-app:problem:already-exists"
+)
+
+// Problem is an RFC 7807 problem details response body.
+type Problem struct {
+	Type     string       `json:"type"`
+	Title    string       `json:"title"`
+	Status   int          `json:"status"`
+	Detail   string       `json:"detail,omitempty"`
+	Instance string       `json:"instance,omitempty"`
+	Errors   []FieldError `json:"errors,omitempty"`
 }
 
 type Handler struct {
@@ -36,64 +84,160 @@ func (h *Handler) HandleAboutPage(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) HandleListItemsPage(w http.ResponseWriter, r *http.Request) {
-	items, err := h.service.ReadAll()
+	include, err := includeArchived(r)
 	if err != nil {
-		slog.ErrorContext(r.Context(), err.Error())
-		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		h.problemResponse(w, r, http.StatusBadRequest, "query parameter 'include_archived' must be a boolean")
+		return
+	}
+
+	items, err := h.readItems(include)
+	if err != nil {
+		h.serviceErrorResponse(w, r, err)
 		return
 	}
 
 	var tmpl *template.Template
 	tmpl, err = template.ParseFiles("./web/templates/list.html")
 	if err != nil {
-		slog.ErrorContext(r.Context(), err.Error())
-		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		h.serviceErrorResponse(w, r, err)
 		return
 	}
 
 	if err = tmpl.Execute(w, items); err != nil {
-		slog.ErrorContext(r.Context(), err.Error())
-		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		h.serviceErrorResponse(w, r, err)
 		return
 	}
 }
 
 func (h *Handler) HandleCreateItem(w http.ResponseWriter, r *http.Request) {
 	var item store.Item
-	if err := json.NewDecoder(r.Body).Decode(&item); err != nil {
+	if statusCode, err := decodeJSON(w, r, &item); err != nil {
 		slog.ErrorContext(r.Context(), err.Error())
-		h.errorResponse(w, http.StatusBadRequest, err.Error())
+		h.problemResponse(w, r, statusCode, err.Error())
 		return
 	}
 
-	newItem, err := h.service.Create(item)
+	// IDs are assigned by the store
+	item.ID = ""
+	idempotencyKey := r.Header.Get(IdempotencyKeyHeader)
+
+	details := validateItem(item)
+	if len(idempotencyKey) > MaxIdempotencyKeyBytes {
+		details = append(details, FieldError{
+			Field:   IdempotencyKeyHeader,
+			Message: fmt.Sprintf("must be at most %d bytes", MaxIdempotencyKeyBytes),
+		})
+	}
+	if len(details) > 0 {
+		h.validationErrorResponse(w, r, details)
+		return
+	}
+
+	var (
+		newItem  store.Item
+		replayed bool
+		err      error
+	)
+	if idempotencyKey == "" {
+		newItem, err = h.service.Create(item)
+	} else {
+		// Keys are remembered per user, so users picking the same key never receive each other's items
+		user, _ := middleware.UserID(r.Context())
+		newItem, replayed, err = h.service.CreateIdempotent(user, idempotencyKey, item)
+	}
 	if err != nil {
-		slog.ErrorContext(r.Context(), err.Error())
-		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		h.serviceErrorResponse(w, r, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	if replayed {
+		w.Header().Set(IdempotentReplayedHeader, "true")
+	}
 	w.WriteHeader(http.StatusCreated)
 	if err := json.NewEncoder(w).Encode(newItem); err != nil {
+		h.serviceErrorResponse(w, r, err)
+		return
+	}
+}
+
+func (h *Handler) HandleBatchItems(w http.ResponseWriter, r *http.Request) {
+	var batch BatchRequest
+	if statusCode, err := decodeJSON(w, r, &batch); err != nil {
 		slog.ErrorContext(r.Context(), err.Error())
-		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		h.problemResponse(w, r, statusCode, err.Error())
+		return
+	}
+
+	if details := validateBatch(batch); len(details) > 0 {
+		h.validationErrorResponse(w, r, details)
+		return
+	}
+
+	for i, operation := range batch.Operations {
+		if operation.Op == store.OpCreate {
+			// IDs are assigned by the store
+			batch.Operations[i].ID = ""
+		}
+	}
+
+	results, err := h.service.Batch(batch.Operations)
+	var batchError *store.BatchError
+	if errors.As(err, &batchError) {
+		slog.ErrorContext(r.Context(), err.Error())
+		h.validationErrorResponse(w, r, []FieldError{
+			{Field: fmt.Sprintf("operations[%d]", batchError.Index), Message: batchError.Err.Error()},
+		})
+		return
+	}
+	if err != nil {
+		h.serviceErrorResponse(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(BatchResponse{Results: results}); err != nil {
+		h.serviceErrorResponse(w, r, err)
 		return
 	}
 }
 
 func (h *Handler) HandleGetItems(w http.ResponseWriter, r *http.Request) {
-	items, err := h.service.ReadAll()
+	include, err := includeArchived(r)
 	if err != nil {
-		slog.ErrorContext(r.Context(), err.Error())
-		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		h.problemResponse(w, r, http.StatusBadRequest, "query parameter 'include_archived' must be a boolean")
+		return
+	}
+
+	items, err := h.readItems(include)
+	if err != nil {
+		h.serviceErrorResponse(w, r, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(items); err != nil {
-		slog.ErrorContext(r.Context(), err.Error())
-		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		h.serviceErrorResponse(w, r, err)
+		return
+	}
+}
+
+func (h *Handler) HandleGetMyItems(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.UserID(r.Context())
+	if !ok {
+		h.problemResponse(w, r, http.StatusUnauthorized, "missing UserID header")
+		return
+	}
+
+	items, err := h.service.ReadAssigned(userID)
+	if err != nil {
+		h.serviceErrorResponse(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(items); err != nil {
+		h.serviceErrorResponse(w, r, err)
 		return
 	}
 }
@@ -102,16 +246,14 @@ func (h *Handler) HandleGetItemWithID(w http.ResponseWriter, r *http.Request) {
 	id := store.ItemID(r.PathValue("id"))
 	item, err := h.service.Read(id)
 	if err != nil {
-		slog.ErrorContext(r.Context(), err.Error())
-		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		h.serviceErrorResponse(w, r, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	err = json.NewEncoder(w).Encode(item)
 	if err != nil {
-		slog.ErrorContext(r.Context(), err.Error())
-		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		h.serviceErrorResponse(w, r, err)
 		return
 	}
 }
@@ -120,23 +262,26 @@ func (h *Handler) HandleUpdateItem(w http.ResponseWriter, r *http.Request) {
 	id := store.ItemID(r.PathValue("id"))
 
 	var newItem store.Item
-	if err := json.NewDecoder(r.Body).Decode(&newItem); err != nil {
+	if statusCode, err := decodeJSON(w, r, &newItem); err != nil {
 		slog.ErrorContext(r.Context(), err.Error())
-		h.errorResponse(w, http.StatusBadRequest, err.Error())
+		h.problemResponse(w, r, statusCode, err.Error())
+		return
+	}
+
+	if details := validateItem(newItem); len(details) > 0 {
+		h.validationErrorResponse(w, r, details)
 		return
 	}
 
 	item, err := h.service.Update(id, newItem)
 	if err != nil {
-		slog.ErrorContext(r.Context(), err.Error())
-		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		h.serviceErrorResponse(w, r, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(item); err != nil {
-		slog.ErrorContext(r.Context(), err.Error())
-		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		h.serviceErrorResponse(w, r, err)
 		return
 	}
 }
@@ -145,22 +290,276 @@ func (h *Handler) HandleDeleteItem(w http.ResponseWriter, r *http.Request) {
 	id := store.ItemID(r.PathValue("id"))
 
 	if err := h.service.Delete(id); err != nil {
-		slog.ErrorContext(r.Context(), err.Error())
-		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		h.serviceErrorResponse(w, r, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
 
-func (h *Handler) errorResponse(w http.ResponseWriter, statusCode int, errorString string) {
+func (h *Handler) HandleCreateComment(w http.ResponseWriter, r *http.Request) {
+	id := store.ItemID(r.PathValue("id"))
+
+	var comment store.Comment
+	if statusCode, err := decodeJSON(w, r, &comment); err != nil {
+		slog.ErrorContext(r.Context(), err.Error())
+		h.problemResponse(w, r, statusCode, err.Error())
+		return
+	}
+
+	if details := validateComment(comment); len(details) > 0 {
+		h.validationErrorResponse(w, r, details)
+		return
+	}
+
+	if userID, ok := middleware.UserID(r.Context()); ok {
+		comment.Author = userID
+	}
+
+	newComment, err := h.service.AddComment(id, comment)
+	if err != nil {
+		h.serviceErrorResponse(w, r, err)
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(statusCode)
-	encodingError := json.NewEncoder(w).Encode(Error{
-		StatusCode: statusCode,
-		Error:      errorString,
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(newComment); err != nil {
+		h.serviceErrorResponse(w, r, err)
+		return
+	}
+}
+
+func (h *Handler) HandleGetComments(w http.ResponseWriter, r *http.Request) {
+	id := store.ItemID(r.PathValue("id"))
+
+	comments, err := h.service.ReadComments(id)
+	if err != nil {
+		h.serviceErrorResponse(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(comments); err != nil {
+		h.serviceErrorResponse(w, r, err)
+		return
+	}
+}
+
+func (h *Handler) HandleGetHistory(w http.ResponseWriter, r *http.Request) {
+	id := store.ItemID(r.PathValue("id"))
+
+	history, err := h.service.ReadHistory(id)
+	if err != nil {
+		h.serviceErrorResponse(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(history); err != nil {
+		h.serviceErrorResponse(w, r, err)
+		return
+	}
+}
+
+func (h *Handler) HandleGetTrash(w http.ResponseWriter, r *http.Request) {
+	trash, err := h.service.ReadTrash()
+	if err != nil {
+		h.serviceErrorResponse(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(trash); err != nil {
+		h.serviceErrorResponse(w, r, err)
+		return
+	}
+}
+
+func (h *Handler) HandleGetArchive(w http.ResponseWriter, r *http.Request) {
+	archived, err := h.service.ReadArchived()
+	if err != nil {
+		h.serviceErrorResponse(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(archived); err != nil {
+		h.serviceErrorResponse(w, r, err)
+		return
+	}
+}
+
+func (h *Handler) HandleRestoreDeletedItem(w http.ResponseWriter, r *http.Request) {
+	id := store.ItemID(r.PathValue("id"))
+
+	item, err := h.service.RestoreDeleted(id)
+	if err != nil {
+		h.serviceErrorResponse(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(item); err != nil {
+		h.serviceErrorResponse(w, r, err)
+		return
+	}
+}
+
+func (h *Handler) HandleUnarchiveItem(w http.ResponseWriter, r *http.Request) {
+	id := store.ItemID(r.PathValue("id"))
+
+	item, err := h.service.Unarchive(id)
+	if err != nil {
+		h.serviceErrorResponse(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(item); err != nil {
+		h.serviceErrorResponse(w, r, err)
+		return
+	}
+}
+
+func (h *Handler) HandleRestoreItems(w http.ResponseWriter, r *http.Request) {
+	at, err := time.Parse(time.RFC3339, r.URL.Query().Get("at"))
+	if err != nil {
+		slog.ErrorContext(r.Context(), err.Error())
+		h.problemResponse(w, r, http.StatusBadRequest, "query parameter 'at' must be an RFC 3339 timestamp")
+		return
+	}
+
+	items, err := h.service.Restore(at)
+	if err != nil {
+		h.serviceErrorResponse(w, r, err)
+		return
+	}
+
+	slog.InfoContext(r.Context(), "items restored", "at", at)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(items); err != nil {
+		h.serviceErrorResponse(w, r, err)
+		return
+	}
+}
+
+func (h *Handler) readItems(includeArchived bool) ([]store.Item, error) {
+	items, err := h.service.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	if !includeArchived {
+		return items, nil
+	}
+
+	archived, err := h.service.ReadArchived()
+	if err != nil {
+		return nil, err
+	}
+
+	return append(items, archived...), nil
+}
+
+// includeArchived reads the include_archived query parameter, false when it is missing.
+func includeArchived(r *http.Request) (bool, error) {
+	value := r.URL.Query().Get("include_archived")
+	if value == "" {
+		return false, nil
+	}
+
+	return strconv.ParseBool(value)
+}
+
+// serviceErrorResponse logs the error and responds with a problem that does not expose internal error details,
+// the detail is fixed per kind of error as the wrapped error names files and internals.
+func (h *Handler) serviceErrorResponse(w http.ResponseWriter, r *http.Request, err error) {
+	slog.ErrorContext(r.Context(), err.Error())
+
+	statusCode, detail := serviceError(err)
+	h.writeProblem(w, Problem{
+		Type:     problemType(err),
+		Title:    http.StatusText(statusCode),
+		Status:   statusCode,
+		Detail:   detail,
+		Instance: instance(r),
 	})
-	if encodingError != nil {
+}
+
+// problemType returns the type of the problem answering a service error, telling apart the conflicts that
+// share a status code.
+func problemType(err error) string {
+	switch {
+	case errors.Is(err, store.ErrIdempotencyConflict):
+		return ProblemTypeIdempotencyConflict
+	case errors.Is(err, store.ErrVersionConflict):
+		return ProblemTypeVersionConflict
+	case errors.Is(err, store.ErrAlreadyExists):
+		return ProblemTypeAlreadyExists
+	default:
+		return ProblemTypeDefault
+	}
+}
+
+// serviceError returns the status code and the fixed detail answering a service error, the error itself is
+// only logged as it can tell about the internals of the service.
+func serviceError(err error) (int, string) {
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		return http.StatusNotFound, "the requested resource does not exist"
+	case errors.Is(err, store.ErrIdempotencyConflict):
+		return http.StatusConflict, "the idempotency key was already used with a different item"
+	case errors.Is(err, store.ErrVersionConflict):
+		return http.StatusConflict, "the item changed since it was read, read it again and retry"
+	case errors.Is(err, store.ErrAlreadyExists):
+		return http.StatusConflict, "an item with this ID already exists"
+	case errors.Is(err, store.ErrHistoryUnavailable):
+		return http.StatusUnprocessableEntity, "the history does not go back to the requested time"
+	case errors.Is(err, store.ErrReadOnly):
+		return http.StatusServiceUnavailable, "the store is read-only, retry against the leader"
+	case errors.Is(err, store.ErrClosed):
+		return http.StatusServiceUnavailable, "the store is shutting down, retry later"
+	default:
+		return http.StatusInternalServerError, "an internal error occurred, quote the instance when reporting it"
+	}
+}
+
+func (h *Handler) problemResponse(w http.ResponseWriter, r *http.Request, statusCode int, detail string) {
+	h.writeProblem(w, Problem{
+		Type:     ProblemTypeDefault,
+		Title:    http.StatusText(statusCode),
+		Status:   statusCode,
+		Detail:   detail,
+		Instance: instance(r),
+	})
+}
+
+func (h *Handler) validationErrorResponse(w http.ResponseWriter, r *http.Request, details []FieldError) {
+	h.writeProblem(w, Problem{
+		Type:     ProblemTypeValidation,
+		Title:    "Validation Failed",
+		Status:   http.StatusUnprocessableEntity,
+		Detail:   "the request body contains invalid fields",
+		Instance: instance(r),
+		Errors:   details,
+	})
+}
+
+func (h *Handler) writeProblem(w http.ResponseWriter, problem Problem) {
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.WriteHeader(problem.Status)
+	if encodingError := json.NewEncoder(w).Encode(problem); encodingError != nil {
 		http.Error(w, encodingError.Error(), http.StatusInternalServerError)
 	}
 }
+
+// instance identifies the occurrence of a problem by the TraceID of the request.
+func instance(r *http.Request) string {
+	traceID, ok := r.Context().Value("TraceID").(string)
+	if !ok {
+		return ""
+	}
+	return "urn:uuid:" + traceID
+}
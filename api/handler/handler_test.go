@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"to-do-app-v2/api/middleware"
+	"to-do-app-v2/internal/store"
+)
+
+func serveServiceError(err error) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/items/id1", nil)
+	req = req.WithContext(context.WithValue(req.Context(), "TraceID", "2b1c0c52-6f7e-4d39-9a5e-0d2c6b0f8a11"))
+	NewHandler(nil).serviceErrorResponse(recorder, req, err)
+	return recorder
+}
+
+func Test_ServiceErrorResponse_HidesInternalErrors(t *testing.T) {
+	recorder := serveServiceError(errors.Wrap(errors.New("unexpected EOF"), "load items: decode items.json"))
+
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+	problem := decodeProblem(t, recorder)
+	assert.Equal(t, ProblemTypeDefault, problem.Type)
+	assert.Equal(t, "Internal Server Error", problem.Title)
+	assert.NotContains(t, problem.Detail, "items.json")
+	assert.Equal(t, "urn:uuid:2b1c0c52-6f7e-4d39-9a5e-0d2c6b0f8a11", problem.Instance)
+}
+
+func Test_ServiceErrorResponse_ReturnsNotFound(t *testing.T) {
+	recorder := serveServiceError(errors.Wrapf(store.ErrNotFound, "item '%s'", "id1"))
+
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+	problem := decodeProblem(t, recorder)
+	assert.Equal(t, http.StatusNotFound, problem.Status)
+	assert.Equal(t, "the requested resource does not exist", problem.Detail)
+}
+
+func Test_ServiceErrorResponse_ReturnsFixedDetailPerError(t *testing.T) {
+	tests := []struct {
+		err        error
+		statusCode int
+	}{
+		{store.ErrIdempotencyConflict, http.StatusConflict},
+		{store.ErrVersionConflict, http.StatusConflict},
+		{store.ErrAlreadyExists, http.StatusConflict},
+		{store.ErrHistoryUnavailable, http.StatusUnprocessableEntity},
+		{store.ErrReadOnly, http.StatusServiceUnavailable},
+		{store.ErrClosed, http.StatusServiceUnavailable},
+	}
+
+	for _, test := range tests {
+		recorder := serveServiceError(errors.Wrap(test.err, "load items.json"))
+
+		assert.Equal(t, test.statusCode, recorder.Code)
+		problem := decodeProblem(t, recorder)
+		assert.NotEmpty(t, problem.Detail)
+		assert.NotContains(t, problem.Detail, "items.json")
+	}
+}
+
+func Test_ServiceErrorResponse_TellsConflictsApartByType(t *testing.T) {
+	tests := []struct {
+		err         error
+		problemType string
+	}{
+		{store.ErrIdempotencyConflict, ProblemTypeIdempotencyConflict},
+		{store.ErrVersionConflict, ProblemTypeVersionConflict},
+		{store.ErrAlreadyExists, ProblemTypeAlreadyExists},
+	}
+
+	for _, test := range tests {
+		recorder := serveServiceError(errors.Wrap(test.err, "item 'id1'"))
+
+		assert.Equal(t, http.StatusConflict, recorder.Code)
+		assert.Equal(t, test.problemType, decodeProblem(t, recorder).Type)
+	}
+}
+
+func Test_HandleGetItems_ReturnsBadRequestForInvalidIncludeArchived(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/items?include_archived=maybe", nil)
+
+	NewHandler(newTestStore(t)).HandleGetItems(recorder, req)
+
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.Contains(t, decodeProblem(t, recorder).Detail, "include_archived")
+}
+
+func serveGetMyItems(service Service, userID string) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/me/items", nil)
+	if userID != "" {
+		req.Header.Set(middleware.UserIDHeader, userID)
+	}
+	middleware.UserIDMiddleware(http.HandlerFunc(NewHandler(service).HandleGetMyItems)).ServeHTTP(recorder, req)
+	return recorder
+}
+
+func Test_HandleGetMyItems_ReturnsUnauthorizedWithoutUser(t *testing.T) {
+	recorder := serveGetMyItems(nil, "")
+
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+	assert.Equal(t, http.StatusUnauthorized, decodeProblem(t, recorder).Status)
+}
+
+func Test_HandleGetMyItems_ReturnsItemsAssignedToUser(t *testing.T) {
+	itemStore := newTestStore(t)
+	item1, err := itemStore.Create(store.Item{Name: "name1", Status: store.StatusNotStarted, Assignee: "user1"})
+	require.NoError(t, err)
+	_, err = itemStore.Create(store.Item{Name: "name2", Status: store.StatusNotStarted, Assignee: "user2"})
+	require.NoError(t, err)
+	_, err = itemStore.Create(store.Item{Name: "name3", Status: store.StatusNotStarted})
+	require.NoError(t, err)
+
+	recorder := serveGetMyItems(itemStore, "user1")
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	var items []store.Item
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&items))
+	assert.Equal(t, []store.Item{item1}, items)
+}
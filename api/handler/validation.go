@@ -0,0 +1,160 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"to-do-app-v2/internal/store"
+	"unicode/utf8"
+)
+
+const (
+	MaxBodyBytes   = 1 << 20
+	MaxNameLength  = 200
+	MaxTextLength  = 4000
+	MaxUserIDBytes = 100
+	MaxBatchSize   = 100
+	MaxSecretBytes = 255
+
+	MaxIdempotencyKeyBytes = 255
+)
+
+var statuses = []string{store.StatusNotStarted, store.StatusStarted, store.StatusCompleted}
+
+var eventTypes = []string{store.EventCreate, store.EventUpdate, store.EventDelete, store.EventArchive, store.EventUnarchive, store.EventComment}
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// decodeJSON strictly decodes a single JSON value from the request body into v and returns the status
+// code to respond with when it fails.
+func decodeJSON(w http.ResponseWriter, r *http.Request, v any) (int, error) {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, MaxBodyBytes))
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(v); err != nil {
+		var maxBytesError *http.MaxBytesError
+		if errors.As(err, &maxBytesError) {
+			return http.StatusRequestEntityTooLarge, errors.Errorf("request body larger than %d bytes", maxBytesError.Limit)
+		}
+		return http.StatusBadRequest, errors.Wrap(err, "decode request body")
+	}
+
+	if err := decoder.Decode(&struct{}{}); !errors.Is(err, io.EOF) {
+		return http.StatusBadRequest, errors.New("request body must contain a single JSON value")
+	}
+
+	return http.StatusOK, nil
+}
+
+func validateItem(item store.Item) []FieldError {
+	var details []FieldError
+
+	switch {
+	case strings.TrimSpace(item.Name) == "":
+		details = append(details, FieldError{Field: "name", Message: "must not be empty"})
+	case utf8.RuneCountInString(item.Name) > MaxNameLength:
+		details = append(details, FieldError{Field: "name", Message: fmt.Sprintf("must be at most %d characters", MaxNameLength)})
+	}
+
+	if utf8.RuneCountInString(item.Desc) > MaxTextLength {
+		details = append(details, FieldError{Field: "description", Message: fmt.Sprintf("must be at most %d characters", MaxTextLength)})
+	}
+
+	if !slices.Contains(statuses, item.Status) {
+		details = append(details, FieldError{Field: "status", Message: "must be one of: " + strings.Join(statuses, ", ")})
+	}
+
+	if len(item.Assignee) > MaxUserIDBytes {
+		details = append(details, FieldError{Field: "assignee", Message: fmt.Sprintf("must be at most %d bytes", MaxUserIDBytes)})
+	}
+
+	return details
+}
+
+func validateComment(comment store.Comment) []FieldError {
+	var details []FieldError
+
+	switch {
+	case strings.TrimSpace(comment.Text) == "":
+		details = append(details, FieldError{Field: "text", Message: "must not be empty"})
+	case utf8.RuneCountInString(comment.Text) > MaxTextLength:
+		details = append(details, FieldError{Field: "text", Message: fmt.Sprintf("must be at most %d characters", MaxTextLength)})
+	}
+
+	if len(comment.Author) > MaxUserIDBytes {
+		details = append(details, FieldError{Field: "author", Message: fmt.Sprintf("must be at most %d bytes", MaxUserIDBytes)})
+	}
+
+	return details
+}
+
+func validateWebhook(webhook store.Webhook) []FieldError {
+	var details []FieldError
+
+	if u, err := url.Parse(webhook.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		details = append(details, FieldError{Field: "url", Message: "must be an absolute http or https URL"})
+	}
+
+	for i, event := range webhook.Events {
+		if !slices.Contains(eventTypes, event) {
+			details = append(details, FieldError{Field: fmt.Sprintf("events[%d]", i), Message: "must be one of: " + strings.Join(eventTypes, ", ")})
+		}
+	}
+
+	switch {
+	case webhook.Secret == "":
+		details = append(details, FieldError{Field: "secret", Message: "must not be empty"})
+	case len(webhook.Secret) > MaxSecretBytes:
+		details = append(details, FieldError{Field: "secret", Message: fmt.Sprintf("must be at most %d bytes", MaxSecretBytes)})
+	}
+
+	return details
+}
+
+func validateBatch(batch BatchRequest) []FieldError {
+	switch {
+	case len(batch.Operations) == 0:
+		return []FieldError{{Field: "operations", Message: "must not be empty"}}
+	case len(batch.Operations) > MaxBatchSize:
+		return []FieldError{{Field: "operations", Message: fmt.Sprintf("must contain at most %d operations", MaxBatchSize)}}
+	}
+
+	var details []FieldError
+	for i, operation := range batch.Operations {
+		field := fmt.Sprintf("operations[%d]", i)
+
+		switch operation.Op {
+		case store.OpCreate, store.OpUpdate, store.OpDelete:
+		default:
+			details = append(details, FieldError{Field: field + ".op", Message: "must be one of: create, update, delete"})
+			continue
+		}
+
+		if operation.Op != store.OpCreate && operation.ID == "" {
+			details = append(details, FieldError{Field: field + ".id", Message: "must not be empty"})
+		}
+
+		if operation.Op == store.OpDelete {
+			continue
+		}
+
+		if operation.Item == nil {
+			details = append(details, FieldError{Field: field + ".item", Message: "must not be empty"})
+			continue
+		}
+
+		for _, detail := range validateItem(*operation.Item) {
+			details = append(details, FieldError{Field: field + ".item." + detail.Field, Message: detail.Message})
+		}
+	}
+
+	return details
+}
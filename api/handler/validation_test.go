@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"to-do-app-v2/internal/store"
+)
+
+func serveCreateItem(body string) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/items", strings.NewReader(body))
+	NewHandler(nil).HandleCreateItem(recorder, req)
+	return recorder
+}
+
+func decodeProblem(t *testing.T, recorder *httptest.ResponseRecorder) Problem {
+	assert.Equal(t, ProblemContentType, recorder.Header().Get("Content-Type"))
+	var problem Problem
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&problem))
+	return problem
+}
+
+func Test_HandleCreateItem_RejectsUnknownFields(t *testing.T) {
+	recorder := serveCreateItem(`{"name": "name1", "status": "started", "priority": 1}`)
+
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.Contains(t, decodeProblem(t, recorder).Detail, "priority")
+}
+
+func Test_HandleCreateItem_RejectsTrailingData(t *testing.T) {
+	recorder := serveCreateItem(`{"name": "name1", "status": "started"} {}`)
+
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+}
+
+func Test_HandleCreateItem_RejectsLargeBody(t *testing.T) {
+	recorder := serveCreateItem(`{"name": "name1", "status": "started", "description": "` + strings.Repeat("a", MaxBodyBytes) + `"}`)
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, recorder.Code)
+}
+
+func Test_HandleCreateItem_ReturnsFieldErrors(t *testing.T) {
+	recorder := serveCreateItem(`{"name": " ", "status": "done"}`)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+	problem := decodeProblem(t, recorder)
+	assert.Equal(t, ProblemTypeValidation, problem.Type)
+	assert.Equal(t, http.StatusUnprocessableEntity, problem.Status)
+	assert.ElementsMatch(t, []FieldError{
+		{Field: "name", Message: "must not be empty"},
+		{Field: "status", Message: "must be one of: not started, started, completed"},
+	}, problem.Errors)
+}
+
+func Test_DecodeJSON_DecodesValidBody(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/items", strings.NewReader(`{"name": "name1", "status": "not started"}`))
+
+	var item struct {
+		Name   string `json:"name"`
+		Status string `json:"status"`
+	}
+	statusCode, err := decodeJSON(recorder, req, &item)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, statusCode)
+	assert.Equal(t, "name1", item.Name)
+}
+
+func Test_ValidateItem_AcceptsValidItem(t *testing.T) {
+	details := validateItem(store.Item{Name: "name1", Desc: "desc1", Status: store.StatusStarted, Assignee: "user1"})
+
+	assert.Empty(t, details)
+}
+
+func Test_ValidateBatch_ReturnsIndexedFieldErrors(t *testing.T) {
+	details := validateBatch(BatchRequest{Operations: []store.Operation{
+		{Op: store.OpCreate, Item: &store.Item{Name: "name1", Status: store.StatusStarted}},
+		{Op: store.OpUpdate, Item: &store.Item{Name: "", Status: store.StatusStarted}},
+		{Op: store.OpDelete},
+		{Op: "move", ID: "id1"},
+	}})
+
+	assert.ElementsMatch(t, []FieldError{
+		{Field: "operations[1].id", Message: "must not be empty"},
+		{Field: "operations[1].item.name", Message: "must not be empty"},
+		{Field: "operations[2].id", Message: "must not be empty"},
+		{Field: "operations[3].op", Message: "must be one of: create, update, delete"},
+	}, details)
+}
+
+func Test_ValidateBatch_RejectsEmptyBatch(t *testing.T) {
+	details := validateBatch(BatchRequest{})
+
+	assert.Equal(t, []FieldError{{Field: "operations", Message: "must not be empty"}}, details)
+}
@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"to-do-app-v2/internal/store"
+)
+
+func (h *Handler) HandleCreateWebhook(w http.ResponseWriter, r *http.Request) {
+	var webhook store.Webhook
+	if statusCode, err := decodeJSON(w, r, &webhook); err != nil {
+		slog.ErrorContext(r.Context(), err.Error())
+		h.problemResponse(w, r, statusCode, err.Error())
+		return
+	}
+
+	if details := validateWebhook(webhook); len(details) > 0 {
+		h.validationErrorResponse(w, r, details)
+		return
+	}
+
+	webhook.ID = ""
+	newWebhook, err := h.service.AddWebhook(webhook)
+	if err != nil {
+		h.serviceErrorResponse(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(redacted(newWebhook)); err != nil {
+		h.serviceErrorResponse(w, r, err)
+		return
+	}
+}
+
+func (h *Handler) HandleGetWebhooks(w http.ResponseWriter, r *http.Request) {
+	webhooks, err := h.service.ReadWebhooks()
+	if err != nil {
+		h.serviceErrorResponse(w, r, err)
+		return
+	}
+
+	for i := range webhooks {
+		webhooks[i] = redacted(webhooks[i])
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(webhooks); err != nil {
+		h.serviceErrorResponse(w, r, err)
+		return
+	}
+}
+
+func (h *Handler) HandleDeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.DeleteWebhook(r.PathValue("id")); err != nil {
+		h.serviceErrorResponse(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) HandleGetDeliveries(w http.ResponseWriter, r *http.Request) {
+	deliveries, err := h.service.ReadDeliveries(r.PathValue("id"))
+	if err != nil {
+		h.serviceErrorResponse(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(deliveries); err != nil {
+		h.serviceErrorResponse(w, r, err)
+		return
+	}
+}
+
+// redacted hides the webhook secret, it is only known to whoever registered the webhook.
+func redacted(webhook store.Webhook) store.Webhook {
+	webhook.Secret = ""
+	return webhook
+}
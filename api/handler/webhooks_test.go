@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"to-do-app-v2/internal/store"
+)
+
+func Test_HandleCreateWebhook_HidesSecret(t *testing.T) {
+	itemStore := newTestStore(t)
+	recorder := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(`{"url": "https://example.com/hook", "events": ["create"], "secret": "secret"}`))
+
+	NewHandler(itemStore).HandleCreateWebhook(recorder, req)
+
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	var webhook store.Webhook
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&webhook))
+	assert.NotEmpty(t, webhook.ID)
+	assert.Empty(t, webhook.Secret)
+	webhooks, err := itemStore.ReadWebhooks()
+	require.NoError(t, err)
+	assert.Equal(t, "secret", webhooks[0].Secret)
+}
+
+func Test_HandleCreateWebhook_ReturnsFieldErrors(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(`{"url": "example.com", "events": ["created"]}`))
+
+	NewHandler(nil).HandleCreateWebhook(recorder, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+	assert.ElementsMatch(t, []FieldError{
+		{Field: "url", Message: "must be an absolute http or https URL"},
+		{Field: "events[0]", Message: "must be one of: create, update, delete, archive, unarchive, comment"},
+		{Field: "secret", Message: "must not be empty"},
+	}, decodeProblem(t, recorder).Errors)
+}
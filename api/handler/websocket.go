@@ -0,0 +1,209 @@
+package handler
+
+import (
+	"github.com/gorilla/websocket"
+	"github.com/pkg/errors"
+	"log/slog"
+	"net/http"
+	"time"
+	"to-do-app-v2/internal/store"
+)
+
+const (
+	LiveCreate   = "create"
+	LiveUpdate   = "update"
+	LiveDelete   = "delete"
+	LiveSnapshot = "snapshot"
+	LiveEvent    = "event"
+	LiveAck      = "ack"
+	LiveConflict = "conflict"
+	LiveError    = "error"
+)
+
+const (
+	LiveWriteTimeout = 10 * time.Second
+	LivePongTimeout  = 60 * time.Second
+	LivePingInterval = LivePongTimeout * 9 / 10
+	MaxLiveMessage   = MaxBodyBytes
+)
+
+// LiveMessage is a message on the live collaboration channel. Clients send create, update and delete messages,
+// the server answers each with an ack, conflict or error carrying the same request ID and forwards every item
+// change as an event. A delete carrying a version only deletes that version of the item.
+type LiveMessage struct {
+	Type      string       `json:"type"`
+	RequestID string       `json:"request_id,omitempty"`
+	ID        string       `json:"id,omitempty"`
+	Version   int64        `json:"version,omitempty"`
+	Item      *store.Item  `json:"item,omitempty"`
+	Items     []store.Item `json:"items,omitempty"`
+	Event     *store.Event `json:"event,omitempty"`
+	Error     string       `json:"error,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+}
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+}
+
+// HandleLiveItems upgrades the connection to a WebSocket that sends a snapshot of the item list, then carries
+// item mutations in both directions. Updates and deletes carrying a stale item version are answered with a
+// conflict holding the current item.
+func (h *Handler) HandleLiveItems(w http.ResponseWriter, r *http.Request) {
+	sub, err := h.service.Subscribe(0)
+	if err != nil {
+		h.serviceErrorResponse(w, r, err)
+		return
+	}
+	defer sub.Close()
+
+	items, err := h.service.ReadAll()
+	if err != nil {
+		h.serviceErrorResponse(w, r, err)
+		return
+	}
+
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		slog.ErrorContext(r.Context(), err.Error())
+		return
+	}
+	defer conn.Close()
+
+	replies := make(chan LiveMessage)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		h.readLiveMessages(r, conn, replies)
+	}()
+
+	if err := writeLiveMessage(conn, LiveMessage{Type: LiveSnapshot, Items: items}); err != nil {
+		slog.ErrorContext(r.Context(), err.Error())
+		return
+	}
+
+	ping := time.NewTicker(LivePingInterval)
+	defer ping.Stop()
+
+	for {
+		var message LiveMessage
+		select {
+		case <-done:
+			return
+		case <-ping.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(LiveWriteTimeout)); err != nil {
+				return
+			}
+			continue
+		case event, ok := <-sub.Events:
+			if !ok {
+				return
+			}
+			message = LiveMessage{Type: LiveEvent, Event: &event}
+		case message = <-replies:
+		}
+
+		if err := writeLiveMessage(conn, message); err != nil {
+			slog.ErrorContext(r.Context(), err.Error())
+			return
+		}
+	}
+}
+
+func (h *Handler) readLiveMessages(r *http.Request, conn *websocket.Conn, replies chan<- LiveMessage) {
+	conn.SetReadLimit(MaxLiveMessage)
+	_ = conn.SetReadDeadline(time.Now().Add(LivePongTimeout))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(LivePongTimeout))
+	})
+
+	for {
+		var message LiveMessage
+		if err := conn.ReadJSON(&message); err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				slog.ErrorContext(r.Context(), err.Error())
+			}
+			return
+		}
+
+		reply := h.applyLiveMessage(r, message)
+		reply.RequestID = message.RequestID
+		select {
+		case replies <- reply:
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func (h *Handler) applyLiveMessage(r *http.Request, message LiveMessage) LiveMessage {
+	switch message.Type {
+	case LiveCreate, LiveUpdate:
+		if message.Item == nil {
+			return LiveMessage{Type: LiveError, Error: "item is required"}
+		}
+		if details := validateItem(*message.Item); len(details) > 0 {
+			return LiveMessage{Type: LiveError, Errors: details}
+		}
+	case LiveDelete:
+	default:
+		return LiveMessage{Type: LiveError, Error: "unknown message type '" + message.Type + "'"}
+	}
+
+	var item store.Item
+	var err error
+	switch message.Type {
+	case LiveCreate:
+		newItem := *message.Item
+		newItem.ID = ""
+		item, err = h.service.Create(newItem)
+	case LiveUpdate:
+		item, err = h.service.Update(store.ItemID(message.ID), *message.Item)
+	case LiveDelete:
+		err = h.deleteVersion(store.ItemID(message.ID), message.Version)
+	}
+
+	switch {
+	case err == nil && message.Type == LiveDelete:
+		return LiveMessage{Type: LiveAck, ID: message.ID}
+	case err == nil:
+		return LiveMessage{Type: LiveAck, ID: item.ID, Item: &item}
+	case errors.Is(err, store.ErrVersionConflict):
+		slog.ErrorContext(r.Context(), err.Error())
+		current, readErr := h.service.Read(store.ItemID(message.ID))
+		if readErr != nil {
+			return liveError(r, message.ID, readErr)
+		}
+		_, detail := serviceError(err)
+		return LiveMessage{Type: LiveConflict, ID: message.ID, Item: &current, Error: detail}
+	default:
+		return liveError(r, message.ID, err)
+	}
+}
+
+// deleteVersion deletes the item, only at the version when it is not zero. The version is checked by the
+// service while deleting, as a batch of one operation.
+func (h *Handler) deleteVersion(id store.ItemID, version int64) error {
+	if version == 0 {
+		return h.service.Delete(id)
+	}
+
+	_, err := h.service.Batch([]store.Operation{{Op: store.OpDelete, ID: id, Item: &store.Item{Version: version}}})
+	return err
+}
+
+// liveError answers a service error with the same fixed detail as over HTTP.
+func liveError(r *http.Request, id string, err error) LiveMessage {
+	slog.ErrorContext(r.Context(), err.Error())
+
+	_, detail := serviceError(err)
+	return LiveMessage{Type: LiveError, ID: id, Error: detail}
+}
+
+func writeLiveMessage(conn *websocket.Conn, message LiveMessage) error {
+	if err := conn.SetWriteDeadline(time.Now().Add(LiveWriteTimeout)); err != nil {
+		return err
+	}
+	return conn.WriteJSON(message)
+}
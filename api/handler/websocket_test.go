@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"to-do-app-v2/internal/store"
+)
+
+func dialLive(t *testing.T, itemStore *store.Store) *websocket.Conn {
+	server := httptest.NewServer(http.HandlerFunc(NewHandler(itemStore).HandleLiveItems))
+	t.Cleanup(server.Close)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	return conn
+}
+
+func readLive(t *testing.T, conn *websocket.Conn, messageType string) LiveMessage {
+	for {
+		var message LiveMessage
+		require.NoError(t, conn.ReadJSON(&message))
+		if message.Type == messageType {
+			return message
+		}
+	}
+}
+
+func Test_HandleLiveItems_SendsSnapshot(t *testing.T) {
+	itemStore := newTestStore(t)
+	item, err := itemStore.Create(store.Item{Name: "name1", Status: store.StatusStarted})
+	require.NoError(t, err)
+
+	conn := dialLive(t, itemStore)
+
+	snapshot := readLive(t, conn, LiveSnapshot)
+	assert.Equal(t, []store.Item{item}, snapshot.Items)
+}
+
+func Test_HandleLiveItems_BroadcastsUpdates(t *testing.T) {
+	itemStore := newTestStore(t)
+	item, err := itemStore.Create(store.Item{Name: "name1", Status: store.StatusStarted})
+	require.NoError(t, err)
+	editor := dialLive(t, itemStore)
+	viewer := dialLive(t, itemStore)
+	readLive(t, editor, LiveSnapshot)
+	readLive(t, viewer, LiveSnapshot)
+
+	item.Name = "name2"
+	require.NoError(t, editor.WriteJSON(LiveMessage{Type: LiveUpdate, RequestID: "1", ID: item.ID, Item: &item}))
+
+	ack := readLive(t, editor, LiveAck)
+	assert.Equal(t, "1", ack.RequestID)
+	assert.Equal(t, int64(2), ack.Item.Version)
+	event := readLive(t, viewer, LiveEvent)
+	assert.Equal(t, store.EventUpdate, event.Event.Type)
+	assert.Equal(t, ack.Item, event.Event.Item)
+}
+
+func Test_HandleLiveItems_ReportsVersionConflict(t *testing.T) {
+	itemStore := newTestStore(t)
+	item, err := itemStore.Create(store.Item{Name: "name1", Status: store.StatusStarted})
+	require.NoError(t, err)
+	current, err := itemStore.Update(store.ItemID(item.ID), store.Item{Name: "name2", Status: store.StatusStarted})
+	require.NoError(t, err)
+	conn := dialLive(t, itemStore)
+	readLive(t, conn, LiveSnapshot)
+
+	item.Name = "name3"
+	require.NoError(t, conn.WriteJSON(LiveMessage{Type: LiveUpdate, RequestID: "1", ID: item.ID, Item: &item}))
+
+	conflict := readLive(t, conn, LiveConflict)
+	assert.Equal(t, "1", conflict.RequestID)
+	assert.Equal(t, &current, conflict.Item)
+}
+
+func Test_HandleLiveItems_RejectsInvalidItem(t *testing.T) {
+	itemStore := newTestStore(t)
+	conn := dialLive(t, itemStore)
+	readLive(t, conn, LiveSnapshot)
+
+	require.NoError(t, conn.WriteJSON(LiveMessage{Type: LiveCreate, RequestID: "1", Item: &store.Item{Status: store.StatusStarted}}))
+
+	reply := readLive(t, conn, LiveError)
+	assert.Equal(t, "1", reply.RequestID)
+	assert.Equal(t, "name", reply.Errors[0].Field)
+}
+
+func Test_HandleLiveItems_ReportsVersionConflictOnDelete(t *testing.T) {
+	itemStore := newTestStore(t)
+	item, err := itemStore.Create(store.Item{Name: "name1", Status: store.StatusStarted})
+	require.NoError(t, err)
+	current, err := itemStore.Update(store.ItemID(item.ID), store.Item{Name: "name2", Status: store.StatusStarted})
+	require.NoError(t, err)
+	conn := dialLive(t, itemStore)
+	readLive(t, conn, LiveSnapshot)
+
+	require.NoError(t, conn.WriteJSON(LiveMessage{Type: LiveDelete, RequestID: "1", ID: item.ID, Version: item.Version}))
+
+	conflict := readLive(t, conn, LiveConflict)
+	assert.Equal(t, "1", conflict.RequestID)
+	assert.Equal(t, &current, conflict.Item)
+	assert.NotContains(t, conflict.Error, item.ID)
+	_, err = itemStore.Read(store.ItemID(item.ID))
+	assert.NoError(t, err)
+
+	require.NoError(t, conn.WriteJSON(LiveMessage{Type: LiveDelete, RequestID: "2", ID: item.ID, Version: current.Version}))
+
+	ack := readLive(t, conn, LiveAck)
+	assert.Equal(t, "2", ack.RequestID)
+	_, err = itemStore.Read(store.ItemID(item.ID))
+	assert.ErrorIs(t, err, store.ErrNotFound)
+}
+
+func Test_HandleLiveItems_AnswersErrorsWithFixedDetail(t *testing.T) {
+	itemStore := newTestStore(t)
+	conn := dialLive(t, itemStore)
+	readLive(t, conn, LiveSnapshot)
+
+	require.NoError(t, conn.WriteJSON(LiveMessage{Type: LiveDelete, RequestID: "1", ID: "id1"}))
+
+	reply := readLive(t, conn, LiveError)
+	assert.Equal(t, "1", reply.RequestID)
+	assert.Equal(t, "the requested resource does not exist", reply.Error)
+}
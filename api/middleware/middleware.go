@@ -6,7 +6,15 @@ import (
 	"net/http"
 )
 
-const TraceIDHeader = "TraceID"
+const (
+	TraceIDHeader = "TraceID"
+	UserIDHeader  = "UserID"
+)
+
+// contextKey keys the values the middleware puts in the request context, no other package can collide with it.
+type contextKey int
+
+const userIDKey contextKey = iota
 
 func TraceIDMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -20,3 +28,26 @@ func TraceIDMiddleware(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
+
+func UserIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID := r.Header.Get(UserIDHeader)
+		if userID == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(WithUserID(r.Context(), userID)))
+	})
+}
+
+// WithUserID returns a copy of ctx carrying the ID of the calling user.
+func WithUserID(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, userIDKey, userID)
+}
+
+// UserID returns the ID of the calling user, it reports false when the request named no user.
+func UserID(ctx context.Context) (string, bool) {
+	userID, ok := ctx.Value(userIDKey).(string)
+	return userID, ok
+}
@@ -0,0 +1,4 @@
+// Package todopb holds the protobuf definition of the TodoService and its generated Go bindings.
+package todopb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative todo.proto
@@ -0,0 +1,1202 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.2
+// - protoc             (unknown)
+// source: todo.proto
+
+package todopb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	TodoService_Create_FullMethodName           = "/todo.v1.TodoService/Create"
+	TodoService_ReadAll_FullMethodName          = "/todo.v1.TodoService/ReadAll"
+	TodoService_Read_FullMethodName             = "/todo.v1.TodoService/Read"
+	TodoService_Update_FullMethodName           = "/todo.v1.TodoService/Update"
+	TodoService_Delete_FullMethodName           = "/todo.v1.TodoService/Delete"
+	TodoService_Assign_FullMethodName           = "/todo.v1.TodoService/Assign"
+	TodoService_ReadAssigned_FullMethodName     = "/todo.v1.TodoService/ReadAssigned"
+	TodoService_AddComment_FullMethodName       = "/todo.v1.TodoService/AddComment"
+	TodoService_ReadComments_FullMethodName     = "/todo.v1.TodoService/ReadComments"
+	TodoService_ReadHistory_FullMethodName      = "/todo.v1.TodoService/ReadHistory"
+	TodoService_Restore_FullMethodName          = "/todo.v1.TodoService/Restore"
+	TodoService_ReadTrash_FullMethodName        = "/todo.v1.TodoService/ReadTrash"
+	TodoService_RestoreDeleted_FullMethodName   = "/todo.v1.TodoService/RestoreDeleted"
+	TodoService_Archive_FullMethodName          = "/todo.v1.TodoService/Archive"
+	TodoService_ArchiveCompleted_FullMethodName = "/todo.v1.TodoService/ArchiveCompleted"
+	TodoService_ReadArchived_FullMethodName     = "/todo.v1.TodoService/ReadArchived"
+	TodoService_Unarchive_FullMethodName        = "/todo.v1.TodoService/Unarchive"
+	TodoService_Batch_FullMethodName            = "/todo.v1.TodoService/Batch"
+	TodoService_Undo_FullMethodName             = "/todo.v1.TodoService/Undo"
+	TodoService_Redo_FullMethodName             = "/todo.v1.TodoService/Redo"
+	TodoService_Export_FullMethodName           = "/todo.v1.TodoService/Export"
+	TodoService_Import_FullMethodName           = "/todo.v1.TodoService/Import"
+	TodoService_Evict_FullMethodName            = "/todo.v1.TodoService/Evict"
+	TodoService_AddWebhook_FullMethodName       = "/todo.v1.TodoService/AddWebhook"
+	TodoService_ReadWebhooks_FullMethodName     = "/todo.v1.TodoService/ReadWebhooks"
+	TodoService_DeleteWebhook_FullMethodName    = "/todo.v1.TodoService/DeleteWebhook"
+	TodoService_ReadDeliveries_FullMethodName   = "/todo.v1.TodoService/ReadDeliveries"
+	TodoService_Watch_FullMethodName            = "/todo.v1.TodoService/Watch"
+	TodoService_Promote_FullMethodName          = "/todo.v1.TodoService/Promote"
+)
+
+// TodoServiceClient is the client API for TodoService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// TodoService exposes the item store over gRPC, mirroring handler.Service plus the store operations used
+// by the command line front ends.
+type TodoServiceClient interface {
+	Create(ctx context.Context, in *CreateRequest, opts ...grpc.CallOption) (*CreateResponse, error)
+	ReadAll(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*Items, error)
+	Read(ctx context.Context, in *ItemRef, opts ...grpc.CallOption) (*Item, error)
+	Update(ctx context.Context, in *UpdateRequest, opts ...grpc.CallOption) (*Item, error)
+	Delete(ctx context.Context, in *ItemRef, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	Assign(ctx context.Context, in *AssignRequest, opts ...grpc.CallOption) (*Item, error)
+	ReadAssigned(ctx context.Context, in *ReadAssignedRequest, opts ...grpc.CallOption) (*Items, error)
+	AddComment(ctx context.Context, in *AddCommentRequest, opts ...grpc.CallOption) (*Comment, error)
+	ReadComments(ctx context.Context, in *ItemRef, opts ...grpc.CallOption) (*Comments, error)
+	ReadHistory(ctx context.Context, in *ItemRef, opts ...grpc.CallOption) (*Activities, error)
+	Restore(ctx context.Context, in *RestoreRequest, opts ...grpc.CallOption) (*Items, error)
+	ReadTrash(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*TrashedItems, error)
+	RestoreDeleted(ctx context.Context, in *ItemRef, opts ...grpc.CallOption) (*Item, error)
+	Archive(ctx context.Context, in *ItemRef, opts ...grpc.CallOption) (*Item, error)
+	ArchiveCompleted(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*Items, error)
+	ReadArchived(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*Items, error)
+	Unarchive(ctx context.Context, in *ItemRef, opts ...grpc.CallOption) (*Item, error)
+	Batch(ctx context.Context, in *BatchRequest, opts ...grpc.CallOption) (*BatchResponse, error)
+	Undo(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	Redo(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// Export, Import and Evict move items with their comments and history between stores.
+	Export(ctx context.Context, in *ItemRefs, opts ...grpc.CallOption) (*ItemRecords, error)
+	Import(ctx context.Context, in *ItemRecords, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	Evict(ctx context.Context, in *ItemRefs, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	AddWebhook(ctx context.Context, in *Webhook, opts ...grpc.CallOption) (*Webhook, error)
+	ReadWebhooks(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*Webhooks, error)
+	DeleteWebhook(ctx context.Context, in *WebhookRef, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	ReadDeliveries(ctx context.Context, in *WebhookRef, opts ...grpc.CallOption) (*Deliveries, error)
+	// Watch streams item changes, a non-zero last_event_id first replays the buffered events after it.
+	Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Event], error)
+	// Promote makes a follower stop following its leader and take writes.
+	Promote(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*emptypb.Empty, error)
+}
+
+type todoServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewTodoServiceClient(cc grpc.ClientConnInterface) TodoServiceClient {
+	return &todoServiceClient{cc}
+}
+
+func (c *todoServiceClient) Create(ctx context.Context, in *CreateRequest, opts ...grpc.CallOption) (*CreateResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CreateResponse)
+	err := c.cc.Invoke(ctx, TodoService_Create_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *todoServiceClient) ReadAll(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*Items, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Items)
+	err := c.cc.Invoke(ctx, TodoService_ReadAll_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *todoServiceClient) Read(ctx context.Context, in *ItemRef, opts ...grpc.CallOption) (*Item, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Item)
+	err := c.cc.Invoke(ctx, TodoService_Read_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *todoServiceClient) Update(ctx context.Context, in *UpdateRequest, opts ...grpc.CallOption) (*Item, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Item)
+	err := c.cc.Invoke(ctx, TodoService_Update_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *todoServiceClient) Delete(ctx context.Context, in *ItemRef, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, TodoService_Delete_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *todoServiceClient) Assign(ctx context.Context, in *AssignRequest, opts ...grpc.CallOption) (*Item, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Item)
+	err := c.cc.Invoke(ctx, TodoService_Assign_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *todoServiceClient) ReadAssigned(ctx context.Context, in *ReadAssignedRequest, opts ...grpc.CallOption) (*Items, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Items)
+	err := c.cc.Invoke(ctx, TodoService_ReadAssigned_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *todoServiceClient) AddComment(ctx context.Context, in *AddCommentRequest, opts ...grpc.CallOption) (*Comment, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Comment)
+	err := c.cc.Invoke(ctx, TodoService_AddComment_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *todoServiceClient) ReadComments(ctx context.Context, in *ItemRef, opts ...grpc.CallOption) (*Comments, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Comments)
+	err := c.cc.Invoke(ctx, TodoService_ReadComments_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *todoServiceClient) ReadHistory(ctx context.Context, in *ItemRef, opts ...grpc.CallOption) (*Activities, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Activities)
+	err := c.cc.Invoke(ctx, TodoService_ReadHistory_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *todoServiceClient) Restore(ctx context.Context, in *RestoreRequest, opts ...grpc.CallOption) (*Items, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Items)
+	err := c.cc.Invoke(ctx, TodoService_Restore_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *todoServiceClient) ReadTrash(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*TrashedItems, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TrashedItems)
+	err := c.cc.Invoke(ctx, TodoService_ReadTrash_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *todoServiceClient) RestoreDeleted(ctx context.Context, in *ItemRef, opts ...grpc.CallOption) (*Item, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Item)
+	err := c.cc.Invoke(ctx, TodoService_RestoreDeleted_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *todoServiceClient) Archive(ctx context.Context, in *ItemRef, opts ...grpc.CallOption) (*Item, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Item)
+	err := c.cc.Invoke(ctx, TodoService_Archive_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *todoServiceClient) ArchiveCompleted(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*Items, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Items)
+	err := c.cc.Invoke(ctx, TodoService_ArchiveCompleted_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *todoServiceClient) ReadArchived(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*Items, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Items)
+	err := c.cc.Invoke(ctx, TodoService_ReadArchived_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *todoServiceClient) Unarchive(ctx context.Context, in *ItemRef, opts ...grpc.CallOption) (*Item, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Item)
+	err := c.cc.Invoke(ctx, TodoService_Unarchive_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *todoServiceClient) Batch(ctx context.Context, in *BatchRequest, opts ...grpc.CallOption) (*BatchResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BatchResponse)
+	err := c.cc.Invoke(ctx, TodoService_Batch_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *todoServiceClient) Undo(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, TodoService_Undo_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *todoServiceClient) Redo(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, TodoService_Redo_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *todoServiceClient) Export(ctx context.Context, in *ItemRefs, opts ...grpc.CallOption) (*ItemRecords, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ItemRecords)
+	err := c.cc.Invoke(ctx, TodoService_Export_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *todoServiceClient) Import(ctx context.Context, in *ItemRecords, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, TodoService_Import_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *todoServiceClient) Evict(ctx context.Context, in *ItemRefs, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, TodoService_Evict_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *todoServiceClient) AddWebhook(ctx context.Context, in *Webhook, opts ...grpc.CallOption) (*Webhook, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Webhook)
+	err := c.cc.Invoke(ctx, TodoService_AddWebhook_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *todoServiceClient) ReadWebhooks(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*Webhooks, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Webhooks)
+	err := c.cc.Invoke(ctx, TodoService_ReadWebhooks_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *todoServiceClient) DeleteWebhook(ctx context.Context, in *WebhookRef, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, TodoService_DeleteWebhook_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *todoServiceClient) ReadDeliveries(ctx context.Context, in *WebhookRef, opts ...grpc.CallOption) (*Deliveries, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Deliveries)
+	err := c.cc.Invoke(ctx, TodoService_ReadDeliveries_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *todoServiceClient) Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Event], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &TodoService_ServiceDesc.Streams[0], TodoService_Watch_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchRequest, Event]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type TodoService_WatchClient = grpc.ServerStreamingClient[Event]
+
+func (c *todoServiceClient) Promote(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, TodoService_Promote_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// TodoServiceServer is the server API for TodoService service.
+// All implementations must embed UnimplementedTodoServiceServer
+// for forward compatibility.
+//
+// TodoService exposes the item store over gRPC, mirroring handler.Service plus the store operations used
+// by the command line front ends.
+type TodoServiceServer interface {
+	Create(context.Context, *CreateRequest) (*CreateResponse, error)
+	ReadAll(context.Context, *emptypb.Empty) (*Items, error)
+	Read(context.Context, *ItemRef) (*Item, error)
+	Update(context.Context, *UpdateRequest) (*Item, error)
+	Delete(context.Context, *ItemRef) (*emptypb.Empty, error)
+	Assign(context.Context, *AssignRequest) (*Item, error)
+	ReadAssigned(context.Context, *ReadAssignedRequest) (*Items, error)
+	AddComment(context.Context, *AddCommentRequest) (*Comment, error)
+	ReadComments(context.Context, *ItemRef) (*Comments, error)
+	ReadHistory(context.Context, *ItemRef) (*Activities, error)
+	Restore(context.Context, *RestoreRequest) (*Items, error)
+	ReadTrash(context.Context, *emptypb.Empty) (*TrashedItems, error)
+	RestoreDeleted(context.Context, *ItemRef) (*Item, error)
+	Archive(context.Context, *ItemRef) (*Item, error)
+	ArchiveCompleted(context.Context, *emptypb.Empty) (*Items, error)
+	ReadArchived(context.Context, *emptypb.Empty) (*Items, error)
+	Unarchive(context.Context, *ItemRef) (*Item, error)
+	Batch(context.Context, *BatchRequest) (*BatchResponse, error)
+	Undo(context.Context, *emptypb.Empty) (*emptypb.Empty, error)
+	Redo(context.Context, *emptypb.Empty) (*emptypb.Empty, error)
+	// Export, Import and Evict move items with their comments and history between stores.
+	Export(context.Context, *ItemRefs) (*ItemRecords, error)
+	Import(context.Context, *ItemRecords) (*emptypb.Empty, error)
+	Evict(context.Context, *ItemRefs) (*emptypb.Empty, error)
+	AddWebhook(context.Context, *Webhook) (*Webhook, error)
+	ReadWebhooks(context.Context, *emptypb.Empty) (*Webhooks, error)
+	DeleteWebhook(context.Context, *WebhookRef) (*emptypb.Empty, error)
+	ReadDeliveries(context.Context, *WebhookRef) (*Deliveries, error)
+	// Watch streams item changes, a non-zero last_event_id first replays the buffered events after it.
+	Watch(*WatchRequest, grpc.ServerStreamingServer[Event]) error
+	// Promote makes a follower stop following its leader and take writes.
+	Promote(context.Context, *emptypb.Empty) (*emptypb.Empty, error)
+	mustEmbedUnimplementedTodoServiceServer()
+}
+
+// UnimplementedTodoServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedTodoServiceServer struct{}
+
+func (UnimplementedTodoServiceServer) Create(context.Context, *CreateRequest) (*CreateResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method Create not implemented")
+}
+func (UnimplementedTodoServiceServer) ReadAll(context.Context, *emptypb.Empty) (*Items, error) {
+	return nil, status.Error(codes.Unimplemented, "method ReadAll not implemented")
+}
+func (UnimplementedTodoServiceServer) Read(context.Context, *ItemRef) (*Item, error) {
+	return nil, status.Error(codes.Unimplemented, "method Read not implemented")
+}
+func (UnimplementedTodoServiceServer) Update(context.Context, *UpdateRequest) (*Item, error) {
+	return nil, status.Error(codes.Unimplemented, "method Update not implemented")
+}
+func (UnimplementedTodoServiceServer) Delete(context.Context, *ItemRef) (*emptypb.Empty, error) {
+	return nil, status.Error(codes.Unimplemented, "method Delete not implemented")
+}
+func (UnimplementedTodoServiceServer) Assign(context.Context, *AssignRequest) (*Item, error) {
+	return nil, status.Error(codes.Unimplemented, "method Assign not implemented")
+}
+func (UnimplementedTodoServiceServer) ReadAssigned(context.Context, *ReadAssignedRequest) (*Items, error) {
+	return nil, status.Error(codes.Unimplemented, "method ReadAssigned not implemented")
+}
+func (UnimplementedTodoServiceServer) AddComment(context.Context, *AddCommentRequest) (*Comment, error) {
+	return nil, status.Error(codes.Unimplemented, "method AddComment not implemented")
+}
+func (UnimplementedTodoServiceServer) ReadComments(context.Context, *ItemRef) (*Comments, error) {
+	return nil, status.Error(codes.Unimplemented, "method ReadComments not implemented")
+}
+func (UnimplementedTodoServiceServer) ReadHistory(context.Context, *ItemRef) (*Activities, error) {
+	return nil, status.Error(codes.Unimplemented, "method ReadHistory not implemented")
+}
+func (UnimplementedTodoServiceServer) Restore(context.Context, *RestoreRequest) (*Items, error) {
+	return nil, status.Error(codes.Unimplemented, "method Restore not implemented")
+}
+func (UnimplementedTodoServiceServer) ReadTrash(context.Context, *emptypb.Empty) (*TrashedItems, error) {
+	return nil, status.Error(codes.Unimplemented, "method ReadTrash not implemented")
+}
+func (UnimplementedTodoServiceServer) RestoreDeleted(context.Context, *ItemRef) (*Item, error) {
+	return nil, status.Error(codes.Unimplemented, "method RestoreDeleted not implemented")
+}
+func (UnimplementedTodoServiceServer) Archive(context.Context, *ItemRef) (*Item, error) {
+	return nil, status.Error(codes.Unimplemented, "method Archive not implemented")
+}
+func (UnimplementedTodoServiceServer) ArchiveCompleted(context.Context, *emptypb.Empty) (*Items, error) {
+	return nil, status.Error(codes.Unimplemented, "method ArchiveCompleted not implemented")
+}
+func (UnimplementedTodoServiceServer) ReadArchived(context.Context, *emptypb.Empty) (*Items, error) {
+	return nil, status.Error(codes.Unimplemented, "method ReadArchived not implemented")
+}
+func (UnimplementedTodoServiceServer) Unarchive(context.Context, *ItemRef) (*Item, error) {
+	return nil, status.Error(codes.Unimplemented, "method Unarchive not implemented")
+}
+func (UnimplementedTodoServiceServer) Batch(context.Context, *BatchRequest) (*BatchResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method Batch not implemented")
+}
+func (UnimplementedTodoServiceServer) Undo(context.Context, *emptypb.Empty) (*emptypb.Empty, error) {
+	return nil, status.Error(codes.Unimplemented, "method Undo not implemented")
+}
+func (UnimplementedTodoServiceServer) Redo(context.Context, *emptypb.Empty) (*emptypb.Empty, error) {
+	return nil, status.Error(codes.Unimplemented, "method Redo not implemented")
+}
+func (UnimplementedTodoServiceServer) Export(context.Context, *ItemRefs) (*ItemRecords, error) {
+	return nil, status.Error(codes.Unimplemented, "method Export not implemented")
+}
+func (UnimplementedTodoServiceServer) Import(context.Context, *ItemRecords) (*emptypb.Empty, error) {
+	return nil, status.Error(codes.Unimplemented, "method Import not implemented")
+}
+func (UnimplementedTodoServiceServer) Evict(context.Context, *ItemRefs) (*emptypb.Empty, error) {
+	return nil, status.Error(codes.Unimplemented, "method Evict not implemented")
+}
+func (UnimplementedTodoServiceServer) AddWebhook(context.Context, *Webhook) (*Webhook, error) {
+	return nil, status.Error(codes.Unimplemented, "method AddWebhook not implemented")
+}
+func (UnimplementedTodoServiceServer) ReadWebhooks(context.Context, *emptypb.Empty) (*Webhooks, error) {
+	return nil, status.Error(codes.Unimplemented, "method ReadWebhooks not implemented")
+}
+func (UnimplementedTodoServiceServer) DeleteWebhook(context.Context, *WebhookRef) (*emptypb.Empty, error) {
+	return nil, status.Error(codes.Unimplemented, "method DeleteWebhook not implemented")
+}
+func (UnimplementedTodoServiceServer) ReadDeliveries(context.Context, *WebhookRef) (*Deliveries, error) {
+	return nil, status.Error(codes.Unimplemented, "method ReadDeliveries not implemented")
+}
+func (UnimplementedTodoServiceServer) Watch(*WatchRequest, grpc.ServerStreamingServer[Event]) error {
+	return status.Error(codes.Unimplemented, "method Watch not implemented")
+}
+func (UnimplementedTodoServiceServer) Promote(context.Context, *emptypb.Empty) (*emptypb.Empty, error) {
+	return nil, status.Error(codes.Unimplemented, "method Promote not implemented")
+}
+func (UnimplementedTodoServiceServer) mustEmbedUnimplementedTodoServiceServer() {}
+func (UnimplementedTodoServiceServer) testEmbeddedByValue()                     {}
+
+// UnsafeTodoServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to TodoServiceServer will
+// result in compilation errors.
+type UnsafeTodoServiceServer interface {
+	mustEmbedUnimplementedTodoServiceServer()
+}
+
+func RegisterTodoServiceServer(s grpc.ServiceRegistrar, srv TodoServiceServer) {
+	// If the following call panics, it indicates UnimplementedTodoServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&TodoService_ServiceDesc, srv)
+}
+
+func _TodoService_Create_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TodoServiceServer).Create(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TodoService_Create_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TodoServiceServer).Create(ctx, req.(*CreateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TodoService_ReadAll_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TodoServiceServer).ReadAll(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TodoService_ReadAll_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TodoServiceServer).ReadAll(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TodoService_Read_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ItemRef)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TodoServiceServer).Read(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TodoService_Read_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TodoServiceServer).Read(ctx, req.(*ItemRef))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TodoService_Update_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TodoServiceServer).Update(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TodoService_Update_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TodoServiceServer).Update(ctx, req.(*UpdateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TodoService_Delete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ItemRef)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TodoServiceServer).Delete(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TodoService_Delete_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TodoServiceServer).Delete(ctx, req.(*ItemRef))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TodoService_Assign_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AssignRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TodoServiceServer).Assign(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TodoService_Assign_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TodoServiceServer).Assign(ctx, req.(*AssignRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TodoService_ReadAssigned_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReadAssignedRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TodoServiceServer).ReadAssigned(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TodoService_ReadAssigned_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TodoServiceServer).ReadAssigned(ctx, req.(*ReadAssignedRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TodoService_AddComment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddCommentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TodoServiceServer).AddComment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TodoService_AddComment_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TodoServiceServer).AddComment(ctx, req.(*AddCommentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TodoService_ReadComments_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ItemRef)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TodoServiceServer).ReadComments(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TodoService_ReadComments_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TodoServiceServer).ReadComments(ctx, req.(*ItemRef))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TodoService_ReadHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ItemRef)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TodoServiceServer).ReadHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TodoService_ReadHistory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TodoServiceServer).ReadHistory(ctx, req.(*ItemRef))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TodoService_Restore_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RestoreRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TodoServiceServer).Restore(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TodoService_Restore_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TodoServiceServer).Restore(ctx, req.(*RestoreRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TodoService_ReadTrash_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TodoServiceServer).ReadTrash(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TodoService_ReadTrash_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TodoServiceServer).ReadTrash(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TodoService_RestoreDeleted_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ItemRef)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TodoServiceServer).RestoreDeleted(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TodoService_RestoreDeleted_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TodoServiceServer).RestoreDeleted(ctx, req.(*ItemRef))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TodoService_Archive_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ItemRef)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TodoServiceServer).Archive(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TodoService_Archive_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TodoServiceServer).Archive(ctx, req.(*ItemRef))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TodoService_ArchiveCompleted_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TodoServiceServer).ArchiveCompleted(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TodoService_ArchiveCompleted_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TodoServiceServer).ArchiveCompleted(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TodoService_ReadArchived_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TodoServiceServer).ReadArchived(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TodoService_ReadArchived_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TodoServiceServer).ReadArchived(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TodoService_Unarchive_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ItemRef)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TodoServiceServer).Unarchive(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TodoService_Unarchive_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TodoServiceServer).Unarchive(ctx, req.(*ItemRef))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TodoService_Batch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TodoServiceServer).Batch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TodoService_Batch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TodoServiceServer).Batch(ctx, req.(*BatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TodoService_Undo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TodoServiceServer).Undo(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TodoService_Undo_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TodoServiceServer).Undo(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TodoService_Redo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TodoServiceServer).Redo(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TodoService_Redo_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TodoServiceServer).Redo(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TodoService_Export_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ItemRefs)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TodoServiceServer).Export(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TodoService_Export_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TodoServiceServer).Export(ctx, req.(*ItemRefs))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TodoService_Import_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ItemRecords)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TodoServiceServer).Import(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TodoService_Import_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TodoServiceServer).Import(ctx, req.(*ItemRecords))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TodoService_Evict_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ItemRefs)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TodoServiceServer).Evict(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TodoService_Evict_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TodoServiceServer).Evict(ctx, req.(*ItemRefs))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TodoService_AddWebhook_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Webhook)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TodoServiceServer).AddWebhook(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TodoService_AddWebhook_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TodoServiceServer).AddWebhook(ctx, req.(*Webhook))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TodoService_ReadWebhooks_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TodoServiceServer).ReadWebhooks(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TodoService_ReadWebhooks_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TodoServiceServer).ReadWebhooks(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TodoService_DeleteWebhook_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(WebhookRef)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TodoServiceServer).DeleteWebhook(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TodoService_DeleteWebhook_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TodoServiceServer).DeleteWebhook(ctx, req.(*WebhookRef))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TodoService_ReadDeliveries_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(WebhookRef)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TodoServiceServer).ReadDeliveries(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TodoService_ReadDeliveries_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TodoServiceServer).ReadDeliveries(ctx, req.(*WebhookRef))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TodoService_Watch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(TodoServiceServer).Watch(m, &grpc.GenericServerStream[WatchRequest, Event]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type TodoService_WatchServer = grpc.ServerStreamingServer[Event]
+
+func _TodoService_Promote_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TodoServiceServer).Promote(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TodoService_Promote_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TodoServiceServer).Promote(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// TodoService_ServiceDesc is the grpc.ServiceDesc for TodoService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var TodoService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "todo.v1.TodoService",
+	HandlerType: (*TodoServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Create",
+			Handler:    _TodoService_Create_Handler,
+		},
+		{
+			MethodName: "ReadAll",
+			Handler:    _TodoService_ReadAll_Handler,
+		},
+		{
+			MethodName: "Read",
+			Handler:    _TodoService_Read_Handler,
+		},
+		{
+			MethodName: "Update",
+			Handler:    _TodoService_Update_Handler,
+		},
+		{
+			MethodName: "Delete",
+			Handler:    _TodoService_Delete_Handler,
+		},
+		{
+			MethodName: "Assign",
+			Handler:    _TodoService_Assign_Handler,
+		},
+		{
+			MethodName: "ReadAssigned",
+			Handler:    _TodoService_ReadAssigned_Handler,
+		},
+		{
+			MethodName: "AddComment",
+			Handler:    _TodoService_AddComment_Handler,
+		},
+		{
+			MethodName: "ReadComments",
+			Handler:    _TodoService_ReadComments_Handler,
+		},
+		{
+			MethodName: "ReadHistory",
+			Handler:    _TodoService_ReadHistory_Handler,
+		},
+		{
+			MethodName: "Restore",
+			Handler:    _TodoService_Restore_Handler,
+		},
+		{
+			MethodName: "ReadTrash",
+			Handler:    _TodoService_ReadTrash_Handler,
+		},
+		{
+			MethodName: "RestoreDeleted",
+			Handler:    _TodoService_RestoreDeleted_Handler,
+		},
+		{
+			MethodName: "Archive",
+			Handler:    _TodoService_Archive_Handler,
+		},
+		{
+			MethodName: "ArchiveCompleted",
+			Handler:    _TodoService_ArchiveCompleted_Handler,
+		},
+		{
+			MethodName: "ReadArchived",
+			Handler:    _TodoService_ReadArchived_Handler,
+		},
+		{
+			MethodName: "Unarchive",
+			Handler:    _TodoService_Unarchive_Handler,
+		},
+		{
+			MethodName: "Batch",
+			Handler:    _TodoService_Batch_Handler,
+		},
+		{
+			MethodName: "Undo",
+			Handler:    _TodoService_Undo_Handler,
+		},
+		{
+			MethodName: "Redo",
+			Handler:    _TodoService_Redo_Handler,
+		},
+		{
+			MethodName: "Export",
+			Handler:    _TodoService_Export_Handler,
+		},
+		{
+			MethodName: "Import",
+			Handler:    _TodoService_Import_Handler,
+		},
+		{
+			MethodName: "Evict",
+			Handler:    _TodoService_Evict_Handler,
+		},
+		{
+			MethodName: "AddWebhook",
+			Handler:    _TodoService_AddWebhook_Handler,
+		},
+		{
+			MethodName: "ReadWebhooks",
+			Handler:    _TodoService_ReadWebhooks_Handler,
+		},
+		{
+			MethodName: "DeleteWebhook",
+			Handler:    _TodoService_DeleteWebhook_Handler,
+		},
+		{
+			MethodName: "ReadDeliveries",
+			Handler:    _TodoService_ReadDeliveries_Handler,
+		},
+		{
+			MethodName: "Promote",
+			Handler:    _TodoService_Promote_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Watch",
+			Handler:       _TodoService_Watch_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "todo.proto",
+}
@@ -0,0 +1,248 @@
+package v1
+
+import (
+	"encoding/json"
+	"github.com/pkg/errors"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"to-do-app-v2/api/handler"
+)
+
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+}
+
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type PathItem map[string]Operation
+
+type Operation struct {
+	Summary     string              `json:"summary"`
+	OperationID string              `json:"operationId"`
+	Parameters  []Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]Response `json:"responses"`
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas"`
+}
+
+type Schema struct {
+	Ref        string             `json:"$ref,omitempty"`
+	Type       string             `json:"type,omitempty"`
+	Format     string             `json:"format,omitempty"`
+	Nullable   bool               `json:"nullable,omitempty"`
+	AllOf      []*Schema          `json:"allOf,omitempty"`
+	Items      *Schema            `json:"items,omitempty"`
+	Properties map[string]*Schema `json:"properties,omitempty"`
+	Required   []string           `json:"required,omitempty"`
+}
+
+type Spec struct {
+	body []byte
+}
+
+var pathParamPattern = regexp.MustCompile(`\{([^}]+)\}`)
+
+func NewSpec(routes []Route) (*Spec, error) {
+	components := Components{Schemas: make(map[string]*Schema)}
+	problemSchema := schemaFor(reflect.TypeOf(handler.Problem{}), components.Schemas)
+
+	document := Document{
+		OpenAPI:    "3.0.3",
+		Info:       Info{Title: "To-Do API", Version: "1.0.0"},
+		Paths:      make(map[string]PathItem),
+		Components: components,
+	}
+
+	for _, route := range routes {
+		operation := Operation{
+			Summary:     route.Summary,
+			OperationID: operationID(route),
+			Responses: map[string]Response{
+				"default": {
+					Description: "Error",
+					Content:     map[string]MediaType{handler.ProblemContentType: {Schema: problemSchema}},
+				},
+			},
+		}
+
+		for _, match := range pathParamPattern.FindAllStringSubmatch(route.Path, -1) {
+			operation.Parameters = append(operation.Parameters, Parameter{
+				Name: match[1], In: "path", Required: true, Schema: &Schema{Type: "string"},
+			})
+		}
+		for _, param := range route.Params {
+			schema := &Schema{Type: "string"}
+			if param.Format == "boolean" {
+				schema = &Schema{Type: "boolean"}
+			} else if param.Format != "" {
+				schema.Format = param.Format
+			}
+			operation.Parameters = append(operation.Parameters, Parameter{
+				Name: param.Name, In: param.In, Description: param.Description, Required: param.Required, Schema: schema,
+			})
+		}
+
+		if route.Request != nil {
+			operation.RequestBody = &RequestBody{
+				Required: true,
+				Content: map[string]MediaType{
+					"application/json": {Schema: schemaFor(reflect.TypeOf(route.Request), components.Schemas)},
+				},
+			}
+			for _, statusCode := range []int{http.StatusBadRequest, http.StatusRequestEntityTooLarge, http.StatusUnprocessableEntity} {
+				operation.Responses[strconv.Itoa(statusCode)] = Response{
+					Description: http.StatusText(statusCode),
+					Content:     map[string]MediaType{handler.ProblemContentType: {Schema: problemSchema}},
+				}
+			}
+		}
+
+		response := Response{Description: http.StatusText(route.Status)}
+		if route.Response != nil {
+			contentType := route.ContentType
+			if contentType == "" {
+				contentType = "application/json"
+			}
+			response.Content = map[string]MediaType{
+				contentType: {Schema: schemaFor(reflect.TypeOf(route.Response), components.Schemas)},
+			}
+		}
+		operation.Responses[strconv.Itoa(route.Status)] = response
+
+		path := Prefix + route.Path
+		if document.Paths[path] == nil {
+			document.Paths[path] = make(PathItem)
+		}
+		document.Paths[path][strings.ToLower(route.Method)] = operation
+	}
+
+	body, err := json.Marshal(document)
+	if err != nil {
+		return nil, errors.Wrap(err, "encode openapi document")
+	}
+
+	return &Spec{body: body}, nil
+}
+
+func (spec *Spec) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if _, err := w.Write(spec.body); err != nil {
+		slog.ErrorContext(r.Context(), err.Error())
+	}
+}
+
+func operationID(route Route) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(route.Method))
+	for _, segment := range strings.FieldsFunc(route.Path, func(r rune) bool { return r == '/' || r == ':' }) {
+		segment = strings.Trim(segment, "{}")
+		if segment == "" {
+			continue
+		}
+		b.WriteString(strings.ToUpper(segment[:1]) + segment[1:])
+	}
+	return b.String()
+}
+
+// schemaFor returns the schema of a Go type, named structs are added to the components and referenced.
+func schemaFor(t reflect.Type, schemas map[string]*Schema) *Schema {
+	if t == reflect.TypeOf(time.Time{}) {
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		schema := schemaFor(t.Elem(), schemas)
+		// Siblings of a $ref are ignored, so a nullable reference wraps it
+		if schema.Ref != "" {
+			return &Schema{AllOf: []*Schema{schema}, Nullable: true}
+		}
+		schema.Nullable = true
+		return schema
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: schemaFor(t.Elem(), schemas)}
+	case reflect.Map:
+		return &Schema{Type: "object"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Struct:
+		if _, found := schemas[t.Name()]; !found {
+			schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+			schemas[t.Name()] = schema
+			addProperties(t, schema, schemas)
+		}
+		return &Schema{Ref: "#/components/schemas/" + t.Name()}
+	default:
+		return &Schema{}
+	}
+}
+
+func addProperties(t reflect.Type, schema *Schema, schemas map[string]*Schema) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			addProperties(field.Type, schema, schemas)
+			continue
+		}
+
+		name, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		schema.Properties[name] = schemaFor(field.Type, schemas)
+		if !strings.Contains(options, "omitempty") && field.Type.Kind() != reflect.Pointer {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+}
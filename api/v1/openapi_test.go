@@ -0,0 +1,183 @@
+package v1
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"to-do-app-v2/api/handler"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden spec with the served one, after reviewing the change")
+
+const goldenSpec = "testdata/openapi.json"
+
+func newTestMux(t *testing.T) (*http.ServeMux, Document) {
+	mux := http.NewServeMux()
+	require.NoError(t, Register(mux, handler.NewHandler(nil)))
+
+	recorder := httptest.NewRecorder()
+	mux.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, Prefix+"/openapi.json", nil))
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+
+	var document Document
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&document))
+
+	return mux, document
+}
+
+func Test_OpenAPI_DescribesEveryRegisteredRoute(t *testing.T) {
+	_, document := newTestMux(t)
+
+	for _, route := range Routes(handler.NewHandler(nil)) {
+		pathItem, found := document.Paths[Prefix+route.Path]
+		if assert.True(t, found, "path %s missing from spec", route.Path) {
+			assert.Contains(t, pathItem, strings.ToLower(route.Method), "operation %s missing from spec", route.Pattern())
+		}
+	}
+}
+
+func Test_OpenAPI_EveryDocumentedOperationIsRouted(t *testing.T) {
+	mux, document := newTestMux(t)
+
+	for path, pathItem := range document.Paths {
+		for method := range pathItem {
+			target := pathParamPattern.ReplaceAllString(path, "test")
+			req := httptest.NewRequest(strings.ToUpper(method), target, nil)
+
+			_, pattern := mux.Handler(req)
+
+			assert.Equal(t, strings.ToUpper(method)+" "+path, pattern)
+		}
+	}
+}
+
+func Test_OpenAPI_DocumentsPathParameters(t *testing.T) {
+	_, document := newTestMux(t)
+
+	for path, pathItem := range document.Paths {
+		for method, operation := range pathItem {
+			var names []string
+			for _, param := range operation.Parameters {
+				if param.In == "path" {
+					names = append(names, param.Name)
+				}
+			}
+			var expected []string
+			for _, match := range pathParamPattern.FindAllStringSubmatch(path, -1) {
+				expected = append(expected, match[1])
+			}
+			assert.Equal(t, expected, names, "%s %s", method, path)
+		}
+	}
+}
+
+func Test_OpenAPI_DescribesItemAndProblem(t *testing.T) {
+	_, document := newTestMux(t)
+
+	require.Contains(t, document.Components.Schemas, "Item")
+	require.Contains(t, document.Components.Schemas, "Problem")
+
+	item := document.Components.Schemas["Item"]
+	assert.Equal(t, "object", item.Type)
+	assert.ElementsMatch(t, []string{"id", "name", "description", "status"}, item.Required)
+	assert.Contains(t, item.Properties, "assignee")
+	assert.Equal(t, "date-time", item.Properties["completed_at"].Format)
+
+	problem := document.Components.Schemas["Problem"]
+	assert.ElementsMatch(t, []string{"type", "title", "status"}, problem.Required)
+	assert.Contains(t, problem.Properties, "instance")
+	assert.Contains(t, problem.Properties, "errors")
+}
+
+func Test_OpenAPI_ReferencesResolve(t *testing.T) {
+	_, document := newTestMux(t)
+
+	var check func(schema *Schema)
+	check = func(schema *Schema) {
+		if schema == nil {
+			return
+		}
+		if schema.Ref != "" {
+			assert.Contains(t, document.Components.Schemas, strings.TrimPrefix(schema.Ref, "#/components/schemas/"))
+		}
+		check(schema.Items)
+		for _, member := range schema.AllOf {
+			check(member)
+		}
+		for _, property := range schema.Properties {
+			check(property)
+		}
+	}
+
+	for _, schema := range document.Components.Schemas {
+		check(schema)
+	}
+	for _, pathItem := range document.Paths {
+		for _, operation := range pathItem {
+			if operation.RequestBody != nil {
+				for _, mediaType := range operation.RequestBody.Content {
+					check(mediaType.Schema)
+				}
+			}
+			for _, response := range operation.Responses {
+				for _, mediaType := range response.Content {
+					check(mediaType.Schema)
+				}
+			}
+		}
+	}
+}
+
+// Test_OpenAPI_MatchesGoldenSpec fails on any change of the served spec, so that changes of the contract are
+// reviewed with the golden file, go test ./api/v1 -update rewrites it.
+func Test_OpenAPI_MatchesGoldenSpec(t *testing.T) {
+	mux := http.NewServeMux()
+	require.NoError(t, Register(mux, handler.NewHandler(nil)))
+	recorder := httptest.NewRecorder()
+	mux.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, Prefix+"/openapi.json", nil))
+
+	var served bytes.Buffer
+	require.NoError(t, json.Indent(&served, recorder.Body.Bytes(), "", "  "))
+	served.WriteString("\n")
+
+	if *update {
+		require.NoError(t, os.MkdirAll(filepath.Dir(goldenSpec), 0o755))
+		require.NoError(t, os.WriteFile(goldenSpec, served.Bytes(), 0o644))
+	}
+	golden, err := os.ReadFile(goldenSpec)
+	require.NoError(t, err)
+
+	assert.Equal(t, string(golden), served.String())
+}
+
+func Test_OpenAPI_WrapsNullableReferences(t *testing.T) {
+	_, document := newTestMux(t)
+
+	comment := document.Components.Schemas["Event"].Properties["comment"]
+
+	assert.Empty(t, comment.Ref)
+	assert.True(t, comment.Nullable)
+	require.Len(t, comment.AllOf, 1)
+	assert.Equal(t, "#/components/schemas/Comment", comment.AllOf[0].Ref)
+}
+
+func Test_Register_RedirectsUnversionedRoutes(t *testing.T) {
+	mux, _ := newTestMux(t)
+
+	recorder := httptest.NewRecorder()
+	mux.ServeHTTP(recorder, httptest.NewRequest(http.MethodPut, "/items/id1?dry_run=true", strings.NewReader(`{"name":"name1"}`)))
+
+	assert.Equal(t, http.StatusPermanentRedirect, recorder.Code)
+	assert.Equal(t, Prefix+"/items/id1?dry_run=true", recorder.Header().Get("Location"))
+	assert.Equal(t, "true", recorder.Header().Get("Deprecation"))
+	assert.Contains(t, recorder.Header().Get("Link"), `rel="successor-version"`)
+}
@@ -0,0 +1,114 @@
+package v1
+
+import (
+	"net/http"
+	"to-do-app-v2/api/handler"
+	"to-do-app-v2/internal/store"
+)
+
+const Prefix = "/api/v1"
+
+type Param struct {
+	In          string
+	Name        string
+	Description string
+	Required    bool
+	Format      string
+}
+
+type Route struct {
+	Method      string
+	Path        string
+	Summary     string
+	Params      []Param
+	Request     any
+	Response    any
+	Status      int
+	ContentType string
+	Handler     http.HandlerFunc
+}
+
+func (route Route) Pattern() string {
+	return route.Method + " " + Prefix + route.Path
+}
+
+func Routes(h *handler.Handler) []Route {
+	includeArchived := Param{In: "query", Name: "include_archived", Description: "Include archived items", Format: "boolean"}
+
+	return []Route{
+		{Method: http.MethodPost, Path: "/items", Summary: "Create an item",
+			Params:  []Param{{In: "header", Name: handler.IdempotencyKeyHeader, Description: "Replays the original response when the same item is created again with this key"}},
+			Request: store.Item{}, Response: store.Item{}, Status: http.StatusCreated, Handler: h.HandleCreateItem},
+		{Method: http.MethodPost, Path: "/items:batch", Summary: "Apply create, update and delete operations atomically",
+			Request: handler.BatchRequest{}, Response: handler.BatchResponse{}, Status: http.StatusOK, Handler: h.HandleBatchItems},
+		{Method: http.MethodGet, Path: "/items", Summary: "List items", Params: []Param{includeArchived},
+			Response: []store.Item{}, Status: http.StatusOK, Handler: h.HandleGetItems},
+		{Method: http.MethodGet, Path: "/items/events", Summary: "Stream item changes as server-sent events",
+			Params:   []Param{{In: "header", Name: handler.LastEventIDHeader, Description: "Resume after this event ID"}},
+			Response: store.Event{}, Status: http.StatusOK, ContentType: handler.EventStreamType, Handler: h.HandleItemEvents},
+		{Method: http.MethodGet, Path: "/items/{id}", Summary: "Get an item",
+			Response: store.Item{}, Status: http.StatusOK, Handler: h.HandleGetItemWithID},
+		{Method: http.MethodPut, Path: "/items/{id}", Summary: "Replace an item",
+			Request: store.Item{}, Response: store.Item{}, Status: http.StatusOK, Handler: h.HandleUpdateItem},
+		{Method: http.MethodDelete, Path: "/items/{id}", Summary: "Move an item to the trash",
+			Status: http.StatusNoContent, Handler: h.HandleDeleteItem},
+		{Method: http.MethodGet, Path: "/items/{id}/comments", Summary: "List the comments on an item",
+			Response: []store.Comment{}, Status: http.StatusOK, Handler: h.HandleGetComments},
+		{Method: http.MethodPost, Path: "/items/{id}/comments", Summary: "Comment on an item",
+			Request: store.Comment{}, Response: store.Comment{}, Status: http.StatusCreated, Handler: h.HandleCreateComment},
+		{Method: http.MethodGet, Path: "/items/{id}/history", Summary: "Get the activity history of an item",
+			Response: []store.Activity{}, Status: http.StatusOK, Handler: h.HandleGetHistory},
+		{Method: http.MethodPost, Path: "/items/{id}/restore", Summary: "Restore an item from the trash",
+			Response: store.Item{}, Status: http.StatusOK, Handler: h.HandleRestoreDeletedItem},
+		{Method: http.MethodPost, Path: "/items/{id}/unarchive", Summary: "Return an archived item to the list",
+			Response: store.Item{}, Status: http.StatusOK, Handler: h.HandleUnarchiveItem},
+		{Method: http.MethodGet, Path: "/trash", Summary: "List deleted items",
+			Response: []store.TrashedItem{}, Status: http.StatusOK, Handler: h.HandleGetTrash},
+		{Method: http.MethodGet, Path: "/archive", Summary: "List archived items",
+			Response: []store.Item{}, Status: http.StatusOK, Handler: h.HandleGetArchive},
+		{Method: http.MethodGet, Path: "/me/items", Summary: "List the items assigned to the calling user",
+			Response: []store.Item{}, Status: http.StatusOK, Handler: h.HandleGetMyItems},
+		{Method: http.MethodPost, Path: "/webhooks", Summary: "Subscribe a URL to item events",
+			Request: store.Webhook{}, Response: store.Webhook{}, Status: http.StatusCreated, Handler: h.HandleCreateWebhook},
+		{Method: http.MethodGet, Path: "/webhooks", Summary: "List webhook subscriptions",
+			Response: []store.Webhook{}, Status: http.StatusOK, Handler: h.HandleGetWebhooks},
+		{Method: http.MethodDelete, Path: "/webhooks/{id}", Summary: "Remove a webhook subscription",
+			Status: http.StatusNoContent, Handler: h.HandleDeleteWebhook},
+		{Method: http.MethodGet, Path: "/webhooks/{id}/deliveries", Summary: "List the recent delivery attempts of a webhook",
+			Response: []store.Delivery{}, Status: http.StatusOK, Handler: h.HandleGetDeliveries},
+		{Method: http.MethodPost, Path: "/admin/restore", Summary: "Restore the item list as of a point in time",
+			Params:   []Param{{In: "query", Name: "at", Description: "RFC 3339 timestamp to restore to", Required: true, Format: "date-time"}},
+			Response: []store.Item{}, Status: http.StatusOK, Handler: h.HandleRestoreItems},
+	}
+}
+
+func Register(mux *http.ServeMux, h *handler.Handler) error {
+	routes := Routes(h)
+
+	spec, err := NewSpec(routes)
+	if err != nil {
+		return err
+	}
+
+	for _, route := range routes {
+		mux.HandleFunc(route.Pattern(), route.Handler)
+		// Clients of the routes from before versioning are sent on while they move to the prefix
+		mux.HandleFunc(route.Method+" "+route.Path, redirectToVersion)
+	}
+	mux.HandleFunc("GET "+Prefix+"/openapi.json", spec.ServeHTTP)
+
+	return nil
+}
+
+// redirectToVersion redirects a request of an unversioned route to the same route under the prefix, keeping
+// the method and body, and marks the unversioned route as deprecated.
+func redirectToVersion(w http.ResponseWriter, r *http.Request) {
+	target := Prefix + r.URL.EscapedPath()
+	if r.URL.RawQuery != "" {
+		target += "?" + r.URL.RawQuery
+	}
+
+	w.Header().Set("Deprecation", "true")
+	w.Header().Set("Link", "<"+target+">; rel=\"successor-version\"")
+	http.Redirect(w, r, target, http.StatusPermanentRedirect)
+}
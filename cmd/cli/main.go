@@ -4,9 +4,13 @@ import (
 	"context"
 	"flag"
 	"github.com/google/uuid"
+	"github.com/pkg/errors"
 	"log/slog"
 	"os"
+	"strings"
 	"to-do-app-v2/internal/app"
+	"to-do-app-v2/internal/rpc"
+	"to-do-app-v2/internal/shard"
 	"to-do-app-v2/internal/store"
 )
 
@@ -28,13 +32,66 @@ func main() {
 
 	setNewDefaultLogger()
 
-	itemStore := store.NewStore()
+	storeAddr := flag.String("store-addr", "", "comma separated addresses of storeservers to use instead of the local files, items are sharded across several")
+	backend := flag.String("backend", store.BackendFile, "where the local store keeps its items, file, sqlite, bolt or a postgres:// URL")
+	keyFile := flag.String("key-file", "", "file with the hex encoded AES-256 key the local store encrypts its files with, such as from openssl rand -hex 32, the "+store.PassphraseEnv+" variable sets a passphrase instead")
+	flag.Parse()
+
+	// Promote talks to a single storeserver rather than to the items sharded across several
+	if args := flag.Args(); len(args) > 0 && args[0] == "promote" {
+		if err := promote(*storeAddr); err != nil {
+			slog.ErrorContext(ctx, err.Error())
+			return
+		}
+		slog.InfoContext(ctx, "storeserver promoted")
+		return
+	}
+
+	// Rebalance talks to every storeserver rather than to the items sharded across them
+	if args := flag.Args(); len(args) > 0 && args[0] == "rebalance" {
+		if *storeAddr == "" {
+			slog.ErrorContext(ctx, "rebalance needs the addresses of the storeservers in -store-addr")
+			return
+		}
+		if err := shard.Rebalance(strings.Split(*storeAddr, ",")...); err != nil {
+			slog.ErrorContext(ctx, err.Error())
+			return
+		}
+		slog.InfoContext(ctx, "items rebalanced")
+		return
+	}
+
+	var itemStore app.Store
+	if *storeAddr != "" {
+		remote, closeRemote, err := shard.Dial(strings.Split(*storeAddr, ",")...)
+		if err != nil {
+			slog.ErrorContext(ctx, err.Error())
+			return
+		}
+		defer closeRemote()
+
+		itemStore = remote
+	} else {
+		backendOption, err := store.BackendOption(*backend)
+		if err != nil {
+			slog.ErrorContext(ctx, err.Error())
+			return
+		}
+		key, err := store.ReadEncryptionKey(*keyFile, store.PassphraseEnv)
+		if err != nil {
+			slog.ErrorContext(ctx, err.Error())
+			return
+		}
+		localStore := store.NewStore(store.WithPendingChanges(), backendOption, store.WithEncryption(key))
+		defer localStore.Close()
+
+		itemStore = localStore
+	}
 	newCli := app.NewCli(itemStore)
 
-	flag.Parse()
 	args := flag.Args()
 	if len(args) == 0 {
-		slog.ErrorContext(ctx, "expected one of the following commands: add, list, update, delete")
+		slog.ErrorContext(ctx, "expected one of the following commands: add, list, update, delete, assign, show, archive, import, undo, redo, sync, migrate, rotate-key, promote, rebalance")
 		return
 	}
 
@@ -63,8 +120,60 @@ func main() {
 			return
 		}
 		slog.InfoContext(ctx, "item deleted")
+	case "assign":
+		if err := newCli.AssignCommand(cmdArgs); err != nil {
+			slog.ErrorContext(ctx, err.Error())
+			return
+		}
+		slog.InfoContext(ctx, "item assigned")
+	case "show":
+		if err := newCli.ShowCommand(cmdArgs); err != nil {
+			slog.ErrorContext(ctx, err.Error())
+			return
+		}
+	case "archive":
+		if err := newCli.ArchiveCommand(cmdArgs); err != nil {
+			slog.ErrorContext(ctx, err.Error())
+			return
+		}
+		slog.InfoContext(ctx, "items archived")
+	case "import":
+		if err := newCli.ImportCommand(os.Stdin); err != nil {
+			slog.ErrorContext(ctx, err.Error())
+			return
+		}
+		slog.InfoContext(ctx, "items imported")
+	case "undo":
+		if err := newCli.UndoCommand(); err != nil {
+			slog.ErrorContext(ctx, err.Error())
+			return
+		}
+		slog.InfoContext(ctx, "change undone")
+	case "redo":
+		if err := newCli.RedoCommand(); err != nil {
+			slog.ErrorContext(ctx, err.Error())
+			return
+		}
+		slog.InfoContext(ctx, "change redone")
+	case "sync":
+		if err := newCli.SyncCommand(cmdArgs); err != nil {
+			slog.ErrorContext(ctx, err.Error())
+			return
+		}
+		slog.InfoContext(ctx, "items synced")
+	case "migrate":
+		if err := newCli.MigrateCommand(cmdArgs); err != nil {
+			slog.ErrorContext(ctx, err.Error())
+			return
+		}
+	case "rotate-key":
+		if err := newCli.RotateKeyCommand(cmdArgs); err != nil {
+			slog.ErrorContext(ctx, err.Error())
+			return
+		}
+		slog.InfoContext(ctx, "key rotated")
 	default:
-		slog.ErrorContext(ctx, "expected one of the following commands: add, list, update, delete")
+		slog.ErrorContext(ctx, "expected one of the following commands: add, list, update, delete, assign, show, archive, import, undo, redo, sync, migrate, rotate-key, promote, rebalance")
 		return
 	}
 
@@ -76,6 +185,21 @@ func main() {
 	*/
 }
 
+// promote makes the follower storeserver at addr stop following its leader and take writes.
+func promote(addr string) error {
+	if addr == "" || strings.Contains(addr, ",") {
+		return errors.New("promote needs the address of a single storeserver in -store-addr")
+	}
+
+	client, err := rpc.Dial(addr)
+	if err != nil {
+		return errors.Wrapf(err, "dial storeserver '%s'", addr)
+	}
+	defer client.Close()
+
+	return errors.Wrap(client.Promote(), "promote storeserver")
+}
+
 func setNewDefaultLogger() {
 	var handler slog.Handler
 	handler = slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
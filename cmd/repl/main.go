@@ -3,11 +3,15 @@ package main
 import (
 	"bufio"
 	"context"
+	"flag"
 	"fmt"
 	"github.com/google/uuid"
 	"log/slog"
 	"os"
 	"strconv"
+	"strings"
+	"to-do-app-v2/internal/app"
+	"to-do-app-v2/internal/shard"
 	"to-do-app-v2/internal/store"
 )
 
@@ -29,7 +33,37 @@ func main() {
 
 	setNewDefaultLogger()
 
-	itemStore := store.NewStore()
+	storeAddr := flag.String("store-addr", "", "comma separated addresses of storeservers to use instead of the local files, items are sharded across several")
+	backend := flag.String("backend", store.BackendFile, "where the local store keeps its items, file, sqlite, bolt or a postgres:// URL")
+	keyFile := flag.String("key-file", "", "file with the hex encoded AES-256 key the local store encrypts its files with, such as from openssl rand -hex 32, the "+store.PassphraseEnv+" variable sets a passphrase instead")
+	flag.Parse()
+
+	var itemStore app.Store
+	if *storeAddr != "" {
+		remote, closeRemote, err := shard.Dial(strings.Split(*storeAddr, ",")...)
+		if err != nil {
+			slog.ErrorContext(ctx, err.Error())
+			return
+		}
+		defer closeRemote()
+
+		itemStore = remote
+	} else {
+		backendOption, err := store.BackendOption(*backend)
+		if err != nil {
+			slog.ErrorContext(ctx, err.Error())
+			return
+		}
+		key, err := store.ReadEncryptionKey(*keyFile, store.PassphraseEnv)
+		if err != nil {
+			slog.ErrorContext(ctx, err.Error())
+			return
+		}
+		localStore := store.NewStore(backendOption, store.WithEncryption(key))
+		defer localStore.Close()
+
+		itemStore = localStore
+	}
 
 	fmt.Println("Options")
 	fmt.Println("1. Create")
@@ -37,12 +71,13 @@ func main() {
 	fmt.Println("3. Print one")
 	fmt.Println("4. Update")
 	fmt.Println("5. Delete")
-	fmt.Println("6. Exit")
+	fmt.Println("6. Undo")
+	fmt.Println("7. Exit")
 
 	scanner := bufio.NewScanner(os.Stdin)
 
 	for {
-		fmt.Print("Enter choice (1, 2, 3, 4, 5, 6): ")
+		fmt.Print("Enter choice (1, 2, 3, 4, 5, 6, 7): ")
 		scanner.Scan()
 		choice, err := strconv.Atoi(scanner.Text())
 		if err != nil {
@@ -127,6 +162,13 @@ func main() {
 
 			slog.InfoContext(ctx, "item deleted")
 		case 6:
+			if err = itemStore.Undo(); err != nil {
+				slog.ErrorContext(ctx, err.Error())
+				continue
+			}
+
+			slog.InfoContext(ctx, "change undone")
+		case 7:
 			fmt.Println("Goodbye!")
 			os.Exit(0)
 		default:
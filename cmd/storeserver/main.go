@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"log/slog"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+	"to-do-app-v2/api/todopb"
+	"to-do-app-v2/internal/replica"
+	"to-do-app-v2/internal/rpc"
+	"to-do-app-v2/internal/store"
+	"to-do-app-v2/internal/webhook"
+)
+
+type ContextHandler struct {
+	slog.Handler
+}
+
+func (h *ContextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if traceID, ok := ctx.Value(TraceIDHeader).(string); ok {
+		r.AddAttrs(slog.String(TraceIDHeader, traceID))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+const (
+	TraceIDHeader = "TraceID"
+	DefaultAddr   = ":9090"
+)
+
+func main() {
+	ctx := context.WithValue(context.Background(), TraceIDHeader, uuid.NewString())
+
+	setNewDefaultLogger()
+
+	addr := flag.String("addr", DefaultAddr, "address to serve the store over gRPC on")
+	trashRetention := flag.Duration("trash-retention", store.DefaultTrashRetention, "how long deleted items are kept in the trash")
+	archiveAfter := flag.Duration("archive-after", store.DefaultArchiveAfter, "how long completed items are kept before being archived")
+	follow := flag.String("follow", "", "address of a leader storeserver to replicate, writes are rejected until the follower is promoted, such as with the promote command of the cli")
+	backend := flag.String("backend", store.BackendFile, "where the store keeps its items, file, sqlite, bolt or a postgres:// URL")
+	keyFile := flag.String("key-file", "", "file with the hex encoded AES-256 key the store encrypts its files with, such as from openssl rand -hex 32, the "+store.PassphraseEnv+" variable sets a passphrase instead")
+	flag.Parse()
+
+	backendOption, err := store.BackendOption(*backend)
+	if err != nil {
+		slog.ErrorContext(ctx, err.Error())
+		return
+	}
+	key, err := store.ReadEncryptionKey(*keyFile, store.PassphraseEnv)
+	if err != nil {
+		slog.ErrorContext(ctx, err.Error())
+		return
+	}
+
+	var service rpc.Service
+	if *follow != "" {
+		leader, err := rpc.Dial(*follow)
+		if err != nil {
+			slog.ErrorContext(ctx, err.Error())
+			return
+		}
+		defer leader.Close()
+
+		// The leader delivers the webhooks until the follower is promoted
+		var dispatcher *webhook.Dispatcher
+
+		// The janitor of the leader purges and archives items until the follower is promoted and starts its own
+		local := store.NewStore(store.WithJanitorInterval(0), store.WithTrashRetention(*trashRetention),
+			store.WithArchiveAfter(*archiveAfter), backendOption, store.WithEncryption(key))
+		var follower *replica.Follower
+		follower = replica.NewFollower(local,
+			replica.WithOnPromote(func() {
+				slog.InfoContext(ctx, "promoted, taking writes")
+				d, err := webhook.NewDispatcher(follower)
+				if err != nil {
+					slog.ErrorContext(ctx, err.Error())
+					return
+				}
+				dispatcher = d
+			}))
+		if err := follower.Follow(leader); err != nil {
+			slog.ErrorContext(ctx, err.Error())
+			return
+		}
+		defer follower.Close()
+		defer func() {
+			if dispatcher != nil {
+				dispatcher.Close()
+			}
+		}()
+
+		service = follower
+	} else {
+		itemStore := store.NewStore(store.WithTrashRetention(*trashRetention), store.WithArchiveAfter(*archiveAfter), backendOption, store.WithEncryption(key))
+		defer itemStore.Close()
+
+		dispatcher, err := webhook.NewDispatcher(itemStore)
+		if err != nil {
+			slog.ErrorContext(ctx, err.Error())
+			return
+		}
+		defer dispatcher.Close()
+
+		service = itemStore
+	}
+
+	listener, err := net.Listen("tcp", *addr)
+	if err != nil {
+		slog.ErrorContext(ctx, err.Error())
+		return
+	}
+
+	grpcServer := grpc.NewServer()
+	todopb.RegisterTodoServiceServer(grpcServer, rpc.NewServer(service))
+
+	// Stop accepting calls on SIGINT or SIGTERM and let the ones in flight finish
+	go func() {
+		signalCtx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		<-signalCtx.Done()
+		grpcServer.GracefulStop()
+	}()
+
+	slog.InfoContext(ctx, "serving store on "+listener.Addr().String())
+	if err := grpcServer.Serve(listener); err != nil {
+		slog.ErrorContext(ctx, err.Error())
+	}
+}
+
+func setNewDefaultLogger() {
+	var h slog.Handler
+	h = slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
+		AddSource: true,
+	})
+	h = &ContextHandler{h}
+
+	slog.SetDefault(slog.New(h))
+}
@@ -2,13 +2,18 @@ package main
 
 import (
 	"context"
+	"flag"
 	"github.com/google/uuid"
 	"log/slog"
 	"net/http"
 	"os"
+	"strings"
 	"to-do-app-v2/api/handler"
 	"to-do-app-v2/api/middleware"
+	"to-do-app-v2/api/v1"
+	"to-do-app-v2/internal/shard"
 	"to-do-app-v2/internal/store"
+	"to-do-app-v2/internal/webhook"
 )
 
 type ContextHandler struct {
@@ -29,8 +34,48 @@ func main() {
 
 	setNewDefaultLogger()
 
-	itemStore := store.NewStore()
-	itemHandler := handler.NewHandler(itemStore)
+	trashRetention := flag.Duration("trash-retention", store.DefaultTrashRetention, "how long deleted items are kept in the trash")
+	archiveAfter := flag.Duration("archive-after", store.DefaultArchiveAfter, "how long completed items are kept before being archived")
+	storeAddr := flag.String("store-addr", "", "comma separated addresses of storeservers to use instead of the local files, items are sharded across several")
+	backend := flag.String("backend", store.BackendFile, "where the local store keeps its items, file, sqlite, bolt or a postgres:// URL")
+	keyFile := flag.String("key-file", "", "file with the hex encoded AES-256 key the local store encrypts its files with, such as from openssl rand -hex 32, the "+store.PassphraseEnv+" variable sets a passphrase instead")
+	flag.Parse()
+
+	var service handler.Service
+	if *storeAddr != "" {
+		remote, closeRemote, err := shard.Dial(strings.Split(*storeAddr, ",")...)
+		if err != nil {
+			slog.ErrorContext(ctx, err.Error())
+			return
+		}
+		defer closeRemote()
+
+		service = remote
+	} else {
+		backendOption, err := store.BackendOption(*backend)
+		if err != nil {
+			slog.ErrorContext(ctx, err.Error())
+			return
+		}
+		key, err := store.ReadEncryptionKey(*keyFile, store.PassphraseEnv)
+		if err != nil {
+			slog.ErrorContext(ctx, err.Error())
+			return
+		}
+		itemStore := store.NewStore(store.WithTrashRetention(*trashRetention), store.WithArchiveAfter(*archiveAfter), backendOption, store.WithEncryption(key))
+		defer itemStore.Close()
+
+		dispatcher, err := webhook.NewDispatcher(itemStore)
+		if err != nil {
+			slog.ErrorContext(ctx, err.Error())
+			return
+		}
+		defer dispatcher.Close()
+
+		service = itemStore
+	}
+
+	itemHandler := handler.NewHandler(service)
 
 	router := http.NewServeMux()
 
@@ -39,16 +84,16 @@ func main() {
 
 	router.HandleFunc("/about/", itemHandler.HandleAboutPage)
 	router.HandleFunc("/list/", itemHandler.HandleListItemsPage)
+	router.HandleFunc("GET /ws/items", itemHandler.HandleLiveItems)
 
-	router.HandleFunc("POST /items", itemHandler.HandleCreateItem)
-	router.HandleFunc("GET /items", itemHandler.HandleGetItems)
-	router.HandleFunc("GET /items/{id}", itemHandler.HandleGetItemWithID)
-	router.HandleFunc("PUT /items/{id}", itemHandler.HandleUpdateItem)
-	router.HandleFunc("DELETE /items/{id}", itemHandler.HandleDeleteItem)
+	if err := v1.Register(router, itemHandler); err != nil {
+		slog.ErrorContext(ctx, err.Error())
+		return
+	}
 
 	srv := &http.Server{
 		Addr:    ":8080",
-		Handler: middleware.TraceIDMiddleware(router),
+		Handler: middleware.TraceIDMiddleware(middleware.UserIDMiddleware(router)),
 	}
 
 	if err := srv.ListenAndServe(); err != nil {
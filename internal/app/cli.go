@@ -1,17 +1,39 @@
 package app
 
 import (
+	"bufio"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"github.com/pkg/errors"
+	"io"
+	"strings"
+	"to-do-app-v2/internal/offline"
 	"to-do-app-v2/internal/store"
 )
 
+// Store is the item store the commands run against, either a local store or a remote one.
+type Store interface {
+	Create(item store.Item) (store.Item, error)
+	ReadAll() ([]store.Item, error)
+	Read(id store.ItemID) (store.Item, error)
+	Update(id store.ItemID, item store.Item) (store.Item, error)
+	Delete(id store.ItemID) error
+	Assign(id store.ItemID, assignee string) (store.Item, error)
+	ReadComments(id store.ItemID) ([]store.Comment, error)
+	ReadHistory(id store.ItemID) ([]store.Activity, error)
+	Archive(id store.ItemID) (store.Item, error)
+	ArchiveCompleted() ([]store.Item, error)
+	Batch(operations []store.Operation) ([]store.OperationResult, error)
+	Undo() error
+	Redo() error
+}
+
 type Cli struct {
-	store *store.Store
+	store Store
 }
 
-func NewCli(store *store.Store) *Cli {
+func NewCli(store Store) *Cli {
 	return &Cli{
 		store: store,
 	}
@@ -20,18 +42,19 @@ func NewCli(store *store.Store) *Cli {
 func (c *Cli) AddCommand(args []string) error {
 	cmd := flag.NewFlagSet("add", flag.ExitOnError)
 	var (
-		name, desc, status string
+		name, desc, status, assignee string
 	)
 
 	cmd.StringVar(&name, "name", "", "store name")
 	cmd.StringVar(&desc, "description", "", "store description")
 	cmd.StringVar(&status, "status", "", "store status")
+	cmd.StringVar(&assignee, "assignee", "", "store assignee")
 
 	if err := cmd.Parse(args); err != nil {
 		return errors.Wrapf(err, "parse arguments: %v", args)
 	}
 
-	newItem := store.Item{Name: name, Desc: desc, Status: status}
+	newItem := store.Item{Name: name, Desc: desc, Status: status, Assignee: assignee}
 
 	if _, err := c.store.Create(newItem); err != nil {
 		return errors.Wrap(err, "create item")
@@ -55,21 +78,39 @@ func (c *Cli) ListCommand() error {
 func (c *Cli) UpdateCommand(args []string) error {
 	cmd := flag.NewFlagSet("update", flag.ExitOnError)
 	var (
-		id                 string
-		name, desc, status string
+		id                           string
+		name, desc, status, assignee string
 	)
 
 	cmd.StringVar(&id, "id", "", "store id")
 	cmd.StringVar(&name, "name", "", "store name")
 	cmd.StringVar(&desc, "description", "", "store description")
 	cmd.StringVar(&status, "status", "", "store status")
+	cmd.StringVar(&assignee, "assignee", "", "store assignee")
 
 	if err := cmd.Parse(args); err != nil {
 		return errors.Wrapf(err, "parse arguments: %v", args)
 	}
 
 	itemID := store.ItemID(id)
-	item := store.Item{Name: name, Desc: desc, Status: status}
+	item, err := c.store.Read(itemID)
+	if err != nil {
+		return errors.Wrap(err, "read item")
+	}
+
+	// Only the fields given are changed, the others keep their current value
+	cmd.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "name":
+			item.Name = name
+		case "description":
+			item.Desc = desc
+		case "status":
+			item.Status = status
+		case "assignee":
+			item.Assignee = assignee
+		}
+	})
 
 	if _, err := c.store.Update(itemID, item); err != nil {
 		return errors.Wrap(err, "update item")
@@ -105,6 +146,305 @@ func (c *Cli) DeleteCommand(args []string) error {
 	return nil
 }
 
+func (c *Cli) AssignCommand(args []string) error {
+	cmd := flag.NewFlagSet("assign", flag.ExitOnError)
+	var (
+		id, assignee string
+	)
+
+	cmd.StringVar(&id, "id", "", "store id")
+	cmd.StringVar(&assignee, "assignee", "", "store assignee")
+
+	if err := cmd.Parse(args); err != nil {
+		return errors.Wrapf(err, "parse arguments: %v", args)
+	}
+
+	itemID := store.ItemID(id)
+
+	if _, err := c.store.Assign(itemID, assignee); err != nil {
+		return errors.Wrap(err, "assign item")
+	}
+
+	if err := c.printItems(); err != nil {
+		return errors.Wrap(err, "print items")
+	}
+
+	return nil
+}
+
+func (c *Cli) ShowCommand(args []string) error {
+	cmd := flag.NewFlagSet("show", flag.ExitOnError)
+
+	var id string
+	cmd.StringVar(&id, "id", "", "store id")
+
+	if err := cmd.Parse(args); err != nil {
+		return errors.Wrapf(err, "parse arguments: %v", args)
+	}
+
+	itemID := store.ItemID(id)
+
+	item, err := c.store.Read(itemID)
+	if err != nil {
+		return errors.Wrap(err, "read item")
+	}
+
+	comments, err := c.store.ReadComments(itemID)
+	if err != nil {
+		return errors.Wrap(err, "read comments")
+	}
+
+	history, err := c.store.ReadHistory(itemID)
+	if err != nil {
+		return errors.Wrap(err, "read history")
+	}
+
+	fmt.Println(item)
+	fmt.Println("Comments:")
+	for _, comment := range comments {
+		fmt.Println(comment)
+	}
+	fmt.Println("History:")
+	for _, activity := range history {
+		fmt.Println(activity)
+	}
+
+	return nil
+}
+
+func (c *Cli) ArchiveCommand(args []string) error {
+	cmd := flag.NewFlagSet("archive", flag.ExitOnError)
+
+	var id string
+	cmd.StringVar(&id, "id", "", "store id, archives all completed items past the archive policy when empty")
+
+	if err := cmd.Parse(args); err != nil {
+		return errors.Wrapf(err, "parse arguments: %v", args)
+	}
+
+	if id == "" {
+		if _, err := c.store.ArchiveCompleted(); err != nil {
+			return errors.Wrap(err, "archive completed items")
+		}
+	} else {
+		if _, err := c.store.Archive(store.ItemID(id)); err != nil {
+			return errors.Wrap(err, "archive item")
+		}
+	}
+
+	if err := c.printItems(); err != nil {
+		return errors.Wrap(err, "print items")
+	}
+
+	return nil
+}
+
+// ImportCommand applies operations read from the input as JSON lines, one operation per line, as a single batch.
+func (c *Cli) ImportCommand(input io.Reader) error {
+	var operations []store.Operation
+
+	scanner := bufio.NewScanner(input)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var operation store.Operation
+		if err := json.Unmarshal(scanner.Bytes(), &operation); err != nil {
+			return errors.Wrapf(err, "decode operation on line %d", line)
+		}
+		operations = append(operations, operation)
+	}
+	if err := scanner.Err(); err != nil {
+		return errors.Wrap(err, "read operations")
+	}
+
+	results, err := c.store.Batch(operations)
+	if err != nil {
+		return errors.Wrap(err, "apply operations")
+	}
+
+	for _, result := range results {
+		fmt.Printf("%s %s\n", result.Op, result.ID)
+	}
+
+	return nil
+}
+
+func (c *Cli) UndoCommand() error {
+	if err := c.store.Undo(); err != nil {
+		return errors.Wrap(err, "undo")
+	}
+
+	if err := c.printItems(); err != nil {
+		return errors.Wrap(err, "print items")
+	}
+
+	return nil
+}
+
+func (c *Cli) RedoCommand() error {
+	if err := c.store.Redo(); err != nil {
+		return errors.Wrap(err, "redo")
+	}
+
+	if err := c.printItems(); err != nil {
+		return errors.Wrap(err, "print items")
+	}
+
+	return nil
+}
+
+// SyncCommand reconciles the local store with a cmd/web server, or settles the conflict of one item when an
+// id is given.
+func (c *Cli) SyncCommand(args []string) error {
+	cmd := flag.NewFlagSet("sync", flag.ExitOnError)
+	var (
+		server, strategy string
+		id, keep         string
+	)
+
+	cmd.StringVar(&server, "server", "", "server URL, such as http://localhost:8080")
+	cmd.StringVar(&strategy, "strategy", offline.StrategyMerge, "conflict strategy, merge, lww or fields")
+	cmd.StringVar(&id, "id", "", "store id of a conflict to resolve instead of syncing")
+	cmd.StringVar(&keep, "keep", "", "side to keep when resolving a conflict, local or remote")
+
+	if err := cmd.Parse(args); err != nil {
+		return errors.Wrapf(err, "parse arguments: %v", args)
+	}
+
+	if server == "" {
+		return errors.New("missing server URL")
+	}
+
+	local, ok := c.store.(offline.Local)
+	if !ok {
+		return errors.New("sync needs a local store")
+	}
+
+	syncer, err := offline.NewSyncer(local, offline.NewClient(server), strategy)
+	if err != nil {
+		return errors.Wrap(err, "create syncer")
+	}
+
+	if id != "" {
+		if err := syncer.Resolve(store.ItemID(id), keep); err != nil {
+			return errors.Wrapf(err, "resolve item '%s'", id)
+		}
+	} else {
+		report, err := syncer.Sync()
+		if err != nil {
+			return errors.Wrap(err, "sync")
+		}
+		printReport(report)
+	}
+
+	if err := c.printItems(); err != nil {
+		return errors.Wrap(err, "print items")
+	}
+
+	return nil
+}
+
+// MigrateCommand upgrades the local items file to the version this build writes, or with -dry-run tells
+// what the upgrade would do.
+func (c *Cli) MigrateCommand(args []string) error {
+	cmd := flag.NewFlagSet("migrate", flag.ExitOnError)
+	var dryRun bool
+
+	cmd.BoolVar(&dryRun, "dry-run", false, "tell what would be migrated without changing the file")
+
+	if err := cmd.Parse(args); err != nil {
+		return errors.Wrapf(err, "parse arguments: %v", args)
+	}
+
+	migrator, ok := c.store.(interface {
+		MigrateItems(dryRun bool) (store.ItemsMigration, error)
+	})
+	if !ok {
+		return errors.New("migrate needs a local store")
+	}
+
+	migration, err := migrator.MigrateItems(dryRun)
+	if err != nil {
+		return errors.Wrap(err, "migrate items")
+	}
+
+	switch {
+	case migration.From == migration.To:
+		fmt.Printf("%s is at version %d, nothing to migrate\n", store.ItemsFilename, migration.To)
+	case dryRun:
+		fmt.Printf("would migrate %d items of %s from version %d to %d\n", migration.Items, store.ItemsFilename, migration.From, migration.To)
+	default:
+		fmt.Printf("migrated %d items of %s from version %d to %d\n", migration.Items, store.ItemsFilename, migration.From, migration.To)
+	}
+
+	return nil
+}
+
+// RotateKeyCommand encrypts the local files with the key in -new-key-file or the passphrase in
+// TODO_NEW_PASSPHRASE, or with -decrypt stores them unencrypted.
+func (c *Cli) RotateKeyCommand(args []string) error {
+	cmd := flag.NewFlagSet("rotate-key", flag.ExitOnError)
+	var (
+		newKeyFile string
+		decrypt    bool
+	)
+
+	cmd.StringVar(&newKeyFile, "new-key-file", "", "file with the hex encoded AES-256 key to encrypt with from now on")
+	cmd.BoolVar(&decrypt, "decrypt", false, "store the files unencrypted from now on")
+
+	if err := cmd.Parse(args); err != nil {
+		return errors.Wrapf(err, "parse arguments: %v", args)
+	}
+
+	key, err := store.ReadEncryptionKey(newKeyFile, store.NewPassphraseEnv)
+	if err != nil {
+		return errors.Wrap(err, "read new key")
+	}
+	if key == nil && !decrypt {
+		return errors.Errorf("missing new key, set -new-key-file or %s, or -decrypt", store.NewPassphraseEnv)
+	}
+	if key != nil && decrypt {
+		return errors.New("a new key and -decrypt exclude each other")
+	}
+
+	rotator, ok := c.store.(interface {
+		RotateKey(key *store.EncryptionKey) error
+	})
+	if !ok {
+		return errors.New("rotate-key needs a local store")
+	}
+
+	if err := rotator.RotateKey(key); err != nil {
+		return errors.Wrap(err, "rotate key")
+	}
+
+	return nil
+}
+
+func printReport(report offline.Report) {
+	fmt.Printf("pushed %d, pulled %d, removed %d, conflicts %d, rejected %d\n",
+		len(report.Pushed), len(report.Pulled), len(report.Removed), len(report.Conflicts), len(report.Rejected))
+
+	for _, rejection := range report.Rejected {
+		fmt.Printf("rejected %s: %s\n", rejection.ID, rejection.Reason)
+	}
+
+	for _, conflict := range report.Conflicts {
+		switch {
+		case conflict.Local == nil:
+			fmt.Printf("conflict %s: deleted locally, changed on the server\n", conflict.ID)
+		case conflict.Remote == nil:
+			fmt.Printf("conflict %s: changed locally, deleted on the server\n", conflict.ID)
+		default:
+			fmt.Printf("conflict %s: %s changed on both sides\n", conflict.ID, strings.Join(conflict.Fields, ", "))
+			fmt.Printf("  local:  %v\n", *conflict.Local)
+			fmt.Printf("  server: %v\n", *conflict.Remote)
+		}
+	}
+}
+
 func (c *Cli) printItems() error {
 	items, err := c.store.ReadAll()
 	if err != nil {
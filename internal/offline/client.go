@@ -0,0 +1,193 @@
+package offline
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"to-do-app-v2/api/handler"
+	"to-do-app-v2/api/v1"
+	"to-do-app-v2/internal/store"
+)
+
+const DefaultTimeout = 10 * time.Second
+
+// ErrRejected is returned when the server refuses an item as invalid.
+var ErrRejected = errors.New("rejected by the server")
+
+var _ Remote = (*Client)(nil)
+
+// Client calls the item API of a cmd/web server.
+type Client struct {
+	baseURL string
+	http    *http.Client
+}
+
+// NewClient returns a client for the server at baseURL, such as http://localhost:8080.
+func NewClient(baseURL string) *Client {
+	return &Client{
+		baseURL: strings.TrimSuffix(baseURL, "/") + v1.Prefix,
+		http:    &http.Client{Timeout: DefaultTimeout},
+	}
+}
+
+func (c *Client) ReadAll() ([]store.Item, error) {
+	var items []store.Item
+	err := c.do(http.MethodGet, "/items", nil, &items)
+	return items, err
+}
+
+func (c *Client) Read(id store.ItemID) (store.Item, error) {
+	var item store.Item
+	err := c.do(http.MethodGet, "/items/"+url.PathEscape(string(id)), nil, &item)
+	return item, err
+}
+
+// CreateIdempotent creates the item once per key, a retry with the same key returns the item created first and
+// reports true.
+func (c *Client) CreateIdempotent(key string, item store.Item) (store.Item, bool, error) {
+	req, err := c.newRequest(http.MethodPost, "/items", item)
+	if err != nil {
+		return store.Item{}, false, err
+	}
+	req.Header.Set(handler.IdempotencyKeyHeader, key)
+
+	var created store.Item
+	header, err := c.send(req, "/items", &created)
+	if err != nil {
+		return store.Item{}, false, err
+	}
+
+	return created, header.Get(handler.IdempotentReplayedHeader) == "true", nil
+}
+
+// Update replaces the item, failing with store.ErrVersionConflict unless the server holds it at item.Version.
+func (c *Client) Update(id store.ItemID, item store.Item) (store.Item, error) {
+	var updated store.Item
+	err := c.do(http.MethodPut, "/items/"+url.PathEscape(string(id)), item, &updated)
+	return updated, err
+}
+
+func (c *Client) Delete(id store.ItemID) error {
+	return c.do(http.MethodDelete, "/items/"+url.PathEscape(string(id)), nil, nil)
+}
+
+func (c *Client) RestoreDeleted(id store.ItemID) (store.Item, error) {
+	var item store.Item
+	err := c.do(http.MethodPost, "/items/"+url.PathEscape(string(id))+"/restore", nil, &item)
+	return item, err
+}
+
+func (c *Client) ReadArchived() ([]store.Item, error) {
+	var archived []store.Item
+	err := c.do(http.MethodGet, "/archive", nil, &archived)
+	return archived, err
+}
+
+func (c *Client) Unarchive(id store.ItemID) (store.Item, error) {
+	var item store.Item
+	err := c.do(http.MethodPost, "/items/"+url.PathEscape(string(id))+"/unarchive", nil, &item)
+	return item, err
+}
+
+func (c *Client) ReadHistory(id store.ItemID) ([]store.Activity, error) {
+	var history []store.Activity
+	err := c.do(http.MethodGet, "/items/"+url.PathEscape(string(id))+"/history", nil, &history)
+	return history, err
+}
+
+func (c *Client) ReadTrash() ([]store.TrashedItem, error) {
+	var trash []store.TrashedItem
+	err := c.do(http.MethodGet, "/trash", nil, &trash)
+	return trash, err
+}
+
+func (c *Client) do(method, path string, body, result any) error {
+	req, err := c.newRequest(method, path, body)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.send(req, path, result)
+	return err
+}
+
+func (c *Client) newRequest(method, path string, body any) (*http.Request, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, errors.Wrap(err, "encode request body")
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, c.baseURL+path, reader)
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s %s", method, path)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	return req, nil
+}
+
+// send sends the request to path and decodes the response body into result, returning the response header.
+func (c *Client) send(req *http.Request, path string, result any) (http.Header, error) {
+	method := req.Method
+	res, err := c.http.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s %s", method, path)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= http.StatusBadRequest {
+		return nil, problemError(method, path, res)
+	}
+
+	if result == nil {
+		return res.Header, nil
+	}
+
+	if err := json.NewDecoder(res.Body).Decode(result); err != nil {
+		return nil, errors.Wrapf(err, "decode response of %s %s", method, path)
+	}
+
+	return res.Header, nil
+}
+
+// problemError turns a problem response into an error matching the store sentinels, conflicts are told apart
+// by the problem type.
+func problemError(method, path string, res *http.Response) error {
+	var problem handler.Problem
+	if err := json.NewDecoder(res.Body).Decode(&problem); err != nil || problem.Detail == "" {
+		problem.Detail = res.Status
+	}
+	for _, fieldErr := range problem.Errors {
+		problem.Detail += ", " + fieldErr.Field + " " + fieldErr.Message
+	}
+
+	switch res.StatusCode {
+	case http.StatusNotFound:
+		return errors.Wrap(store.ErrNotFound, problem.Detail)
+	case http.StatusConflict:
+		switch problem.Type {
+		case handler.ProblemTypeVersionConflict:
+			return errors.Wrap(store.ErrVersionConflict, problem.Detail)
+		case handler.ProblemTypeIdempotencyConflict:
+			return errors.Wrap(store.ErrIdempotencyConflict, problem.Detail)
+		case handler.ProblemTypeAlreadyExists:
+			return errors.Wrap(store.ErrAlreadyExists, problem.Detail)
+		}
+		return errors.Errorf("%s %s: %s", method, path, problem.Detail)
+	case http.StatusUnprocessableEntity:
+		return errors.Wrap(ErrRejected, problem.Detail)
+	default:
+		return errors.Errorf("%s %s: %s", method, path, problem.Detail)
+	}
+}
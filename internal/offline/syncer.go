@@ -0,0 +1,495 @@
+// Package offline syncs a local store that was changed offline with a server. The local store keeps the
+// item changes made since the last sync, the syncer sends them to the server under the item versions they
+// were based on and then brings the local store in line with the server. Comments and history stay local.
+package offline
+
+import (
+	"github.com/pkg/errors"
+	"slices"
+	"time"
+	"to-do-app-v2/internal/store"
+)
+
+const (
+	// StrategyMerge merges the fields changed on one side only and reports items changed on both sides.
+	StrategyMerge = "merge"
+	// StrategyLastWriterWins keeps the side changed last.
+	StrategyLastWriterWins = "lww"
+	// StrategyFields merges the fields changed on one side only, keeps the side changed last for the fields
+	// changed on both and an edited item over a deleted one.
+	StrategyFields = "fields"
+
+	KeepLocal  = "local"
+	KeepRemote = "remote"
+)
+
+// Local is the store changed offline.
+type Local interface {
+	ReadAll() ([]store.Item, error)
+	Pending() ([]store.PendingChange, error)
+	ClearPending(ids ...store.ItemID) error
+	Export(ids ...store.ItemID) ([]store.ItemRecord, error)
+	Import(records ...store.ItemRecord) error
+	Evict(ids ...store.ItemID) error
+}
+
+// Remote is the server the local store syncs with.
+type Remote interface {
+	ReadAll() ([]store.Item, error)
+	Read(id store.ItemID) (store.Item, error)
+	CreateIdempotent(key string, item store.Item) (store.Item, bool, error)
+	Update(id store.ItemID, item store.Item) (store.Item, error)
+	Delete(id store.ItemID) error
+	RestoreDeleted(id store.ItemID) (store.Item, error)
+	ReadArchived() ([]store.Item, error)
+	Unarchive(id store.ItemID) (store.Item, error)
+	ReadHistory(id store.ItemID) ([]store.Activity, error)
+	ReadTrash() ([]store.TrashedItem, error)
+}
+
+// Report tells what a sync changed.
+type Report struct {
+	Pushed    []store.ItemID
+	Pulled    []store.ItemID
+	Removed   []store.ItemID
+	Conflicts []Conflict
+	Rejected  []Rejection
+}
+
+// Rejection is a local change the server refused as invalid. The change stays pending, so that it is sent
+// again once the item is fixed.
+type Rejection struct {
+	ID     store.ItemID
+	Reason string
+}
+
+// Conflict is an item changed on both sides that the strategy could not reconcile. A nil Local or Remote
+// means that side deleted the item, a Remote with ArchivedAt set that the server archived it. Otherwise Fields
+// lists the fields both sides changed differently. The local change stays pending until it is resolved.
+type Conflict struct {
+	ID     store.ItemID
+	Base   *store.Item
+	Local  *store.Item
+	Remote *store.Item
+	Fields []string
+}
+
+type Syncer struct {
+	local    Local
+	remote   Remote
+	strategy string
+}
+
+func NewSyncer(local Local, remote Remote, strategy string) (*Syncer, error) {
+	if strategy != StrategyMerge && strategy != StrategyLastWriterWins && strategy != StrategyFields {
+		return nil, errors.Errorf("unknown strategy '%s', expected %s, %s or %s", strategy, StrategyMerge, StrategyLastWriterWins, StrategyFields)
+	}
+
+	return &Syncer{
+		local:    local,
+		remote:   remote,
+		strategy: strategy,
+	}, nil
+}
+
+// Sync sends the pending local changes to the server, then updates the local store with the server items.
+// Items with a conflict are left as they are locally.
+func (s *Syncer) Sync() (Report, error) {
+	var report Report
+
+	pending, err := s.local.Pending()
+	if err != nil {
+		return report, errors.Wrap(err, "read pending changes")
+	}
+
+	// Items with a conflict or a rejected change are kept as they are locally
+	kept := make(map[store.ItemID]bool)
+	for _, change := range pending {
+		conflict, err := s.push(change, &report)
+		if errors.Is(err, ErrRejected) {
+			report.Rejected = append(report.Rejected, Rejection{ID: change.ID, Reason: err.Error()})
+			kept[change.ID] = true
+			continue
+		}
+		if err != nil {
+			return report, errors.Wrapf(err, "push item '%s'", change.ID)
+		}
+		if conflict != nil {
+			report.Conflicts = append(report.Conflicts, *conflict)
+			kept[change.ID] = true
+		}
+	}
+
+	if err := s.pull(kept, &report); err != nil {
+		return report, errors.Wrap(err, "pull items")
+	}
+
+	return report, nil
+}
+
+// Resolve settles the conflict of an item by keeping the local or the remote side.
+func (s *Syncer) Resolve(id store.ItemID, keep string) error {
+	pending, err := s.local.Pending()
+	if err != nil {
+		return errors.Wrap(err, "read pending changes")
+	}
+
+	index := slices.IndexFunc(pending, func(change store.PendingChange) bool {
+		return change.ID == id
+	})
+	if index < 0 {
+		return errors.Wrapf(store.ErrNotFound, "pending change of item '%s'", id)
+	}
+
+	remote, err := s.readRemote(id)
+	if err != nil {
+		return err
+	}
+
+	switch keep {
+	case KeepLocal:
+		return s.force(pending[index], remote)
+	case KeepRemote:
+		return s.adopt(id, remote)
+	default:
+		return errors.Errorf("unknown side '%s', expected %s or %s", keep, KeepLocal, KeepRemote)
+	}
+}
+
+// push sends a local change to the server, returning a conflict when the server changed the item too and
+// the strategy cannot reconcile both changes.
+func (s *Syncer) push(change store.PendingChange, report *Report) (*Conflict, error) {
+	if change.Base == nil && change.Item == nil {
+		return nil, s.local.ClearPending(change.ID)
+	}
+
+	remote, err := s.readRemote(change.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if change.Base == nil {
+		// The server gives created items new IDs, so a server item with the local ID is one the store had
+		// before it kept pending changes and recorded as created. It came from the server, it is not sent again.
+		if remote != nil {
+			report.Pulled = append(report.Pulled, change.ID)
+			return nil, s.adopt(change.ID, remote)
+		}
+
+		id, err := s.create(change)
+		if err != nil {
+			return nil, err
+		}
+		report.Pushed = append(report.Pushed, id)
+		return nil, nil
+	}
+
+	switch {
+	case remote == nil && change.Item == nil:
+		return nil, s.adopt(change.ID, nil)
+	case remote != nil && remote.Version == change.Base.Version:
+		report.Pushed = append(report.Pushed, change.ID)
+		return nil, s.write(change.ID, change.Item, remote)
+	case s.strategy == StrategyLastWriterWins:
+		return nil, s.lastWriterWins(change, remote, report)
+	case s.strategy == StrategyFields:
+		return nil, s.mergeFields(change, remote, report)
+	}
+
+	conflict := Conflict{ID: change.ID, Base: change.Base, Local: change.Item, Remote: remote}
+	if remote != nil && change.Item != nil {
+		merged, fields := merge(*change.Base, *change.Item, *remote)
+		if len(fields) == 0 {
+			report.Pushed = append(report.Pushed, change.ID)
+			return nil, s.write(change.ID, &merged, remote)
+		}
+		conflict.Fields = fields
+	}
+
+	return &conflict, nil
+}
+
+// create sends the local item to the server as a new item and returns the ID the server gave it. The
+// idempotency key is derived from the local ID, so that a sync retried after losing the response does not
+// create the item twice.
+func (s *Syncer) create(change store.PendingChange) (store.ItemID, error) {
+	item := *change.Item
+	item.ID = ""
+	item.Version = 0
+
+	created, _, err := s.remote.CreateIdempotent(idempotencyKey(change.ID), item)
+	if err != nil {
+		return "", errors.Wrap(err, "create item")
+	}
+
+	return store.ItemID(created.ID), s.adopt(change.ID, &created)
+}
+
+func idempotencyKey(id store.ItemID) string {
+	return "offline-create-" + string(id)
+}
+
+// lastWriterWins keeps the side changed last, comparing the time of the local change with the latest
+// history entry of the server item or the time the server deleted it.
+func (s *Syncer) lastWriterWins(change store.PendingChange, remote *store.Item, report *Report) error {
+	remoteTime, err := s.remoteChangeTime(change.ID, remote)
+	if err != nil {
+		return err
+	}
+
+	if change.Time.After(remoteTime) {
+		report.Pushed = append(report.Pushed, change.ID)
+		return s.force(change, remote)
+	}
+
+	report.Pulled = append(report.Pulled, change.ID)
+	return s.adopt(change.ID, remote)
+}
+
+// mergeFields merges the fields changed on one side only and keeps the side changed last for the fields
+// changed on both. The server keeps no time per field, so the time of the latest history entry of the server
+// item stands for all its changes. An item deleted on one side and edited on the other stays.
+func (s *Syncer) mergeFields(change store.PendingChange, remote *store.Item, report *Report) error {
+	switch {
+	case change.Item == nil:
+		report.Pulled = append(report.Pulled, change.ID)
+		return s.adopt(change.ID, remote)
+	case remote == nil:
+		report.Pushed = append(report.Pushed, change.ID)
+		return s.force(change, nil)
+	}
+
+	remoteTime, err := s.remoteChangeTime(change.ID, remote)
+	if err != nil {
+		return err
+	}
+
+	merged, fields := merge(*change.Base, *change.Item, *remote)
+	if len(fields) > 0 && change.Time.After(remoteTime) {
+		for _, field := range fields {
+			switch field {
+			case "name":
+				merged.Name = change.Item.Name
+			case "description":
+				merged.Desc = change.Item.Desc
+			case "status":
+				merged.Status = change.Item.Status
+			case "assignee":
+				merged.Assignee = change.Item.Assignee
+			}
+		}
+	}
+
+	report.Pushed = append(report.Pushed, change.ID)
+	return s.write(change.ID, &merged, remote)
+}
+
+func (s *Syncer) remoteChangeTime(id store.ItemID, remote *store.Item) (time.Time, error) {
+	if remote != nil {
+		history, err := s.remote.ReadHistory(id)
+		if err != nil {
+			return time.Time{}, errors.Wrap(err, "read history")
+		}
+		if len(history) == 0 {
+			return time.Time{}, nil
+		}
+		return history[len(history)-1].Time, nil
+	}
+
+	trash, err := s.remote.ReadTrash()
+	if err != nil {
+		return time.Time{}, errors.Wrap(err, "read trash")
+	}
+
+	for _, trashed := range trash {
+		if store.ItemID(trashed.ID) == id {
+			return trashed.DeletedAt, nil
+		}
+	}
+
+	// Purged from the trash long ago
+	return time.Time{}, nil
+}
+
+// force makes the server hold the local side of the item, bringing it back from the trash or creating it
+// again when the server deleted it.
+func (s *Syncer) force(change store.PendingChange, remote *store.Item) error {
+	switch {
+	case change.Item == nil && remote == nil:
+		return s.adopt(change.ID, nil)
+	case remote != nil:
+		return s.write(change.ID, change.Item, remote)
+	}
+
+	restored, err := s.remote.RestoreDeleted(change.ID)
+	if errors.Is(err, store.ErrNotFound) {
+		_, err := s.create(change)
+		return err
+	}
+	if err != nil {
+		return errors.Wrap(err, "restore item")
+	}
+
+	return s.write(change.ID, change.Item, &restored)
+}
+
+// write replaces the server item, which must still be at the version of remote, and adopts the result. A
+// nil item deletes it. An archived server item is returned to the list to be replaced and stays in the
+// archive rather than being deleted.
+func (s *Syncer) write(id store.ItemID, item *store.Item, remote *store.Item) error {
+	if item == nil {
+		if remote.ArchivedAt == nil {
+			if err := s.remote.Delete(id); err != nil {
+				return errors.Wrap(err, "delete item")
+			}
+		}
+		return s.adopt(id, nil)
+	}
+
+	if remote.ArchivedAt != nil {
+		if _, err := s.remote.Unarchive(id); err != nil {
+			return errors.Wrap(err, "unarchive item")
+		}
+	}
+
+	update := *item
+	update.Version = remote.Version
+	update.ArchivedAt = nil
+
+	updated, err := s.remote.Update(id, update)
+	if err != nil {
+		return errors.Wrap(err, "update item")
+	}
+
+	return s.adopt(id, &updated)
+}
+
+// adopt makes the local store hold the server item, nil when the server has none or archived it, and forgets
+// the local change. The local comments and history of the item are kept.
+func (s *Syncer) adopt(id store.ItemID, item *store.Item) error {
+	if item == nil || item.ArchivedAt != nil {
+		if err := s.local.Evict(id); err != nil {
+			return errors.Wrap(err, "evict item")
+		}
+		return s.local.ClearPending(id)
+	}
+
+	record := store.ItemRecord{Item: *item}
+	records, err := s.local.Export(id)
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+	case err != nil:
+		return errors.Wrap(err, "export item")
+	default:
+		record = records[0]
+		record.Item = *item
+	}
+
+	if store.ItemID(item.ID) != id {
+		if err := s.local.Evict(id); err != nil {
+			return errors.Wrap(err, "evict item")
+		}
+	}
+
+	if err := s.local.Import(record); err != nil {
+		return errors.Wrap(err, "import item")
+	}
+
+	return s.local.ClearPending(id)
+}
+
+// pull brings the local items in line with the server items, leaving the kept items alone.
+func (s *Syncer) pull(kept map[store.ItemID]bool, report *Report) error {
+	remoteItems, err := s.remote.ReadAll()
+	if err != nil {
+		return errors.Wrap(err, "read server items")
+	}
+
+	localItems, err := s.local.ReadAll()
+	if err != nil {
+		return errors.Wrap(err, "read local items")
+	}
+
+	versions := make(map[store.ItemID]int64, len(localItems))
+	for _, item := range localItems {
+		versions[store.ItemID(item.ID)] = item.Version
+	}
+
+	onServer := make(map[store.ItemID]bool, len(remoteItems))
+	for _, item := range remoteItems {
+		id := store.ItemID(item.ID)
+		onServer[id] = true
+
+		if version, found := versions[id]; kept[id] || found && version == item.Version {
+			continue
+		}
+
+		if err := s.adopt(id, &item); err != nil {
+			return err
+		}
+		report.Pulled = append(report.Pulled, id)
+	}
+
+	for _, item := range localItems {
+		id := store.ItemID(item.ID)
+		if onServer[id] || kept[id] {
+			continue
+		}
+
+		if err := s.adopt(id, nil); err != nil {
+			return err
+		}
+		report.Removed = append(report.Removed, id)
+	}
+
+	return nil
+}
+
+// readRemote returns the server item, archived or not, or nil when the server has none.
+func (s *Syncer) readRemote(id store.ItemID) (*store.Item, error) {
+	item, err := s.remote.Read(id)
+	if err == nil {
+		return &item, nil
+	}
+	if !errors.Is(err, store.ErrNotFound) {
+		return nil, errors.Wrap(err, "read server item")
+	}
+
+	archived, err := s.remote.ReadArchived()
+	if err != nil {
+		return nil, errors.Wrap(err, "read server archive")
+	}
+
+	index := slices.IndexFunc(archived, func(item store.Item) bool {
+		return store.ItemID(item.ID) == id
+	})
+	if index < 0 {
+		return nil, nil
+	}
+
+	return &archived[index], nil
+}
+
+// merge combines the changes both sides made since base, keeping the server value of the fields both sides
+// changed differently and returning their names.
+func merge(base, local, remote store.Item) (store.Item, []string) {
+	var conflicts []string
+	pick := func(field, base, local, remote string) string {
+		switch {
+		case local == remote, local == base:
+			return remote
+		case remote == base:
+			return local
+		}
+		conflicts = append(conflicts, field)
+		return remote
+	}
+
+	merged := remote
+	merged.Name = pick("name", base.Name, local.Name, remote.Name)
+	merged.Desc = pick("description", base.Desc, local.Desc, remote.Desc)
+	merged.Status = pick("status", base.Status, local.Status, remote.Status)
+	merged.Assignee = pick("assignee", base.Assignee, local.Assignee, remote.Assignee)
+
+	return merged, conflicts
+}
@@ -0,0 +1,364 @@
+package offline
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"to-do-app-v2/api/handler"
+	"to-do-app-v2/api/v1"
+	"to-do-app-v2/internal/store"
+)
+
+// serveServer serves the item API of a new store, standing in for cmd/web.
+func serveServer(t *testing.T) (*store.Store, *Client) {
+	t.Helper()
+
+	server := store.NewStore(store.WithDir(t.TempDir()), store.WithJanitorInterval(0))
+	t.Cleanup(server.Close)
+
+	mux := http.NewServeMux()
+	require.NoError(t, v1.Register(mux, handler.NewHandler(server)))
+	httpServer := httptest.NewServer(mux)
+	t.Cleanup(httpServer.Close)
+
+	return server, NewClient(httpServer.URL)
+}
+
+func newTestLocal(t *testing.T) *store.Store {
+	t.Helper()
+
+	local := store.NewStore(store.WithDir(t.TempDir()), store.WithJanitorInterval(0), store.WithPendingChanges())
+	t.Cleanup(local.Close)
+
+	return local
+}
+
+// syncedItem creates an item on the server and syncs it into the local store.
+func syncedItem(t *testing.T, server *store.Store, syncer *Syncer, item store.Item) store.Item {
+	t.Helper()
+
+	created, err := server.Create(item)
+	require.NoError(t, err)
+	_, err = syncer.Sync()
+	require.NoError(t, err)
+
+	return created
+}
+
+func Test_Sync_PushesLocalChangesAndPullsServerItems(t *testing.T) {
+	server, client := serveServer(t)
+	local := newTestLocal(t)
+	syncer, err := NewSyncer(local, client, StrategyMerge)
+	require.NoError(t, err)
+	serverItem, err := server.Create(store.Item{Name: "name1", Status: store.StatusNotStarted})
+	require.NoError(t, err)
+	_, err = local.Create(store.Item{Name: "name2", Status: store.StatusNotStarted})
+	require.NoError(t, err)
+
+	report, err := syncer.Sync()
+
+	assert.NoError(t, err)
+	assert.Len(t, report.Pushed, 1)
+	assert.Equal(t, []store.ItemID{store.ItemID(serverItem.ID)}, report.Pulled)
+	assert.Empty(t, report.Conflicts)
+	serverItems, err := server.ReadAll()
+	require.NoError(t, err)
+	localItems, err := local.ReadAll()
+	require.NoError(t, err)
+	assert.ElementsMatch(t, serverItems, localItems)
+	pending, err := local.Pending()
+	assert.NoError(t, err)
+	assert.Empty(t, pending)
+}
+
+func Test_Sync_CreatesItemOnceWhenRetried(t *testing.T) {
+	server, client := serveServer(t)
+	local := newTestLocal(t)
+	syncer, err := NewSyncer(local, client, StrategyMerge)
+	require.NoError(t, err)
+	item, err := local.Create(store.Item{Name: "name1", Status: store.StatusNotStarted})
+	require.NoError(t, err)
+	// A sync that created the item on the server but lost the response
+	_, _, err = client.CreateIdempotent(idempotencyKey(store.ItemID(item.ID)), store.Item{Name: "name1", Status: store.StatusNotStarted})
+	require.NoError(t, err)
+
+	_, err = syncer.Sync()
+
+	assert.NoError(t, err)
+	serverItems, err := server.ReadAll()
+	require.NoError(t, err)
+	assert.Len(t, serverItems, 1)
+	localItems, err := local.ReadAll()
+	require.NoError(t, err)
+	assert.Equal(t, serverItems, localItems)
+}
+
+func Test_Sync_ReportsRejectedItemsAndContinues(t *testing.T) {
+	server, client := serveServer(t)
+	local := newTestLocal(t)
+	syncer, err := NewSyncer(local, client, StrategyMerge)
+	require.NoError(t, err)
+	invalid, err := local.Create(store.Item{Name: " ", Status: store.StatusNotStarted})
+	require.NoError(t, err)
+	_, err = local.Create(store.Item{Name: "name2", Status: store.StatusNotStarted})
+	require.NoError(t, err)
+
+	report, err := syncer.Sync()
+
+	assert.NoError(t, err)
+	require.Len(t, report.Rejected, 1)
+	assert.Equal(t, store.ItemID(invalid.ID), report.Rejected[0].ID)
+	assert.Contains(t, report.Rejected[0].Reason, "name must not be empty")
+	assert.Len(t, report.Pushed, 1)
+	serverItems, err := server.ReadAll()
+	require.NoError(t, err)
+	assert.Len(t, serverItems, 1)
+	kept, err := local.Read(store.ItemID(invalid.ID))
+	assert.NoError(t, err)
+	assert.Equal(t, invalid, kept)
+	pending, err := local.Pending()
+	assert.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.Equal(t, store.ItemID(invalid.ID), pending[0].ID)
+}
+
+func Test_Sync_AdoptsExistingItemsTheServerHas(t *testing.T) {
+	server, client := serveServer(t)
+	serverItem, err := server.Create(store.Item{Name: "name1", Status: store.StatusNotStarted})
+	require.NoError(t, err)
+	records, err := server.Export(store.ItemID(serverItem.ID))
+	require.NoError(t, err)
+	// A store that had the server items before it kept pending changes
+	dir := t.TempDir()
+	plain := store.NewStore(store.WithDir(dir), store.WithJanitorInterval(0))
+	require.NoError(t, plain.Import(records...))
+	plain.Close()
+	local := store.NewStore(store.WithDir(dir), store.WithJanitorInterval(0), store.WithPendingChanges())
+	t.Cleanup(local.Close)
+	localItem, err := local.Create(store.Item{Name: "name2", Status: store.StatusNotStarted})
+	require.NoError(t, err)
+	syncer, err := NewSyncer(local, client, StrategyMerge)
+	require.NoError(t, err)
+
+	report, err := syncer.Sync()
+
+	assert.NoError(t, err)
+	assert.Equal(t, []store.ItemID{store.ItemID(serverItem.ID)}, report.Pulled)
+	assert.Len(t, report.Pushed, 1)
+	assert.NotContains(t, report.Pushed, store.ItemID(localItem.ID))
+	serverItems, err := server.ReadAll()
+	require.NoError(t, err)
+	assert.Len(t, serverItems, 2)
+	pending, err := local.Pending()
+	assert.NoError(t, err)
+	assert.Empty(t, pending)
+}
+
+func Test_Sync_MergesFieldsChangedOnOneSide(t *testing.T) {
+	server, client := serveServer(t)
+	local := newTestLocal(t)
+	syncer, err := NewSyncer(local, client, StrategyMerge)
+	require.NoError(t, err)
+	item := syncedItem(t, server, syncer, store.Item{Name: "name1", Desc: "desc1", Status: store.StatusNotStarted})
+	_, err = local.Update(store.ItemID(item.ID), store.Item{Name: "name2", Desc: "desc1", Status: store.StatusNotStarted})
+	require.NoError(t, err)
+	_, err = server.Update(store.ItemID(item.ID), store.Item{Name: "name1", Desc: "desc2", Status: store.StatusNotStarted})
+	require.NoError(t, err)
+
+	report, err := syncer.Sync()
+
+	assert.NoError(t, err)
+	assert.Empty(t, report.Conflicts)
+	serverItem, err := server.Read(store.ItemID(item.ID))
+	require.NoError(t, err)
+	assert.Equal(t, "name2", serverItem.Name)
+	assert.Equal(t, "desc2", serverItem.Desc)
+	localItem, err := local.Read(store.ItemID(item.ID))
+	assert.NoError(t, err)
+	assert.Equal(t, serverItem, localItem)
+}
+
+func Test_Sync_ReportsFieldsChangedOnBothSides(t *testing.T) {
+	server, client := serveServer(t)
+	local := newTestLocal(t)
+	syncer, err := NewSyncer(local, client, StrategyMerge)
+	require.NoError(t, err)
+	item := syncedItem(t, server, syncer, store.Item{Name: "name1", Status: store.StatusNotStarted})
+	localItem, err := local.Update(store.ItemID(item.ID), store.Item{Name: "local", Status: store.StatusNotStarted})
+	require.NoError(t, err)
+	_, err = server.Update(store.ItemID(item.ID), store.Item{Name: "remote", Status: store.StatusNotStarted})
+	require.NoError(t, err)
+
+	report, err := syncer.Sync()
+
+	assert.NoError(t, err)
+	require.Len(t, report.Conflicts, 1)
+	assert.Equal(t, store.ItemID(item.ID), report.Conflicts[0].ID)
+	assert.Equal(t, []string{"name"}, report.Conflicts[0].Fields)
+	unchanged, err := local.Read(store.ItemID(item.ID))
+	assert.NoError(t, err)
+	assert.Equal(t, localItem, unchanged)
+	pending, err := local.Pending()
+	assert.NoError(t, err)
+	assert.Len(t, pending, 1)
+}
+
+func Test_Resolve_KeepsChosenSide(t *testing.T) {
+	for _, keep := range []string{KeepLocal, KeepRemote} {
+		t.Run(keep, func(t *testing.T) {
+			server, client := serveServer(t)
+			local := newTestLocal(t)
+			syncer, err := NewSyncer(local, client, StrategyMerge)
+			require.NoError(t, err)
+			item := syncedItem(t, server, syncer, store.Item{Name: "name1", Status: store.StatusNotStarted})
+			_, err = local.Update(store.ItemID(item.ID), store.Item{Name: KeepLocal, Status: store.StatusNotStarted})
+			require.NoError(t, err)
+			_, err = server.Update(store.ItemID(item.ID), store.Item{Name: KeepRemote, Status: store.StatusNotStarted})
+			require.NoError(t, err)
+			_, err = syncer.Sync()
+			require.NoError(t, err)
+
+			err = syncer.Resolve(store.ItemID(item.ID), keep)
+
+			assert.NoError(t, err)
+			serverItem, err := server.Read(store.ItemID(item.ID))
+			require.NoError(t, err)
+			assert.Equal(t, keep, serverItem.Name)
+			localItem, err := local.Read(store.ItemID(item.ID))
+			assert.NoError(t, err)
+			assert.Equal(t, serverItem, localItem)
+			pending, err := local.Pending()
+			assert.NoError(t, err)
+			assert.Empty(t, pending)
+		})
+	}
+}
+
+func Test_Sync_LastWriterWinsKeepsLaterChange(t *testing.T) {
+	server, client := serveServer(t)
+	local := newTestLocal(t)
+	syncer, err := NewSyncer(local, client, StrategyLastWriterWins)
+	require.NoError(t, err)
+	item := syncedItem(t, server, syncer, store.Item{Name: "name1", Status: store.StatusNotStarted})
+	_, err = server.Update(store.ItemID(item.ID), store.Item{Name: "remote", Status: store.StatusNotStarted})
+	require.NoError(t, err)
+	_, err = local.Update(store.ItemID(item.ID), store.Item{Name: "local", Status: store.StatusNotStarted})
+	require.NoError(t, err)
+
+	report, err := syncer.Sync()
+
+	assert.NoError(t, err)
+	assert.Empty(t, report.Conflicts)
+	serverItem, err := server.Read(store.ItemID(item.ID))
+	require.NoError(t, err)
+	assert.Equal(t, "local", serverItem.Name)
+}
+
+func Test_Sync_FieldsKeepsLaterWriteOfFieldsChangedOnBothSides(t *testing.T) {
+	server, client := serveServer(t)
+	local := newTestLocal(t)
+	syncer, err := NewSyncer(local, client, StrategyFields)
+	require.NoError(t, err)
+	item := syncedItem(t, server, syncer, store.Item{Name: "name1", Desc: "desc1", Status: store.StatusNotStarted})
+	_, err = server.Update(store.ItemID(item.ID), store.Item{Name: "remote", Desc: "desc2", Status: store.StatusNotStarted})
+	require.NoError(t, err)
+	_, err = local.Update(store.ItemID(item.ID), store.Item{Name: "local", Desc: "desc1", Status: store.StatusStarted})
+	require.NoError(t, err)
+
+	report, err := syncer.Sync()
+
+	assert.NoError(t, err)
+	assert.Empty(t, report.Conflicts)
+	serverItem, err := server.Read(store.ItemID(item.ID))
+	require.NoError(t, err)
+	assert.Equal(t, "local", serverItem.Name)
+	assert.Equal(t, "desc2", serverItem.Desc)
+	assert.Equal(t, store.StatusStarted, serverItem.Status)
+	localItem, err := local.Read(store.ItemID(item.ID))
+	assert.NoError(t, err)
+	assert.Equal(t, serverItem, localItem)
+}
+
+func Test_Sync_RemovesItemsDeletedOnServer(t *testing.T) {
+	server, client := serveServer(t)
+	local := newTestLocal(t)
+	syncer, err := NewSyncer(local, client, StrategyMerge)
+	require.NoError(t, err)
+	item := syncedItem(t, server, syncer, store.Item{Name: "name1", Status: store.StatusNotStarted})
+	require.NoError(t, server.Delete(store.ItemID(item.ID)))
+
+	report, err := syncer.Sync()
+
+	assert.NoError(t, err)
+	assert.Equal(t, []store.ItemID{store.ItemID(item.ID)}, report.Removed)
+	_, err = local.Read(store.ItemID(item.ID))
+	assert.ErrorIs(t, err, store.ErrNotFound)
+}
+
+func Test_Sync_ReturnsItemsArchivedOnServerToTheListWithLocalChange(t *testing.T) {
+	server, client := serveServer(t)
+	local := newTestLocal(t)
+	syncer, err := NewSyncer(local, client, StrategyLastWriterWins)
+	require.NoError(t, err)
+	item := syncedItem(t, server, syncer, store.Item{Name: "name1", Status: store.StatusNotStarted})
+	_, err = server.Archive(store.ItemID(item.ID))
+	require.NoError(t, err)
+	_, err = local.Update(store.ItemID(item.ID), store.Item{Name: "local", Status: store.StatusNotStarted})
+	require.NoError(t, err)
+
+	report, err := syncer.Sync()
+
+	assert.NoError(t, err)
+	assert.Equal(t, []store.ItemID{store.ItemID(item.ID)}, report.Pushed)
+	serverItems, err := server.ReadAll()
+	require.NoError(t, err)
+	require.Len(t, serverItems, 1)
+	assert.Equal(t, item.ID, serverItems[0].ID)
+	assert.Equal(t, "local", serverItems[0].Name)
+	assert.Nil(t, serverItems[0].ArchivedAt)
+	archived, err := server.ReadArchived()
+	assert.NoError(t, err)
+	assert.Empty(t, archived)
+}
+
+func Test_Sync_RemovesItemsArchivedOnServer(t *testing.T) {
+	server, client := serveServer(t)
+	local := newTestLocal(t)
+	syncer, err := NewSyncer(local, client, StrategyLastWriterWins)
+	require.NoError(t, err)
+	item := syncedItem(t, server, syncer, store.Item{Name: "name1", Status: store.StatusNotStarted})
+	require.NoError(t, local.Delete(store.ItemID(item.ID)))
+	_, err = server.Archive(store.ItemID(item.ID))
+	require.NoError(t, err)
+
+	_, err = syncer.Sync()
+
+	assert.NoError(t, err)
+	_, err = local.Read(store.ItemID(item.ID))
+	assert.ErrorIs(t, err, store.ErrNotFound)
+	archived, err := server.ReadArchived()
+	assert.NoError(t, err)
+	require.Len(t, archived, 1)
+	assert.Equal(t, item.ID, archived[0].ID)
+}
+
+func Test_Client_TellsConflictsApartByProblemType(t *testing.T) {
+	_, client := serveServer(t)
+	item, _, err := client.CreateIdempotent("key1", store.Item{Name: "name1", Status: store.StatusNotStarted})
+	require.NoError(t, err)
+
+	_, _, err = client.CreateIdempotent("key1", store.Item{Name: "name2", Status: store.StatusNotStarted})
+	assert.ErrorIs(t, err, store.ErrIdempotencyConflict)
+	assert.NotErrorIs(t, err, store.ErrVersionConflict)
+
+	_, err = client.Update(store.ItemID(item.ID), store.Item{Name: "name2", Status: store.StatusNotStarted, Version: item.Version + 1})
+	assert.ErrorIs(t, err, store.ErrVersionConflict)
+}
+
+func Test_NewSyncer_RejectsUnknownStrategy(t *testing.T) {
+	_, err := NewSyncer(nil, nil, "newest")
+
+	assert.Error(t, err)
+}
@@ -0,0 +1,308 @@
+package replica
+
+import (
+	"cmp"
+	"github.com/pkg/errors"
+	"log/slog"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+	"to-do-app-v2/internal/rpc"
+	"to-do-app-v2/internal/store"
+)
+
+var ErrNoFollowers = errors.New("no follower to promote")
+
+var _ rpc.Service = (*Cluster)(nil)
+
+// Cluster sends writes to the leader and spreads reads over the followers. When the leader cannot be
+// reached, the follower that applied the latest event is promoted and the other followers follow it, then
+// the write is tried once more on the new leader.
+type Cluster struct {
+	mu        sync.RWMutex
+	leader    rpc.Service
+	followers []*Follower
+	next      atomic.Uint64
+}
+
+// NewCluster makes the followers follow the leader.
+func NewCluster(leader rpc.Service, followers ...*Follower) (*Cluster, error) {
+	for _, follower := range followers {
+		if err := follower.Follow(leader); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Cluster{
+		leader:    leader,
+		followers: followers,
+	}, nil
+}
+
+// Leader returns the store taking the writes.
+func (c *Cluster) Leader() rpc.Service {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.leader
+}
+
+// Followers returns the followers serving the reads.
+func (c *Cluster) Followers() []*Follower {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return slices.Clone(c.followers)
+}
+
+// failover promotes the most up to date follower unless another call already replaced the failed leader.
+func (c *Cluster) failover(failed rpc.Service) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.leader != failed {
+		return nil
+	}
+	if len(c.followers) == 0 {
+		return ErrNoFollowers
+	}
+
+	newest := slices.MaxFunc(c.followers, func(a, b *Follower) int {
+		return cmp.Compare(a.LastEventID(), b.LastEventID())
+	})
+	newest.Promote()
+
+	c.leader = newest
+	c.followers = slices.DeleteFunc(c.followers, func(follower *Follower) bool {
+		return follower == newest
+	})
+	slog.Info("promoted follower to leader", "last_event_id", newest.LastEventID())
+
+	for _, follower := range c.followers {
+		if err := follower.Follow(newest); err != nil {
+			return errors.Wrap(err, "follow new leader")
+		}
+	}
+
+	return nil
+}
+
+// onLeader calls f on the leader, failing over and calling it once more when the leader is unavailable.
+func onLeader[T any](c *Cluster, f func(rpc.Service) (T, error)) (T, error) {
+	leader := c.Leader()
+
+	result, err := f(leader)
+	if !errors.Is(err, rpc.ErrUnavailable) {
+		return result, err
+	}
+
+	if failoverErr := c.failover(leader); failoverErr != nil {
+		return result, errors.Wrapf(err, "fail over: %s", failoverErr)
+	}
+
+	return f(c.Leader())
+}
+
+// onFollower calls f on the next follower, and on the leader when the follower did not find what was asked
+// as it may not have applied a recent write yet. Without followers the leader is read.
+func onFollower[T any](c *Cluster, f func(rpc.Service) (T, error)) (T, error) {
+	c.mu.RLock()
+	var follower *Follower
+	if len(c.followers) > 0 {
+		follower = c.followers[c.next.Add(1)%uint64(len(c.followers))]
+	}
+	c.mu.RUnlock()
+
+	if follower == nil {
+		return onLeader(c, f)
+	}
+
+	result, err := f(follower)
+	if !errors.Is(err, store.ErrNotFound) {
+		return result, err
+	}
+
+	return onLeader(c, f)
+}
+
+func (c *Cluster) Create(item store.Item) (store.Item, error) {
+	return onLeader(c, func(service rpc.Service) (store.Item, error) {
+		return service.Create(item)
+	})
+}
+
+func (c *Cluster) CreateIdempotent(user, key string, item store.Item) (store.Item, bool, error) {
+	type created struct {
+		item     store.Item
+		replayed bool
+	}
+
+	result, err := onLeader(c, func(service rpc.Service) (created, error) {
+		item, replayed, err := service.CreateIdempotent(user, key, item)
+		return created{item, replayed}, err
+	})
+	return result.item, result.replayed, err
+}
+
+func (c *Cluster) ReadAll() ([]store.Item, error) {
+	return onFollower(c, rpc.Service.ReadAll)
+}
+
+func (c *Cluster) Read(id store.ItemID) (store.Item, error) {
+	return onFollower(c, func(service rpc.Service) (store.Item, error) {
+		return service.Read(id)
+	})
+}
+
+func (c *Cluster) Update(id store.ItemID, item store.Item) (store.Item, error) {
+	return onLeader(c, func(service rpc.Service) (store.Item, error) {
+		return service.Update(id, item)
+	})
+}
+
+func (c *Cluster) Delete(id store.ItemID) error {
+	_, err := onLeader(c, func(service rpc.Service) (struct{}, error) {
+		return struct{}{}, service.Delete(id)
+	})
+	return err
+}
+
+func (c *Cluster) Assign(id store.ItemID, assignee string) (store.Item, error) {
+	return onLeader(c, func(service rpc.Service) (store.Item, error) {
+		return service.Assign(id, assignee)
+	})
+}
+
+func (c *Cluster) ReadAssigned(assignee string) ([]store.Item, error) {
+	return onFollower(c, func(service rpc.Service) ([]store.Item, error) {
+		return service.ReadAssigned(assignee)
+	})
+}
+
+func (c *Cluster) AddComment(id store.ItemID, comment store.Comment) (store.Comment, error) {
+	return onLeader(c, func(service rpc.Service) (store.Comment, error) {
+		return service.AddComment(id, comment)
+	})
+}
+
+func (c *Cluster) ReadComments(id store.ItemID) ([]store.Comment, error) {
+	return onFollower(c, func(service rpc.Service) ([]store.Comment, error) {
+		return service.ReadComments(id)
+	})
+}
+
+func (c *Cluster) ReadHistory(id store.ItemID) ([]store.Activity, error) {
+	return onFollower(c, func(service rpc.Service) ([]store.Activity, error) {
+		return service.ReadHistory(id)
+	})
+}
+
+func (c *Cluster) Restore(at time.Time) ([]store.Item, error) {
+	return onLeader(c, func(service rpc.Service) ([]store.Item, error) {
+		return service.Restore(at)
+	})
+}
+
+// ReadTrash reads the trash of the leader, the followers do not replicate it.
+func (c *Cluster) ReadTrash() ([]store.TrashedItem, error) {
+	return onLeader(c, rpc.Service.ReadTrash)
+}
+
+func (c *Cluster) RestoreDeleted(id store.ItemID) (store.Item, error) {
+	return onLeader(c, func(service rpc.Service) (store.Item, error) {
+		return service.RestoreDeleted(id)
+	})
+}
+
+func (c *Cluster) Archive(id store.ItemID) (store.Item, error) {
+	return onLeader(c, func(service rpc.Service) (store.Item, error) {
+		return service.Archive(id)
+	})
+}
+
+func (c *Cluster) ArchiveCompleted() ([]store.Item, error) {
+	return onLeader(c, rpc.Service.ArchiveCompleted)
+}
+
+// ReadArchived reads the archive of the leader, the followers do not replicate it.
+func (c *Cluster) ReadArchived() ([]store.Item, error) {
+	return onLeader(c, rpc.Service.ReadArchived)
+}
+
+func (c *Cluster) Unarchive(id store.ItemID) (store.Item, error) {
+	return onLeader(c, func(service rpc.Service) (store.Item, error) {
+		return service.Unarchive(id)
+	})
+}
+
+func (c *Cluster) Batch(operations []store.Operation) ([]store.OperationResult, error) {
+	return onLeader(c, func(service rpc.Service) ([]store.OperationResult, error) {
+		return service.Batch(operations)
+	})
+}
+
+func (c *Cluster) Undo() error {
+	_, err := onLeader(c, func(service rpc.Service) (struct{}, error) {
+		return struct{}{}, service.Undo()
+	})
+	return err
+}
+
+func (c *Cluster) Redo() error {
+	_, err := onLeader(c, func(service rpc.Service) (struct{}, error) {
+		return struct{}{}, service.Redo()
+	})
+	return err
+}
+
+func (c *Cluster) Export(ids ...store.ItemID) ([]store.ItemRecord, error) {
+	return onLeader(c, func(service rpc.Service) ([]store.ItemRecord, error) {
+		return service.Export(ids...)
+	})
+}
+
+func (c *Cluster) Import(records ...store.ItemRecord) error {
+	_, err := onLeader(c, func(service rpc.Service) (struct{}, error) {
+		return struct{}{}, service.Import(records...)
+	})
+	return err
+}
+
+func (c *Cluster) Evict(ids ...store.ItemID) error {
+	_, err := onLeader(c, func(service rpc.Service) (struct{}, error) {
+		return struct{}{}, service.Evict(ids...)
+	})
+	return err
+}
+
+func (c *Cluster) AddWebhook(webhook store.Webhook) (store.Webhook, error) {
+	return onLeader(c, func(service rpc.Service) (store.Webhook, error) {
+		return service.AddWebhook(webhook)
+	})
+}
+
+func (c *Cluster) ReadWebhooks() ([]store.Webhook, error) {
+	return onLeader(c, rpc.Service.ReadWebhooks)
+}
+
+func (c *Cluster) DeleteWebhook(id string) error {
+	_, err := onLeader(c, func(service rpc.Service) (struct{}, error) {
+		return struct{}{}, service.DeleteWebhook(id)
+	})
+	return err
+}
+
+func (c *Cluster) ReadDeliveries(id string) ([]store.Delivery, error) {
+	return onLeader(c, func(service rpc.Service) ([]store.Delivery, error) {
+		return service.ReadDeliveries(id)
+	})
+}
+
+// Subscribe subscribes to the leader, where the events are published. The subscription closes when the
+// leader goes away and resubscribing reaches the new leader.
+func (c *Cluster) Subscribe(lastEventID uint64) (*store.Subscription, error) {
+	return onLeader(c, func(service rpc.Service) (*store.Subscription, error) {
+		return service.Subscribe(lastEventID)
+	})
+}
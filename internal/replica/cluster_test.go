@@ -0,0 +1,70 @@
+package replica
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"to-do-app-v2/internal/rpc"
+	"to-do-app-v2/internal/store"
+)
+
+func Test_Cluster_ReadsFromFollowers(t *testing.T) {
+	leader, _ := serveLeader(t)
+	followers := []*Follower{newTestFollower(t), newTestFollower(t)}
+	cluster, err := NewCluster(leader, followers...)
+	require.NoError(t, err)
+
+	item, err := cluster.Create(store.Item{Name: "name1"})
+	require.NoError(t, err)
+	actualItem, err := cluster.Read(store.ItemID(item.ID))
+
+	assert.NoError(t, err)
+	assert.Equal(t, item, actualItem)
+	for _, follower := range followers {
+		assertEventuallyHasItems(t, follower, []store.Item{item})
+	}
+	_, err = followers[0].Create(store.Item{Name: "name2"})
+	assert.ErrorIs(t, err, store.ErrReadOnly)
+}
+
+func Test_Cluster_PromotesFollowerWhenLeaderIsKilled(t *testing.T) {
+	leader, server := serveLeader(t)
+	followers := []*Follower{newTestFollower(t), newTestFollower(t), newTestFollower(t)}
+	cluster, err := NewCluster(leader, followers...)
+	require.NoError(t, err)
+	var items []store.Item
+	for _, name := range []string{"name1", "name2", "name3"} {
+		item, err := cluster.Create(store.Item{Name: name})
+		require.NoError(t, err)
+		items = append(items, item)
+	}
+	for _, follower := range followers {
+		assertEventuallyHasItems(t, follower, items)
+	}
+
+	server.Stop()
+	item, err := cluster.Create(store.Item{Name: "name4"})
+
+	require.NoError(t, err)
+	items = append(items, item)
+	newLeader, ok := cluster.Leader().(*Follower)
+	require.True(t, ok)
+	assert.True(t, newLeader.Promoted())
+	assert.Len(t, cluster.Followers(), 2)
+	for _, follower := range cluster.Followers() {
+		assert.False(t, follower.Promoted())
+		assertEventuallyHasItems(t, follower, items)
+	}
+	assertEventuallyHasItems(t, cluster, items)
+}
+
+func Test_Cluster_ReturnsErrorWithoutFollowers(t *testing.T) {
+	leader, server := serveLeader(t)
+	cluster, err := NewCluster(leader)
+	require.NoError(t, err)
+
+	server.Stop()
+	_, err = cluster.Create(store.Item{Name: "name1"})
+
+	assert.ErrorIs(t, err, rpc.ErrUnavailable)
+}
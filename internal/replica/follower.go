@@ -0,0 +1,589 @@
+package replica
+
+import (
+	"github.com/pkg/errors"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+	"to-do-app-v2/internal/rpc"
+	"to-do-app-v2/internal/store"
+)
+
+const DefaultRetryInterval = time.Second
+
+var _ rpc.Service = (*Follower)(nil)
+
+// Leader is the store a follower replicates.
+type Leader interface {
+	Subscribe(lastEventID uint64) (*store.Subscription, error)
+	ReadAll() ([]store.Item, error)
+	Export(ids ...store.ItemID) ([]store.ItemRecord, error)
+	ReadTrash() ([]store.TrashedItem, error)
+	ReadArchived() ([]store.Item, error)
+	ReadWebhooks() ([]store.Webhook, error)
+}
+
+// Follower keeps a local store in line with a leader and serves reads from it. It copies a snapshot of the
+// leader once, then applies the changes the events of the leader carry, resuming after the last event
+// applied when it loses the leader. Applying an event twice is harmless. Items the leader deletes or archives
+// move to the trash or archive of the follower, which are purged and archived by the janitor of the leader
+// until the follower is promoted and starts its own. Writes fail with store.ErrReadOnly until then.
+type Follower struct {
+	store           *store.Store
+	retryInterval   time.Duration
+	janitorInterval time.Duration
+	onPromote       func()
+
+	mu          sync.Mutex
+	promoted    bool
+	stop        chan struct{}
+	done        chan struct{}
+	lastEventID atomic.Uint64
+}
+
+type Option func(*Follower)
+
+// WithRetryInterval sets how long the follower waits before syncing again with a leader it lost.
+func WithRetryInterval(interval time.Duration) Option {
+	return func(f *Follower) {
+		f.retryInterval = interval
+	}
+}
+
+// WithJanitorInterval sets how often the janitor of the local store runs once the follower is promoted, a
+// non-positive interval never starts it.
+func WithJanitorInterval(interval time.Duration) Option {
+	return func(f *Follower) {
+		f.janitorInterval = interval
+	}
+}
+
+// WithOnPromote sets a function called once the follower is promoted, such as to start the jobs only the
+// leader runs.
+func WithOnPromote(onPromote func()) Option {
+	return func(f *Follower) {
+		f.onPromote = onPromote
+	}
+}
+
+// NewFollower returns a follower replicating into local. The local store should run without a janitor, the
+// changes made by the janitor of the leader arrive as events, and be made with the trash retention and
+// archive delay its janitor should apply once the follower is promoted.
+func NewFollower(local *store.Store, opts ...Option) *Follower {
+	f := &Follower{
+		store:           local,
+		retryInterval:   DefaultRetryInterval,
+		janitorInterval: store.DefaultJanitorInterval,
+	}
+
+	for _, opt := range opts {
+		opt(f)
+	}
+
+	return f
+}
+
+// Follow brings the local store in line with a snapshot of the leader, then keeps it in line in the
+// background until the follower follows another leader, is promoted or closed.
+func (f *Follower) Follow(leader Leader) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.stopFollowing()
+
+	sub, err := f.sync(leader)
+	if err != nil {
+		return err
+	}
+
+	f.promoted = false
+	f.stop = make(chan struct{})
+	f.done = make(chan struct{})
+	go f.replicate(leader, sub, f.stop, f.done)
+
+	return nil
+}
+
+// Promote stops following the leader, starts the janitor of the local store and lets the follower accept
+// writes.
+func (f *Follower) Promote() {
+	f.mu.Lock()
+	f.stopFollowing()
+	promoted := f.promoted
+	f.promoted = true
+	f.mu.Unlock()
+
+	if promoted {
+		return
+	}
+	f.store.StartJanitor(f.janitorInterval)
+	if f.onPromote != nil {
+		f.onPromote()
+	}
+}
+
+func (f *Follower) Promoted() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.promoted
+}
+
+// LastEventID returns the ID of the last leader event applied, telling how up to date the follower is.
+func (f *Follower) LastEventID() uint64 {
+	return f.lastEventID.Load()
+}
+
+// Close stops following the leader and closes the local store.
+func (f *Follower) Close() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.stopFollowing()
+	f.store.Close()
+}
+
+func (f *Follower) stopFollowing() {
+	if f.stop == nil {
+		return
+	}
+
+	close(f.stop)
+	<-f.done
+	f.stop, f.done = nil, nil
+}
+
+// sync subscribes to the leader before copying its items, so no change made during the copy is missed.
+// The event IDs of another leader or a restarted one start over, so the last event ID is forgotten.
+func (f *Follower) sync(leader Leader) (*store.Subscription, error) {
+	sub, err := leader.Subscribe(0)
+	if err != nil {
+		return nil, errors.Wrap(err, "subscribe to leader")
+	}
+
+	if err := f.copySnapshot(leader); err != nil {
+		sub.Close()
+		return nil, err
+	}
+	f.lastEventID.Store(0)
+
+	return sub, nil
+}
+
+// resume subscribes to the events after the last event applied, which the leader replays while it still
+// buffers them. Without a last event ID it syncs again.
+func (f *Follower) resume(leader Leader) (*store.Subscription, error) {
+	lastEventID := f.lastEventID.Load()
+	if lastEventID == 0 {
+		return f.sync(leader)
+	}
+
+	sub, err := leader.Subscribe(lastEventID)
+	if err != nil {
+		return nil, errors.Wrap(err, "subscribe to leader")
+	}
+
+	return sub, nil
+}
+
+func (f *Follower) copySnapshot(leader Leader) error {
+	items, err := leader.ReadAll()
+	if err != nil {
+		return errors.Wrap(err, "read leader items")
+	}
+
+	leading := make(map[store.ItemID]bool, len(items))
+	ids := make([]store.ItemID, 0, len(items))
+	for _, item := range items {
+		leading[store.ItemID(item.ID)] = true
+		ids = append(ids, store.ItemID(item.ID))
+	}
+
+	records, err := leader.Export(ids...)
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		// An item went away since the items were read, copy them one by one
+		for _, id := range ids {
+			if err := f.copyItem(leader, id); err != nil {
+				return err
+			}
+		}
+	case err != nil:
+		return errors.Wrap(err, "export leader items")
+	default:
+		if err := f.store.Import(records...); err != nil {
+			return errors.Wrap(err, "import leader items")
+		}
+	}
+
+	localItems, err := f.store.ReadAll()
+	if err != nil {
+		return errors.Wrap(err, "read local items")
+	}
+
+	var stale []store.ItemID
+	for _, item := range localItems {
+		if !leading[store.ItemID(item.ID)] {
+			stale = append(stale, store.ItemID(item.ID))
+		}
+	}
+	if len(stale) > 0 {
+		if err := f.store.Evict(stale...); err != nil {
+			return errors.Wrap(err, "evict stale items")
+		}
+	}
+
+	if err := f.copyTrashAndArchive(leader); err != nil {
+		return err
+	}
+
+	return f.copyWebhooks(leader)
+}
+
+// copyTrashAndArchive moves the items the leader holds in its trash and archive to the trash and archive of
+// the local store, as the events of the leader deleting and archiving them would.
+func (f *Follower) copyTrashAndArchive(leader Leader) error {
+	trash, err := leader.ReadTrash()
+	if err != nil {
+		return errors.Wrap(err, "read leader trash")
+	}
+
+	archived, err := leader.ReadArchived()
+	if err != nil {
+		return errors.Wrap(err, "read leader archive")
+	}
+
+	events := make([]store.Event, 0, len(trash)+len(archived))
+	for _, trashed := range trash {
+		item := trashed.Item
+		events = append(events, store.Event{Type: store.EventDelete, ItemID: store.ItemID(item.ID), Item: &item, Time: trashed.DeletedAt})
+	}
+	for _, item := range archived {
+		events = append(events, store.Event{Type: store.EventArchive, ItemID: store.ItemID(item.ID), Item: &item})
+	}
+
+	return errors.Wrap(f.store.Replicate(events...), "replicate trash and archive")
+}
+
+// copyWebhooks copies the webhooks of the leader, which publish no events and are only copied on sync.
+func (f *Follower) copyWebhooks(leader Leader) error {
+	webhooks, err := leader.ReadWebhooks()
+	if err != nil {
+		return errors.Wrap(err, "read leader webhooks")
+	}
+
+	leading := make(map[string]bool, len(webhooks))
+	for _, webhook := range webhooks {
+		leading[webhook.ID] = true
+		if _, err := f.store.AddWebhook(webhook); err != nil {
+			return errors.Wrap(err, "add webhook")
+		}
+	}
+
+	localWebhooks, err := f.store.ReadWebhooks()
+	if err != nil {
+		return errors.Wrap(err, "read local webhooks")
+	}
+
+	for _, webhook := range localWebhooks {
+		if leading[webhook.ID] {
+			continue
+		}
+		if err := f.store.DeleteWebhook(webhook.ID); err != nil {
+			return errors.Wrap(err, "delete webhook")
+		}
+	}
+
+	return nil
+}
+
+// copyItem copies the current record of the item from the leader, or evicts the item once the leader no
+// longer has it.
+func (f *Follower) copyItem(leader Leader, id store.ItemID) error {
+	records, err := leader.Export(id)
+	if errors.Is(err, store.ErrNotFound) {
+		return f.store.Evict(id)
+	}
+	if err != nil {
+		return errors.Wrapf(err, "export item '%s'", id)
+	}
+
+	return f.store.Import(records...)
+}
+
+// errMissedEvents is returned when the leader no longer buffered the events after the last one applied, or
+// restarted and numbers its events anew.
+var errMissedEvents = errors.New("missed leader events")
+
+// replicate applies the events of the leader, resuming whenever the subscription ends until the follower
+// stops following. Having missed events it syncs again.
+func (f *Follower) replicate(leader Leader, sub *store.Subscription, stop, done chan struct{}) {
+	defer close(done)
+
+	for {
+		err := f.apply(sub, stop)
+		sub.Close()
+		if err == nil {
+			return
+		}
+		slog.Error(err.Error())
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-time.After(f.retryInterval):
+			}
+
+			if errors.Is(err, errMissedEvents) {
+				sub, err = f.sync(leader)
+			} else {
+				sub, err = f.resume(leader)
+			}
+			if err == nil {
+				break
+			}
+			slog.Error(err.Error())
+		}
+	}
+}
+
+// apply applies the events of the subscription as they arrive, together with the ones already waiting,
+// until the subscription ends, which is an error unless the follower stopped following.
+func (f *Follower) apply(sub *store.Subscription, stop chan struct{}) error {
+	for {
+		select {
+		case <-stop:
+			return nil
+		case event, ok := <-sub.Events:
+			if !ok {
+				return errors.New("leader subscription closed")
+			}
+
+			events := []store.Event{event}
+		waiting:
+			for len(events) < store.SubscriptionBufferSize {
+				select {
+				case event, ok := <-sub.Events:
+					if !ok {
+						break waiting
+					}
+					events = append(events, event)
+				default:
+					break waiting
+				}
+			}
+
+			if err := f.applyEvents(events); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// applyEvents applies events that must directly follow the last event applied, when there is one.
+func (f *Follower) applyEvents(events []store.Event) error {
+	lastEventID := f.lastEventID.Load()
+	for i, event := range events {
+		if lastEventID != 0 && event.ID != lastEventID+1 {
+			if err := f.store.Replicate(events[:i]...); err != nil {
+				return errors.Wrap(err, "replicate events")
+			}
+			f.lastEventID.Store(lastEventID)
+			return errors.Wrapf(errMissedEvents, "event %d does not follow %d", event.ID, lastEventID)
+		}
+		lastEventID = event.ID
+	}
+
+	if err := f.store.Replicate(events...); err != nil {
+		return errors.Wrap(err, "replicate events")
+	}
+	f.lastEventID.Store(lastEventID)
+
+	return nil
+}
+
+func (f *Follower) ReadAll() ([]store.Item, error) {
+	return f.store.ReadAll()
+}
+
+func (f *Follower) Read(id store.ItemID) (store.Item, error) {
+	return f.store.Read(id)
+}
+
+func (f *Follower) ReadAssigned(assignee string) ([]store.Item, error) {
+	return f.store.ReadAssigned(assignee)
+}
+
+func (f *Follower) ReadComments(id store.ItemID) ([]store.Comment, error) {
+	return f.store.ReadComments(id)
+}
+
+func (f *Follower) ReadHistory(id store.ItemID) ([]store.Activity, error) {
+	return f.store.ReadHistory(id)
+}
+
+func (f *Follower) ReadTrash() ([]store.TrashedItem, error) {
+	return f.store.ReadTrash()
+}
+
+func (f *Follower) ReadArchived() ([]store.Item, error) {
+	return f.store.ReadArchived()
+}
+
+func (f *Follower) Subscribe(lastEventID uint64) (*store.Subscription, error) {
+	return f.store.Subscribe(lastEventID)
+}
+
+func (f *Follower) Export(ids ...store.ItemID) ([]store.ItemRecord, error) {
+	return f.store.Export(ids...)
+}
+
+func (f *Follower) ReadWebhooks() ([]store.Webhook, error) {
+	return f.store.ReadWebhooks()
+}
+
+func (f *Follower) ReadDeliveries(id string) ([]store.Delivery, error) {
+	return f.store.ReadDeliveries(id)
+}
+
+func (f *Follower) writable() error {
+	if !f.Promoted() {
+		return store.ErrReadOnly
+	}
+	return nil
+}
+
+func (f *Follower) Create(item store.Item) (store.Item, error) {
+	if err := f.writable(); err != nil {
+		return store.Item{}, err
+	}
+	return f.store.Create(item)
+}
+
+func (f *Follower) CreateIdempotent(user, key string, item store.Item) (store.Item, bool, error) {
+	if err := f.writable(); err != nil {
+		return store.Item{}, false, err
+	}
+	return f.store.CreateIdempotent(user, key, item)
+}
+
+func (f *Follower) Update(id store.ItemID, item store.Item) (store.Item, error) {
+	if err := f.writable(); err != nil {
+		return store.Item{}, err
+	}
+	return f.store.Update(id, item)
+}
+
+func (f *Follower) Delete(id store.ItemID) error {
+	if err := f.writable(); err != nil {
+		return err
+	}
+	return f.store.Delete(id)
+}
+
+func (f *Follower) Assign(id store.ItemID, assignee string) (store.Item, error) {
+	if err := f.writable(); err != nil {
+		return store.Item{}, err
+	}
+	return f.store.Assign(id, assignee)
+}
+
+func (f *Follower) AddComment(id store.ItemID, comment store.Comment) (store.Comment, error) {
+	if err := f.writable(); err != nil {
+		return store.Comment{}, err
+	}
+	return f.store.AddComment(id, comment)
+}
+
+func (f *Follower) Restore(at time.Time) ([]store.Item, error) {
+	if err := f.writable(); err != nil {
+		return nil, err
+	}
+	return f.store.Restore(at)
+}
+
+func (f *Follower) RestoreDeleted(id store.ItemID) (store.Item, error) {
+	if err := f.writable(); err != nil {
+		return store.Item{}, err
+	}
+	return f.store.RestoreDeleted(id)
+}
+
+func (f *Follower) Archive(id store.ItemID) (store.Item, error) {
+	if err := f.writable(); err != nil {
+		return store.Item{}, err
+	}
+	return f.store.Archive(id)
+}
+
+func (f *Follower) ArchiveCompleted() ([]store.Item, error) {
+	if err := f.writable(); err != nil {
+		return nil, err
+	}
+	return f.store.ArchiveCompleted()
+}
+
+func (f *Follower) Unarchive(id store.ItemID) (store.Item, error) {
+	if err := f.writable(); err != nil {
+		return store.Item{}, err
+	}
+	return f.store.Unarchive(id)
+}
+
+func (f *Follower) Batch(operations []store.Operation) ([]store.OperationResult, error) {
+	if err := f.writable(); err != nil {
+		return nil, err
+	}
+	return f.store.Batch(operations)
+}
+
+func (f *Follower) Undo() error {
+	if err := f.writable(); err != nil {
+		return err
+	}
+	return f.store.Undo()
+}
+
+func (f *Follower) Redo() error {
+	if err := f.writable(); err != nil {
+		return err
+	}
+	return f.store.Redo()
+}
+
+func (f *Follower) Import(records ...store.ItemRecord) error {
+	if err := f.writable(); err != nil {
+		return err
+	}
+	return f.store.Import(records...)
+}
+
+func (f *Follower) Evict(ids ...store.ItemID) error {
+	if err := f.writable(); err != nil {
+		return err
+	}
+	return f.store.Evict(ids...)
+}
+
+func (f *Follower) AddWebhook(webhook store.Webhook) (store.Webhook, error) {
+	if err := f.writable(); err != nil {
+		return store.Webhook{}, err
+	}
+	return f.store.AddWebhook(webhook)
+}
+
+func (f *Follower) DeleteWebhook(id string) error {
+	if err := f.writable(); err != nil {
+		return err
+	}
+	return f.store.DeleteWebhook(id)
+}
+
+func (f *Follower) RecordDelivery(delivery store.Delivery) error {
+	if err := f.writable(); err != nil {
+		return err
+	}
+	return f.store.RecordDelivery(delivery)
+}
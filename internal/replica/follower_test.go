@@ -0,0 +1,307 @@
+package replica
+
+import (
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/test/bufconn"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+	"to-do-app-v2/api/todopb"
+	"to-do-app-v2/internal/rpc"
+	"to-do-app-v2/internal/store"
+)
+
+// serveLeader serves a new store over an in-memory connection, stopping the server kills the leader.
+func serveLeader(t *testing.T) (*rpc.Client, *grpc.Server) {
+	t.Helper()
+
+	leader := store.NewStore(store.WithDir(t.TempDir()), store.WithJanitorInterval(0))
+	t.Cleanup(leader.Close)
+
+	return serve(t, leader)
+}
+
+// serve serves the service over an in-memory connection.
+func serve(t *testing.T, service rpc.Service) (*rpc.Client, *grpc.Server) {
+	t.Helper()
+
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	todopb.RegisterTodoServiceServer(server, rpc.NewServer(service))
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	t.Cleanup(server.Stop)
+
+	client, err := rpc.Dial("passthrough:///bufconn", grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+		return listener.DialContext(ctx)
+	}))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Close() })
+
+	return client, server
+}
+
+func newTestFollower(t *testing.T, opts ...Option) *Follower {
+	t.Helper()
+
+	return newTestFollowerOf(t, store.NewStore(store.WithDir(t.TempDir()), store.WithJanitorInterval(0)), opts...)
+}
+
+func newTestFollowerOf(t *testing.T, local *store.Store, opts ...Option) *Follower {
+	t.Helper()
+
+	follower := NewFollower(local, append([]Option{WithRetryInterval(10 * time.Millisecond)}, opts...)...)
+	t.Cleanup(follower.Close)
+
+	return follower
+}
+
+func assertEventuallyHasItems(t *testing.T, service interface{ ReadAll() ([]store.Item, error) }, expected []store.Item) {
+	t.Helper()
+
+	assert.EventuallyWithT(t, func(c *assert.CollectT) {
+		items, err := service.ReadAll()
+		assert.NoError(c, err)
+		assert.ElementsMatch(c, expected, items)
+	}, time.Second, 10*time.Millisecond)
+}
+
+func Test_Follower_CopiesLeaderSnapshot(t *testing.T) {
+	leader, _ := serveLeader(t)
+	local := store.NewStore(store.WithDir(t.TempDir()), store.WithJanitorInterval(0))
+	stale, err := local.Create(store.Item{Name: "stale"})
+	require.NoError(t, err)
+	follower := newTestFollowerOf(t, local)
+	item1, err := leader.Create(store.Item{Name: "name1"})
+	require.NoError(t, err)
+	item2, err := leader.Create(store.Item{Name: "name2"})
+	require.NoError(t, err)
+	_, err = leader.AddComment(store.ItemID(item1.ID), store.Comment{Author: "user1", Text: "text1"})
+	require.NoError(t, err)
+
+	require.NoError(t, follower.Follow(leader))
+
+	items, err := follower.ReadAll()
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []store.Item{item1, item2}, items)
+	comments, err := follower.ReadComments(store.ItemID(item1.ID))
+	assert.NoError(t, err)
+	assert.Len(t, comments, 1)
+	_, err = follower.Read(store.ItemID(stale.ID))
+	assert.ErrorIs(t, err, store.ErrNotFound)
+}
+
+func Test_Follower_AppliesLeaderChanges(t *testing.T) {
+	leader, _ := serveLeader(t)
+	follower := newTestFollower(t)
+	item1, err := leader.Create(store.Item{Name: "name1"})
+	require.NoError(t, err)
+	require.NoError(t, follower.Follow(leader))
+
+	item2, err := leader.Create(store.Item{Name: "name2"})
+	require.NoError(t, err)
+	item1, err = leader.Update(store.ItemID(item1.ID), store.Item{Name: "name3"})
+	require.NoError(t, err)
+	_, err = leader.AddComment(store.ItemID(item1.ID), store.Comment{Author: "user1", Text: "text1"})
+	require.NoError(t, err)
+	item3, err := leader.Create(store.Item{Name: "name4"})
+	require.NoError(t, err)
+	require.NoError(t, leader.Delete(store.ItemID(item3.ID)))
+
+	assertEventuallyHasItems(t, follower, []store.Item{item1, item2})
+	assert.EventuallyWithT(t, func(c *assert.CollectT) {
+		comments, err := follower.ReadComments(store.ItemID(item1.ID))
+		assert.NoError(c, err)
+		assert.Len(c, comments, 1)
+	}, time.Second, 10*time.Millisecond)
+	assert.NotZero(t, follower.LastEventID())
+}
+
+func Test_Follower_RejectsWritesUntilPromoted(t *testing.T) {
+	leader, _ := serveLeader(t)
+	follower := newTestFollower(t)
+	require.NoError(t, follower.Follow(leader))
+
+	_, err := follower.Create(store.Item{Name: "name1"})
+	assert.ErrorIs(t, err, store.ErrReadOnly)
+	assert.False(t, follower.Promoted())
+
+	follower.Promote()
+	item, err := follower.Create(store.Item{Name: "name1"})
+
+	assert.NoError(t, err)
+	assert.True(t, follower.Promoted())
+	_, err = leader.Read(store.ItemID(item.ID))
+	assert.ErrorIs(t, err, store.ErrNotFound)
+}
+
+func Test_Follower_StopsFollowingWhenPromoted(t *testing.T) {
+	leader, _ := serveLeader(t)
+	follower := newTestFollower(t)
+	require.NoError(t, follower.Follow(leader))
+	follower.Promote()
+
+	_, err := leader.Create(store.Item{Name: "name1"})
+	require.NoError(t, err)
+
+	time.Sleep(50 * time.Millisecond)
+	items, err := follower.ReadAll()
+	assert.NoError(t, err)
+	assert.Empty(t, items)
+}
+
+func Test_Follower_ReplicatesTrashAndArchive(t *testing.T) {
+	leader, _ := serveLeader(t)
+	item1, err := leader.Create(store.Item{Name: "name1"})
+	require.NoError(t, err)
+	require.NoError(t, leader.Delete(store.ItemID(item1.ID)))
+	item2, err := leader.Create(store.Item{Name: "name2"})
+	require.NoError(t, err)
+	follower := newTestFollower(t)
+	require.NoError(t, follower.Follow(leader))
+
+	archived, err := leader.Archive(store.ItemID(item2.ID))
+	require.NoError(t, err)
+
+	assert.EventuallyWithT(t, func(c *assert.CollectT) {
+		trash, err := follower.ReadTrash()
+		assert.NoError(c, err)
+		if assert.Len(c, trash, 1) {
+			assert.Equal(c, item1, trash[0].Item)
+		}
+		archive, err := follower.ReadArchived()
+		assert.NoError(c, err)
+		assert.Equal(c, []store.Item{archived}, archive)
+	}, time.Second, 10*time.Millisecond)
+	follower.Promote()
+	restored, err := follower.RestoreDeleted(store.ItemID(item1.ID))
+	assert.NoError(t, err)
+	assert.Equal(t, item1, restored)
+	_, err = follower.Unarchive(store.ItemID(item2.ID))
+	assert.NoError(t, err)
+}
+
+func Test_Follower_StartsJanitorWhenPromoted(t *testing.T) {
+	leader, _ := serveLeader(t)
+	item, err := leader.Create(store.Item{Name: "name1"})
+	require.NoError(t, err)
+	require.NoError(t, leader.Delete(store.ItemID(item.ID)))
+	local := store.NewStore(store.WithDir(t.TempDir()), store.WithJanitorInterval(0), store.WithTrashRetention(10*time.Millisecond))
+	follower := newTestFollowerOf(t, local, WithJanitorInterval(10*time.Millisecond))
+	require.NoError(t, follower.Follow(leader))
+
+	time.Sleep(50 * time.Millisecond)
+	trash, err := follower.ReadTrash()
+	require.NoError(t, err)
+	require.Len(t, trash, 1)
+	follower.Promote()
+
+	assert.Eventually(t, func() bool {
+		trash, err := follower.ReadTrash()
+		return err == nil && len(trash) == 0
+	}, time.Second, 10*time.Millisecond)
+}
+
+func Test_Follower_AppliesLeaderBatches(t *testing.T) {
+	leader, _ := serveLeader(t)
+	follower := newTestFollower(t)
+	require.NoError(t, follower.Follow(leader))
+
+	operations := make([]store.Operation, 100)
+	for i := range operations {
+		operations[i] = store.Operation{Op: store.OpCreate, Item: &store.Item{Name: fmt.Sprintf("name%d", i)}}
+	}
+	results, err := leader.Batch(operations)
+	require.NoError(t, err)
+
+	items := make([]store.Item, 0, len(results))
+	for _, result := range results {
+		items = append(items, *result.Item)
+	}
+	assertEventuallyHasItems(t, follower, items)
+}
+
+// resumableLeader is a leader whose subscriptions can be cut, it counts the snapshots taken of it.
+type resumableLeader struct {
+	*store.Store
+
+	mu        sync.Mutex
+	subs      []*store.Subscription
+	snapshots atomic.Int32
+}
+
+func (l *resumableLeader) Subscribe(lastEventID uint64) (*store.Subscription, error) {
+	sub, err := l.Store.Subscribe(lastEventID)
+	if err == nil {
+		l.mu.Lock()
+		l.subs = append(l.subs, sub)
+		l.mu.Unlock()
+	}
+	return sub, err
+}
+
+func (l *resumableLeader) ReadAll() ([]store.Item, error) {
+	l.snapshots.Add(1)
+	return l.Store.ReadAll()
+}
+
+func (l *resumableLeader) cut() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, sub := range l.subs {
+		sub.Close()
+	}
+}
+
+func Test_Follower_ResumesAfterLosingLeaderWithoutSnapshot(t *testing.T) {
+	leaderStore := store.NewStore(store.WithDir(t.TempDir()), store.WithJanitorInterval(0))
+	t.Cleanup(leaderStore.Close)
+	leader := &resumableLeader{Store: leaderStore}
+	follower := newTestFollower(t)
+	item1, err := leader.Create(store.Item{Name: "name1"})
+	require.NoError(t, err)
+	require.NoError(t, follower.Follow(leader))
+	item2, err := leader.Create(store.Item{Name: "name2"})
+	require.NoError(t, err)
+	assertEventuallyHasItems(t, follower, []store.Item{item1, item2})
+
+	leader.cut()
+	item3, err := leader.Create(store.Item{Name: "name3"})
+	require.NoError(t, err)
+
+	assertEventuallyHasItems(t, follower, []store.Item{item1, item2, item3})
+	assert.Equal(t, int32(1), leader.snapshots.Load())
+}
+
+func Test_Follower_IsPromotedOverRPC(t *testing.T) {
+	leader, _ := serveLeader(t)
+	promoted := make(chan struct{})
+	follower := newTestFollower(t, WithOnPromote(func() { close(promoted) }))
+	require.NoError(t, follower.Follow(leader))
+	client, _ := serve(t, follower)
+
+	_, err := client.Create(store.Item{Name: "name1"})
+	assert.ErrorIs(t, err, store.ErrReadOnly)
+
+	require.NoError(t, client.Promote())
+	require.NoError(t, client.Promote())
+
+	_, err = client.Create(store.Item{Name: "name1"})
+	assert.NoError(t, err)
+	assert.True(t, follower.Promoted())
+	<-promoted
+}
+
+func Test_Follower_PromoteFailsForLeader(t *testing.T) {
+	leader, _ := serveLeader(t)
+
+	assert.Error(t, leader.Promote())
+}
@@ -0,0 +1,415 @@
+package rpc
+
+import (
+	"context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"time"
+	"to-do-app-v2/api/todopb"
+	"to-do-app-v2/internal/store"
+)
+
+const DefaultTimeout = 10 * time.Second
+
+var _ Service = (*Client)(nil)
+
+// Client is a Service backed by a remote TodoService.
+type Client struct {
+	conn    *grpc.ClientConn
+	client  todopb.TodoServiceClient
+	timeout time.Duration
+}
+
+// Dial connects to the TodoService at target, without transport security unless opts configure it.
+func Dial(target string, opts ...grpc.DialOption) (*Client, error) {
+	opts = append([]grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}, opts...)
+
+	conn, err := grpc.NewClient(target, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		conn:    conn,
+		client:  todopb.NewTodoServiceClient(conn),
+		timeout: DefaultTimeout,
+	}, nil
+}
+
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+func (c *Client) context() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), c.timeout)
+}
+
+func (c *Client) Create(item store.Item) (store.Item, error) {
+	ctx, cancel := c.context()
+	defer cancel()
+
+	res, err := c.client.Create(ctx, &todopb.CreateRequest{Item: itemToProto(item)})
+	if err != nil {
+		return store.Item{}, fromStatus(err)
+	}
+	return itemFromProto(res.GetItem()), nil
+}
+
+func (c *Client) CreateIdempotent(user, key string, item store.Item) (store.Item, bool, error) {
+	ctx, cancel := c.context()
+	defer cancel()
+
+	res, err := c.client.Create(ctx, &todopb.CreateRequest{Item: itemToProto(item), IdempotencyKey: key, IdempotencyUser: user})
+	if err != nil {
+		return store.Item{}, false, fromStatus(err)
+	}
+	return itemFromProto(res.GetItem()), res.GetReplayed(), nil
+}
+
+func (c *Client) ReadAll() ([]store.Item, error) {
+	ctx, cancel := c.context()
+	defer cancel()
+
+	res, err := c.client.ReadAll(ctx, &emptypb.Empty{})
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+	return itemsFromProto(res), nil
+}
+
+func (c *Client) Read(id store.ItemID) (store.Item, error) {
+	ctx, cancel := c.context()
+	defer cancel()
+
+	res, err := c.client.Read(ctx, &todopb.ItemRef{Id: string(id)})
+	if err != nil {
+		return store.Item{}, fromStatus(err)
+	}
+	return itemFromProto(res), nil
+}
+
+func (c *Client) Update(id store.ItemID, item store.Item) (store.Item, error) {
+	ctx, cancel := c.context()
+	defer cancel()
+
+	res, err := c.client.Update(ctx, &todopb.UpdateRequest{Id: string(id), Item: itemToProto(item)})
+	if err != nil {
+		return store.Item{}, fromStatus(err)
+	}
+	return itemFromProto(res), nil
+}
+
+func (c *Client) Delete(id store.ItemID) error {
+	ctx, cancel := c.context()
+	defer cancel()
+
+	_, err := c.client.Delete(ctx, &todopb.ItemRef{Id: string(id)})
+	return fromStatus(err)
+}
+
+func (c *Client) Assign(id store.ItemID, assignee string) (store.Item, error) {
+	ctx, cancel := c.context()
+	defer cancel()
+
+	res, err := c.client.Assign(ctx, &todopb.AssignRequest{Id: string(id), Assignee: assignee})
+	if err != nil {
+		return store.Item{}, fromStatus(err)
+	}
+	return itemFromProto(res), nil
+}
+
+func (c *Client) ReadAssigned(assignee string) ([]store.Item, error) {
+	ctx, cancel := c.context()
+	defer cancel()
+
+	res, err := c.client.ReadAssigned(ctx, &todopb.ReadAssignedRequest{Assignee: assignee})
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+	return itemsFromProto(res), nil
+}
+
+func (c *Client) AddComment(id store.ItemID, comment store.Comment) (store.Comment, error) {
+	ctx, cancel := c.context()
+	defer cancel()
+
+	res, err := c.client.AddComment(ctx, &todopb.AddCommentRequest{Id: string(id), Comment: commentToProto(comment)})
+	if err != nil {
+		return store.Comment{}, fromStatus(err)
+	}
+	return commentFromProto(res), nil
+}
+
+func (c *Client) ReadComments(id store.ItemID) ([]store.Comment, error) {
+	ctx, cancel := c.context()
+	defer cancel()
+
+	res, err := c.client.ReadComments(ctx, &todopb.ItemRef{Id: string(id)})
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+
+	comments := make([]store.Comment, len(res.GetComments()))
+	for i, comment := range res.GetComments() {
+		comments[i] = commentFromProto(comment)
+	}
+	return comments, nil
+}
+
+func (c *Client) ReadHistory(id store.ItemID) ([]store.Activity, error) {
+	ctx, cancel := c.context()
+	defer cancel()
+
+	res, err := c.client.ReadHistory(ctx, &todopb.ItemRef{Id: string(id)})
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+
+	history := make([]store.Activity, len(res.GetActivities()))
+	for i, activity := range res.GetActivities() {
+		history[i] = activityFromProto(activity)
+	}
+	return history, nil
+}
+
+func (c *Client) Restore(at time.Time) ([]store.Item, error) {
+	ctx, cancel := c.context()
+	defer cancel()
+
+	res, err := c.client.Restore(ctx, &todopb.RestoreRequest{At: timestamppb.New(at)})
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+	return itemsFromProto(res), nil
+}
+
+func (c *Client) ReadTrash() ([]store.TrashedItem, error) {
+	ctx, cancel := c.context()
+	defer cancel()
+
+	res, err := c.client.ReadTrash(ctx, &emptypb.Empty{})
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+
+	trash := make([]store.TrashedItem, len(res.GetItems()))
+	for i, item := range res.GetItems() {
+		trash[i] = trashedItemFromProto(item)
+	}
+	return trash, nil
+}
+
+func (c *Client) RestoreDeleted(id store.ItemID) (store.Item, error) {
+	ctx, cancel := c.context()
+	defer cancel()
+
+	res, err := c.client.RestoreDeleted(ctx, &todopb.ItemRef{Id: string(id)})
+	if err != nil {
+		return store.Item{}, fromStatus(err)
+	}
+	return itemFromProto(res), nil
+}
+
+func (c *Client) Archive(id store.ItemID) (store.Item, error) {
+	ctx, cancel := c.context()
+	defer cancel()
+
+	res, err := c.client.Archive(ctx, &todopb.ItemRef{Id: string(id)})
+	if err != nil {
+		return store.Item{}, fromStatus(err)
+	}
+	return itemFromProto(res), nil
+}
+
+func (c *Client) ArchiveCompleted() ([]store.Item, error) {
+	ctx, cancel := c.context()
+	defer cancel()
+
+	res, err := c.client.ArchiveCompleted(ctx, &emptypb.Empty{})
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+	return itemsFromProto(res), nil
+}
+
+func (c *Client) ReadArchived() ([]store.Item, error) {
+	ctx, cancel := c.context()
+	defer cancel()
+
+	res, err := c.client.ReadArchived(ctx, &emptypb.Empty{})
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+	return itemsFromProto(res), nil
+}
+
+func (c *Client) Unarchive(id store.ItemID) (store.Item, error) {
+	ctx, cancel := c.context()
+	defer cancel()
+
+	res, err := c.client.Unarchive(ctx, &todopb.ItemRef{Id: string(id)})
+	if err != nil {
+		return store.Item{}, fromStatus(err)
+	}
+	return itemFromProto(res), nil
+}
+
+func (c *Client) Batch(operations []store.Operation) ([]store.OperationResult, error) {
+	ctx, cancel := c.context()
+	defer cancel()
+
+	converted := make([]*todopb.Operation, len(operations))
+	for i, operation := range operations {
+		converted[i] = &todopb.Operation{Op: operation.Op, Id: string(operation.ID), Item: itemPtrToProto(operation.Item)}
+	}
+
+	res, err := c.client.Batch(ctx, &todopb.BatchRequest{Operations: converted})
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+
+	results := make([]store.OperationResult, len(res.GetResults()))
+	for i, result := range res.GetResults() {
+		results[i] = store.OperationResult{Op: result.GetOp(), ID: store.ItemID(result.GetId()), Item: itemPtrFromProto(result.GetItem())}
+	}
+	return results, nil
+}
+
+func (c *Client) Undo() error {
+	ctx, cancel := c.context()
+	defer cancel()
+
+	_, err := c.client.Undo(ctx, &emptypb.Empty{})
+	return fromStatus(err)
+}
+
+func (c *Client) Redo() error {
+	ctx, cancel := c.context()
+	defer cancel()
+
+	_, err := c.client.Redo(ctx, &emptypb.Empty{})
+	return fromStatus(err)
+}
+
+func (c *Client) Export(ids ...store.ItemID) ([]store.ItemRecord, error) {
+	ctx, cancel := c.context()
+	defer cancel()
+
+	res, err := c.client.Export(ctx, idsToProto(ids))
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+	return recordsFromProto(res), nil
+}
+
+func (c *Client) Import(records ...store.ItemRecord) error {
+	ctx, cancel := c.context()
+	defer cancel()
+
+	_, err := c.client.Import(ctx, recordsToProto(records))
+	return fromStatus(err)
+}
+
+func (c *Client) Evict(ids ...store.ItemID) error {
+	ctx, cancel := c.context()
+	defer cancel()
+
+	_, err := c.client.Evict(ctx, idsToProto(ids))
+	return fromStatus(err)
+}
+
+func (c *Client) AddWebhook(webhook store.Webhook) (store.Webhook, error) {
+	ctx, cancel := c.context()
+	defer cancel()
+
+	res, err := c.client.AddWebhook(ctx, webhookToProto(webhook))
+	if err != nil {
+		return store.Webhook{}, fromStatus(err)
+	}
+	return webhookFromProto(res), nil
+}
+
+func (c *Client) ReadWebhooks() ([]store.Webhook, error) {
+	ctx, cancel := c.context()
+	defer cancel()
+
+	res, err := c.client.ReadWebhooks(ctx, &emptypb.Empty{})
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+
+	webhooks := make([]store.Webhook, len(res.GetWebhooks()))
+	for i, webhook := range res.GetWebhooks() {
+		webhooks[i] = webhookFromProto(webhook)
+	}
+	return webhooks, nil
+}
+
+func (c *Client) DeleteWebhook(id string) error {
+	ctx, cancel := c.context()
+	defer cancel()
+
+	_, err := c.client.DeleteWebhook(ctx, &todopb.WebhookRef{Id: id})
+	return fromStatus(err)
+}
+
+func (c *Client) ReadDeliveries(id string) ([]store.Delivery, error) {
+	ctx, cancel := c.context()
+	defer cancel()
+
+	res, err := c.client.ReadDeliveries(ctx, &todopb.WebhookRef{Id: id})
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+
+	deliveries := make([]store.Delivery, len(res.GetDeliveries()))
+	for i, delivery := range res.GetDeliveries() {
+		deliveries[i] = deliveryFromProto(delivery)
+	}
+	return deliveries, nil
+}
+
+// Subscribe watches the remote store, the subscription channel is closed when the stream ends.
+func (c *Client) Subscribe(lastEventID uint64) (*store.Subscription, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	stream, err := c.client.Watch(ctx, &todopb.WatchRequest{LastEventId: lastEventID})
+	if err != nil {
+		cancel()
+		return nil, fromStatus(err)
+	}
+
+	if _, err := stream.Header(); err != nil {
+		cancel()
+		return nil, fromStatus(err)
+	}
+
+	events := make(chan store.Event, store.SubscriptionBufferSize)
+	go func() {
+		defer close(events)
+		for {
+			event, err := stream.Recv()
+			if err != nil {
+				return
+			}
+			select {
+			case events <- eventFromProto(event):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return store.NewSubscription(events, cancel), nil
+}
+
+// Promote makes the server take writes when it follows a leader.
+func (c *Client) Promote() error {
+	ctx, cancel := c.context()
+	defer cancel()
+
+	_, err := c.client.Promote(ctx, &emptypb.Empty{})
+	return fromStatus(err)
+}
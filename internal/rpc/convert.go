@@ -0,0 +1,255 @@
+package rpc
+
+import (
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"time"
+	"to-do-app-v2/api/todopb"
+	"to-do-app-v2/internal/store"
+)
+
+func itemToProto(item store.Item) *todopb.Item {
+	return &todopb.Item{
+		Id:          item.ID,
+		Name:        item.Name,
+		Description: item.Desc,
+		Status:      item.Status,
+		Assignee:    item.Assignee,
+		Version:     item.Version,
+		CompletedAt: timeToProto(item.CompletedAt),
+		ArchivedAt:  timeToProto(item.ArchivedAt),
+	}
+}
+
+func itemFromProto(item *todopb.Item) store.Item {
+	return store.Item{
+		ID:          item.GetId(),
+		Name:        item.GetName(),
+		Desc:        item.GetDescription(),
+		Status:      item.GetStatus(),
+		Assignee:    item.GetAssignee(),
+		Version:     item.GetVersion(),
+		CompletedAt: timeFromProto(item.GetCompletedAt()),
+		ArchivedAt:  timeFromProto(item.GetArchivedAt()),
+	}
+}
+
+func itemPtrToProto(item *store.Item) *todopb.Item {
+	if item == nil {
+		return nil
+	}
+	return itemToProto(*item)
+}
+
+func itemPtrFromProto(item *todopb.Item) *store.Item {
+	if item == nil {
+		return nil
+	}
+	converted := itemFromProto(item)
+	return &converted
+}
+
+func itemsToProto(items []store.Item) *todopb.Items {
+	converted := make([]*todopb.Item, len(items))
+	for i, item := range items {
+		converted[i] = itemToProto(item)
+	}
+	return &todopb.Items{Items: converted}
+}
+
+func itemsFromProto(items *todopb.Items) []store.Item {
+	converted := make([]store.Item, len(items.GetItems()))
+	for i, item := range items.GetItems() {
+		converted[i] = itemFromProto(item)
+	}
+	return converted
+}
+
+func commentToProto(comment store.Comment) *todopb.Comment {
+	return &todopb.Comment{
+		Id:     comment.ID,
+		Author: comment.Author,
+		Text:   comment.Text,
+		Time:   timestamppb.New(comment.Time),
+	}
+}
+
+func commentPtrToProto(comment *store.Comment) *todopb.Comment {
+	if comment == nil {
+		return nil
+	}
+	return commentToProto(*comment)
+}
+
+func commentPtrFromProto(comment *todopb.Comment) *store.Comment {
+	if comment == nil {
+		return nil
+	}
+	converted := commentFromProto(comment)
+	return &converted
+}
+
+func commentFromProto(comment *todopb.Comment) store.Comment {
+	return store.Comment{
+		ID:     comment.GetId(),
+		Author: comment.GetAuthor(),
+		Text:   comment.GetText(),
+		Time:   comment.GetTime().AsTime(),
+	}
+}
+
+func activityToProto(activity store.Activity) *todopb.Activity {
+	return &todopb.Activity{
+		Time:    timestamppb.New(activity.Time),
+		Message: activity.Message,
+	}
+}
+
+func activityFromProto(activity *todopb.Activity) store.Activity {
+	return store.Activity{
+		Time:    activity.GetTime().AsTime(),
+		Message: activity.GetMessage(),
+	}
+}
+
+func recordsToProto(records []store.ItemRecord) *todopb.ItemRecords {
+	converted := make([]*todopb.ItemRecord, len(records))
+	for i, record := range records {
+		comments := make([]*todopb.Comment, len(record.Comments))
+		for j, comment := range record.Comments {
+			comments[j] = commentToProto(comment)
+		}
+		history := make([]*todopb.Activity, len(record.History))
+		for j, activity := range record.History {
+			history[j] = activityToProto(activity)
+		}
+		converted[i] = &todopb.ItemRecord{Item: itemToProto(record.Item), Comments: comments, History: history}
+	}
+	return &todopb.ItemRecords{Records: converted}
+}
+
+func recordsFromProto(records *todopb.ItemRecords) []store.ItemRecord {
+	converted := make([]store.ItemRecord, len(records.GetRecords()))
+	for i, record := range records.GetRecords() {
+		var comments []store.Comment
+		for _, comment := range record.GetComments() {
+			comments = append(comments, commentFromProto(comment))
+		}
+		var history []store.Activity
+		for _, activity := range record.GetHistory() {
+			history = append(history, activityFromProto(activity))
+		}
+		converted[i] = store.ItemRecord{Item: itemFromProto(record.GetItem()), Comments: comments, History: history}
+	}
+	return converted
+}
+
+func idsToProto(ids []store.ItemID) *todopb.ItemRefs {
+	converted := make([]string, len(ids))
+	for i, id := range ids {
+		converted[i] = string(id)
+	}
+	return &todopb.ItemRefs{Ids: converted}
+}
+
+func idsFromProto(ids *todopb.ItemRefs) []store.ItemID {
+	converted := make([]store.ItemID, len(ids.GetIds()))
+	for i, id := range ids.GetIds() {
+		converted[i] = store.ItemID(id)
+	}
+	return converted
+}
+
+func trashedItemToProto(item store.TrashedItem) *todopb.TrashedItem {
+	return &todopb.TrashedItem{
+		Item:      itemToProto(item.Item),
+		DeletedAt: timestamppb.New(item.DeletedAt),
+	}
+}
+
+func trashedItemFromProto(item *todopb.TrashedItem) store.TrashedItem {
+	return store.TrashedItem{
+		Item:      itemFromProto(item.GetItem()),
+		DeletedAt: item.GetDeletedAt().AsTime(),
+	}
+}
+
+func webhookToProto(webhook store.Webhook) *todopb.Webhook {
+	return &todopb.Webhook{
+		Id:        webhook.ID,
+		Url:       webhook.URL,
+		Events:    webhook.Events,
+		Secret:    webhook.Secret,
+		CreatedAt: timestamppb.New(webhook.CreatedAt),
+	}
+}
+
+func webhookFromProto(webhook *todopb.Webhook) store.Webhook {
+	return store.Webhook{
+		ID:        webhook.GetId(),
+		URL:       webhook.GetUrl(),
+		Events:    webhook.GetEvents(),
+		Secret:    webhook.GetSecret(),
+		CreatedAt: webhook.GetCreatedAt().AsTime(),
+	}
+}
+
+func deliveryToProto(delivery store.Delivery) *todopb.Delivery {
+	return &todopb.Delivery{
+		WebhookId:  delivery.WebhookID,
+		EventId:    delivery.EventID,
+		EventType:  delivery.EventType,
+		Attempt:    int32(delivery.Attempt),
+		StatusCode: int32(delivery.StatusCode),
+		Error:      delivery.Error,
+		Time:       timestamppb.New(delivery.Time),
+	}
+}
+
+func deliveryFromProto(delivery *todopb.Delivery) store.Delivery {
+	return store.Delivery{
+		WebhookID:  delivery.GetWebhookId(),
+		EventID:    delivery.GetEventId(),
+		EventType:  delivery.GetEventType(),
+		Attempt:    int(delivery.GetAttempt()),
+		StatusCode: int(delivery.GetStatusCode()),
+		Error:      delivery.GetError(),
+		Time:       delivery.GetTime().AsTime(),
+	}
+}
+
+func eventToProto(event store.Event) *todopb.Event {
+	return &todopb.Event{
+		Id:      event.ID,
+		Type:    event.Type,
+		ItemId:  string(event.ItemID),
+		Item:    itemPtrToProto(event.Item),
+		Time:    timestamppb.New(event.Time),
+		Comment: commentPtrToProto(event.Comment),
+	}
+}
+
+func eventFromProto(event *todopb.Event) store.Event {
+	return store.Event{
+		ID:      event.GetId(),
+		Type:    event.GetType(),
+		ItemID:  store.ItemID(event.GetItemId()),
+		Item:    itemPtrFromProto(event.GetItem()),
+		Time:    event.GetTime().AsTime(),
+		Comment: commentPtrFromProto(event.GetComment()),
+	}
+}
+
+func timeToProto(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
+
+func timeFromProto(t *timestamppb.Timestamp) *time.Time {
+	if t == nil {
+		return nil
+	}
+	converted := t.AsTime()
+	return &converted
+}
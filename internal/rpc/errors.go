@@ -0,0 +1,152 @@
+package rpc
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"log/slog"
+	"slices"
+	"to-do-app-v2/internal/store"
+)
+
+// ErrorDomain qualifies the reasons of the errors sent by the server.
+const ErrorDomain = "to-do-app"
+
+// sentinels maps the store errors to the status codes they travel as, the reason tells apart the errors
+// sharing a code.
+type sentinelError struct {
+	err    error
+	code   codes.Code
+	reason string
+}
+
+var sentinels = []sentinelError{
+	{store.ErrNotFound, codes.NotFound, "NOT_FOUND"},
+	{store.ErrVersionConflict, codes.Aborted, "VERSION_CONFLICT"},
+	{store.ErrIdempotencyConflict, codes.AlreadyExists, "IDEMPOTENCY_CONFLICT"},
+	{store.ErrAlreadyExists, codes.AlreadyExists, "ALREADY_EXISTS"},
+	{store.ErrHistoryUnavailable, codes.FailedPrecondition, "HISTORY_UNAVAILABLE"},
+	{store.ErrNothingToUndo, codes.FailedPrecondition, "NOTHING_TO_UNDO"},
+	{store.ErrNothingToRedo, codes.FailedPrecondition, "NOTHING_TO_REDO"},
+	{store.ErrReadOnly, codes.FailedPrecondition, "READ_ONLY"},
+}
+
+// ErrUnavailable matches the errors of calls that could not reach the server.
+var ErrUnavailable = errors.New("store server unavailable")
+
+// remoteError is a store error received from the server, it keeps the server message and still matches
+// the store sentinel with errors.Is.
+type remoteError struct {
+	message  string
+	sentinel error
+}
+
+func (e *remoteError) Error() string {
+	return e.message
+}
+
+func (e *remoteError) Unwrap() error {
+	return e.sentinel
+}
+
+// invalidOperationReason is the reason of a batch rejected for an invalid operation rather than a store
+// sentinel.
+const invalidOperationReason = "INVALID_OPERATION"
+
+// toStatus converts a store error to a gRPC status carrying the reason of its sentinel, a batch error also
+// carries the index of the failed operation as a field violation. A closed store is unavailable, so clients
+// retry it like a server they cannot reach.
+func toStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, store.ErrClosed) {
+		return status.Error(codes.Unavailable, err.Error())
+	}
+
+	var batchErr *store.BatchError
+	isBatchErr := errors.As(err, &batchErr)
+
+	code, reason := codes.InvalidArgument, invalidOperationReason
+	if index := slices.IndexFunc(sentinels, func(sentinel sentinelError) bool {
+		return errors.Is(err, sentinel.err)
+	}); index >= 0 {
+		code, reason = sentinels[index].code, sentinels[index].reason
+	} else if !isBatchErr {
+		slog.Error(err.Error())
+		return status.Error(codes.Internal, "internal server error")
+	}
+
+	st, detailsErr := status.New(code, err.Error()).WithDetails(&errdetails.ErrorInfo{
+		Reason: reason,
+		Domain: ErrorDomain,
+	})
+	if detailsErr != nil {
+		return status.Error(code, err.Error())
+	}
+
+	if isBatchErr {
+		detailed, detailsErr := st.WithDetails(&errdetails.BadRequest{
+			FieldViolations: []*errdetails.BadRequest_FieldViolation{{
+				Field:       fmt.Sprintf("operations[%d]", batchErr.Index),
+				Description: batchErr.Err.Error(),
+			}},
+		})
+		if detailsErr == nil {
+			st = detailed
+		}
+	}
+
+	return st.Err()
+}
+
+// fromStatus converts a gRPC status back to an error matching the store sentinels.
+func fromStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+	if st.Code() == codes.Unavailable {
+		return &remoteError{message: st.Message(), sentinel: ErrUnavailable}
+	}
+
+	var sentinel error
+	var violation *errdetails.BadRequest_FieldViolation
+	for _, detail := range st.Details() {
+		switch detail := detail.(type) {
+		case *errdetails.ErrorInfo:
+			if detail.GetDomain() != ErrorDomain {
+				continue
+			}
+			if index := slices.IndexFunc(sentinels, func(sentinel sentinelError) bool {
+				return sentinel.reason == detail.GetReason()
+			}); index >= 0 {
+				sentinel = sentinels[index].err
+			}
+		case *errdetails.BadRequest:
+			if len(detail.GetFieldViolations()) > 0 {
+				violation = detail.GetFieldViolations()[0]
+			}
+		}
+	}
+
+	var index int
+	if _, scanErr := fmt.Sscanf(violation.GetField(), "operations[%d]", &index); scanErr == nil {
+		if sentinel == nil {
+			return &store.BatchError{Index: index, Err: errors.New(violation.GetDescription())}
+		}
+		return &store.BatchError{Index: index, Err: &remoteError{message: violation.GetDescription(), sentinel: sentinel}}
+	}
+
+	if sentinel == nil {
+		return errors.Wrap(err, "remote store")
+	}
+
+	return &remoteError{message: st.Message(), sentinel: sentinel}
+}
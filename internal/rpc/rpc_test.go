@@ -0,0 +1,194 @@
+package rpc
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"net"
+	"testing"
+	"time"
+	"to-do-app-v2/api/todopb"
+	"to-do-app-v2/internal/store"
+)
+
+// newTestClient serves a store in a temporary directory over an in-memory connection and returns a client of it.
+func newTestClient(t *testing.T) (*Client, *store.Store) {
+	itemStore := store.NewStore(store.WithDir(t.TempDir()), store.WithJanitorInterval(0))
+	t.Cleanup(itemStore.Close)
+
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	todopb.RegisterTodoServiceServer(server, NewServer(itemStore))
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	t.Cleanup(server.Stop)
+
+	client, err := Dial("passthrough:///bufconn", grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+		return listener.DialContext(ctx)
+	}))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Close() })
+
+	return client, itemStore
+}
+
+func Test_Client_CreatesAndReadsItems(t *testing.T) {
+	client, _ := newTestClient(t)
+	completedAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	item, err := client.Create(store.Item{Name: "name1", Desc: "desc1", Status: store.StatusCompleted})
+	require.NoError(t, err)
+	assert.NotEmpty(t, item.ID)
+	assert.Equal(t, int64(1), item.Version)
+	assert.NotNil(t, item.CompletedAt)
+
+	actualItem, err := client.Read(store.ItemID(item.ID))
+	require.NoError(t, err)
+	assert.Equal(t, item, actualItem)
+
+	items, err := client.ReadAll()
+	require.NoError(t, err)
+	assert.Equal(t, []store.Item{item}, items)
+
+	assert.Equal(t, store.Item{ID: "id1", CompletedAt: &completedAt}, itemFromProto(itemToProto(store.Item{ID: "id1", CompletedAt: &completedAt})))
+}
+
+func Test_Client_ReturnsStoreErrors(t *testing.T) {
+	client, _ := newTestClient(t)
+
+	_, err := client.Read("id1")
+	assert.ErrorIs(t, err, store.ErrNotFound)
+	assert.Contains(t, err.Error(), "item 'id1'")
+
+	item, err := client.Create(store.Item{Name: "name1"})
+	require.NoError(t, err)
+	_, err = client.Update(store.ItemID(item.ID), store.Item{Name: "name2", Version: 5})
+	assert.ErrorIs(t, err, store.ErrVersionConflict)
+}
+
+func Test_Client_ReturnsBatchErrorIndex(t *testing.T) {
+	client, _ := newTestClient(t)
+
+	_, err := client.Batch([]store.Operation{
+		{Op: store.OpCreate, Item: &store.Item{Name: "name1"}},
+		{Op: store.OpDelete, ID: "id1"},
+	})
+
+	var batchErr *store.BatchError
+	require.ErrorAs(t, err, &batchErr)
+	assert.Equal(t, 1, batchErr.Index)
+	assert.ErrorIs(t, err, store.ErrNotFound)
+}
+
+func Test_Client_CreateIdempotentReplays(t *testing.T) {
+	client, _ := newTestClient(t)
+
+	item, replayed, err := client.CreateIdempotent("user1", "key1", store.Item{Name: "name1"})
+	require.NoError(t, err)
+	assert.False(t, replayed)
+
+	replayedItem, replayed, err := client.CreateIdempotent("user1", "key1", store.Item{Name: "name1"})
+	require.NoError(t, err)
+	assert.True(t, replayed)
+	assert.Equal(t, item, replayedItem)
+}
+
+func Test_Client_SubscribeStreamsEvents(t *testing.T) {
+	client, itemStore := newTestClient(t)
+	sub, err := client.Subscribe(0)
+	require.NoError(t, err)
+	t.Cleanup(sub.Close)
+
+	item, err := itemStore.Create(store.Item{Name: "name1"})
+	require.NoError(t, err)
+
+	select {
+	case event := <-sub.Events:
+		assert.Equal(t, store.EventCreate, event.Type)
+		assert.Equal(t, store.ItemID(item.ID), event.ItemID)
+		assert.Equal(t, &item, event.Item)
+	case <-time.After(5 * time.Second):
+		t.Fatal("event was not streamed")
+	}
+}
+
+func Test_Client_UndoesAndAssigns(t *testing.T) {
+	client, _ := newTestClient(t)
+
+	err := client.Undo()
+	assert.ErrorIs(t, err, store.ErrNothingToUndo)
+
+	item, err := client.Create(store.Item{Name: "name1"})
+	require.NoError(t, err)
+	assignedItem, err := client.Assign(store.ItemID(item.ID), "user1")
+	require.NoError(t, err)
+	assert.Equal(t, "user1", assignedItem.Assignee)
+
+	require.NoError(t, client.Undo())
+	actualItem, err := client.Read(store.ItemID(item.ID))
+	require.NoError(t, err)
+	assert.Empty(t, actualItem.Assignee)
+	assert.NoError(t, client.Redo())
+	assert.ErrorIs(t, client.Redo(), store.ErrNothingToRedo)
+}
+
+func Test_Client_ReturnsUnavailableWhenServerIsDown(t *testing.T) {
+	listener := bufconn.Listen(1 << 20)
+	require.NoError(t, listener.Close())
+	client, err := Dial("passthrough:///bufconn", grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+		return listener.DialContext(ctx)
+	}))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Close() })
+
+	_, err = client.ReadAll()
+
+	assert.ErrorIs(t, err, ErrUnavailable)
+}
+
+func Test_Client_ReturnsBatchErrorOfInvalidOperation(t *testing.T) {
+	client, _ := newTestClient(t)
+
+	_, err := client.Batch([]store.Operation{
+		{Op: store.OpCreate, Item: &store.Item{Name: "name1"}},
+		{Op: "move", ID: "id1"},
+	})
+
+	var batchErr *store.BatchError
+	require.ErrorAs(t, err, &batchErr)
+	assert.Equal(t, 1, batchErr.Index)
+	assert.Equal(t, "unknown operation 'move'", batchErr.Err.Error())
+}
+
+func Test_Client_ReturnsUnavailableWhenStoreIsClosed(t *testing.T) {
+	client, itemStore := newTestClient(t)
+	itemStore.Close()
+
+	_, err := client.ReadAll()
+
+	assert.ErrorIs(t, err, ErrUnavailable)
+}
+
+func Test_FromStatus_ReturnsErrorsSentByToStatus(t *testing.T) {
+	err := fromStatus(toStatus(&store.BatchError{Index: 2, Err: errors.New("item is required")}))
+	var batchErr *store.BatchError
+	require.ErrorAs(t, err, &batchErr)
+	assert.Equal(t, 2, batchErr.Index)
+	assert.Equal(t, "item is required", batchErr.Err.Error())
+	assert.Equal(t, codes.InvalidArgument, status.Code(toStatus(batchErr)))
+
+	err = fromStatus(toStatus(&store.BatchError{Index: 1, Err: errors.Wrap(store.ErrNotFound, "item 'id1'")}))
+	require.ErrorAs(t, err, &batchErr)
+	assert.Equal(t, 1, batchErr.Index)
+	assert.ErrorIs(t, err, store.ErrNotFound)
+
+	err = fromStatus(toStatus(errors.Wrap(store.ErrClosed, "read items")))
+	assert.ErrorIs(t, err, ErrUnavailable)
+	assert.Equal(t, codes.Unavailable, status.Code(toStatus(store.ErrClosed)))
+}
@@ -0,0 +1,342 @@
+package rpc
+
+import (
+	"context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"to-do-app-v2/api/handler"
+	"to-do-app-v2/api/todopb"
+	"to-do-app-v2/internal/store"
+)
+
+// Service is the store served by the TodoService, the operations of handler.Service plus the ones used by
+// the command line front ends.
+type Service interface {
+	handler.Service
+	Assign(id store.ItemID, assignee string) (store.Item, error)
+	Archive(id store.ItemID) (store.Item, error)
+	ArchiveCompleted() ([]store.Item, error)
+	Undo() error
+	Redo() error
+	Export(ids ...store.ItemID) ([]store.ItemRecord, error)
+	Import(records ...store.ItemRecord) error
+	Evict(ids ...store.ItemID) error
+}
+
+// Promoter is a service following a leader, such as a replica.Follower, that can be promoted to take writes.
+type Promoter interface {
+	Promote()
+}
+
+// Server implements the TodoService over a store.
+type Server struct {
+	todopb.UnimplementedTodoServiceServer
+	service Service
+}
+
+func NewServer(service Service) *Server {
+	return &Server{
+		service: service,
+	}
+}
+
+func (s *Server) Create(_ context.Context, req *todopb.CreateRequest) (*todopb.CreateResponse, error) {
+	item := itemFromProto(req.GetItem())
+
+	if req.GetIdempotencyKey() == "" {
+		newItem, err := s.service.Create(item)
+		if err != nil {
+			return nil, toStatus(err)
+		}
+		return &todopb.CreateResponse{Item: itemToProto(newItem)}, nil
+	}
+
+	newItem, replayed, err := s.service.CreateIdempotent(req.GetIdempotencyUser(), req.GetIdempotencyKey(), item)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &todopb.CreateResponse{Item: itemToProto(newItem), Replayed: replayed}, nil
+}
+
+func (s *Server) ReadAll(context.Context, *emptypb.Empty) (*todopb.Items, error) {
+	items, err := s.service.ReadAll()
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return itemsToProto(items), nil
+}
+
+func (s *Server) Read(_ context.Context, req *todopb.ItemRef) (*todopb.Item, error) {
+	item, err := s.service.Read(store.ItemID(req.GetId()))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return itemToProto(item), nil
+}
+
+func (s *Server) Update(_ context.Context, req *todopb.UpdateRequest) (*todopb.Item, error) {
+	item, err := s.service.Update(store.ItemID(req.GetId()), itemFromProto(req.GetItem()))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return itemToProto(item), nil
+}
+
+func (s *Server) Delete(_ context.Context, req *todopb.ItemRef) (*emptypb.Empty, error) {
+	if err := s.service.Delete(store.ItemID(req.GetId())); err != nil {
+		return nil, toStatus(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *Server) Assign(_ context.Context, req *todopb.AssignRequest) (*todopb.Item, error) {
+	item, err := s.service.Assign(store.ItemID(req.GetId()), req.GetAssignee())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return itemToProto(item), nil
+}
+
+func (s *Server) ReadAssigned(_ context.Context, req *todopb.ReadAssignedRequest) (*todopb.Items, error) {
+	items, err := s.service.ReadAssigned(req.GetAssignee())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return itemsToProto(items), nil
+}
+
+func (s *Server) AddComment(_ context.Context, req *todopb.AddCommentRequest) (*todopb.Comment, error) {
+	comment, err := s.service.AddComment(store.ItemID(req.GetId()), commentFromProto(req.GetComment()))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return commentToProto(comment), nil
+}
+
+func (s *Server) ReadComments(_ context.Context, req *todopb.ItemRef) (*todopb.Comments, error) {
+	comments, err := s.service.ReadComments(store.ItemID(req.GetId()))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	converted := make([]*todopb.Comment, len(comments))
+	for i, comment := range comments {
+		converted[i] = commentToProto(comment)
+	}
+	return &todopb.Comments{Comments: converted}, nil
+}
+
+func (s *Server) ReadHistory(_ context.Context, req *todopb.ItemRef) (*todopb.Activities, error) {
+	history, err := s.service.ReadHistory(store.ItemID(req.GetId()))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	converted := make([]*todopb.Activity, len(history))
+	for i, activity := range history {
+		converted[i] = activityToProto(activity)
+	}
+	return &todopb.Activities{Activities: converted}, nil
+}
+
+func (s *Server) Restore(_ context.Context, req *todopb.RestoreRequest) (*todopb.Items, error) {
+	items, err := s.service.Restore(req.GetAt().AsTime())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return itemsToProto(items), nil
+}
+
+func (s *Server) ReadTrash(context.Context, *emptypb.Empty) (*todopb.TrashedItems, error) {
+	trash, err := s.service.ReadTrash()
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	converted := make([]*todopb.TrashedItem, len(trash))
+	for i, item := range trash {
+		converted[i] = trashedItemToProto(item)
+	}
+	return &todopb.TrashedItems{Items: converted}, nil
+}
+
+func (s *Server) RestoreDeleted(_ context.Context, req *todopb.ItemRef) (*todopb.Item, error) {
+	item, err := s.service.RestoreDeleted(store.ItemID(req.GetId()))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return itemToProto(item), nil
+}
+
+func (s *Server) Archive(_ context.Context, req *todopb.ItemRef) (*todopb.Item, error) {
+	item, err := s.service.Archive(store.ItemID(req.GetId()))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return itemToProto(item), nil
+}
+
+func (s *Server) ArchiveCompleted(context.Context, *emptypb.Empty) (*todopb.Items, error) {
+	items, err := s.service.ArchiveCompleted()
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return itemsToProto(items), nil
+}
+
+func (s *Server) ReadArchived(context.Context, *emptypb.Empty) (*todopb.Items, error) {
+	items, err := s.service.ReadArchived()
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return itemsToProto(items), nil
+}
+
+func (s *Server) Unarchive(_ context.Context, req *todopb.ItemRef) (*todopb.Item, error) {
+	item, err := s.service.Unarchive(store.ItemID(req.GetId()))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return itemToProto(item), nil
+}
+
+func (s *Server) Batch(_ context.Context, req *todopb.BatchRequest) (*todopb.BatchResponse, error) {
+	operations := make([]store.Operation, len(req.GetOperations()))
+	for i, operation := range req.GetOperations() {
+		operations[i] = store.Operation{
+			Op:   operation.GetOp(),
+			ID:   store.ItemID(operation.GetId()),
+			Item: itemPtrFromProto(operation.GetItem()),
+		}
+	}
+
+	results, err := s.service.Batch(operations)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	converted := make([]*todopb.OperationResult, len(results))
+	for i, result := range results {
+		converted[i] = &todopb.OperationResult{Op: result.Op, Id: string(result.ID), Item: itemPtrToProto(result.Item)}
+	}
+	return &todopb.BatchResponse{Results: converted}, nil
+}
+
+func (s *Server) Undo(context.Context, *emptypb.Empty) (*emptypb.Empty, error) {
+	if err := s.service.Undo(); err != nil {
+		return nil, toStatus(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *Server) Redo(context.Context, *emptypb.Empty) (*emptypb.Empty, error) {
+	if err := s.service.Redo(); err != nil {
+		return nil, toStatus(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *Server) Export(_ context.Context, req *todopb.ItemRefs) (*todopb.ItemRecords, error) {
+	records, err := s.service.Export(idsFromProto(req)...)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return recordsToProto(records), nil
+}
+
+func (s *Server) Import(_ context.Context, req *todopb.ItemRecords) (*emptypb.Empty, error) {
+	if err := s.service.Import(recordsFromProto(req)...); err != nil {
+		return nil, toStatus(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *Server) Evict(_ context.Context, req *todopb.ItemRefs) (*emptypb.Empty, error) {
+	if err := s.service.Evict(idsFromProto(req)...); err != nil {
+		return nil, toStatus(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *Server) AddWebhook(_ context.Context, req *todopb.Webhook) (*todopb.Webhook, error) {
+	webhook, err := s.service.AddWebhook(webhookFromProto(req))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return webhookToProto(webhook), nil
+}
+
+func (s *Server) ReadWebhooks(context.Context, *emptypb.Empty) (*todopb.Webhooks, error) {
+	webhooks, err := s.service.ReadWebhooks()
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	converted := make([]*todopb.Webhook, len(webhooks))
+	for i, webhook := range webhooks {
+		converted[i] = webhookToProto(webhook)
+	}
+	return &todopb.Webhooks{Webhooks: converted}, nil
+}
+
+func (s *Server) DeleteWebhook(_ context.Context, req *todopb.WebhookRef) (*emptypb.Empty, error) {
+	if err := s.service.DeleteWebhook(req.GetId()); err != nil {
+		return nil, toStatus(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *Server) ReadDeliveries(_ context.Context, req *todopb.WebhookRef) (*todopb.Deliveries, error) {
+	deliveries, err := s.service.ReadDeliveries(req.GetId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	converted := make([]*todopb.Delivery, len(deliveries))
+	for i, delivery := range deliveries {
+		converted[i] = deliveryToProto(delivery)
+	}
+	return &todopb.Deliveries{Deliveries: converted}, nil
+}
+
+// Watch streams the store events until the client goes away or the subscription falls behind, in which
+// case the client should watch again from the last event ID it received.
+func (s *Server) Watch(req *todopb.WatchRequest, stream todopb.TodoService_WatchServer) error {
+	sub, err := s.service.Subscribe(req.GetLastEventId())
+	if err != nil {
+		return toStatus(err)
+	}
+	defer sub.Close()
+
+	// Headers tell the client the subscription is in place, so what it reads next is not missed
+	if err := stream.SendHeader(metadata.MD{}); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case event, ok := <-sub.Events:
+			if !ok {
+				return nil
+			}
+			if err := stream.Send(eventToProto(event)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// Promote promotes the service when it follows a leader, which lets a follower take over without a restart.
+func (s *Server) Promote(context.Context, *emptypb.Empty) (*emptypb.Empty, error) {
+	promoter, ok := s.service.(Promoter)
+	if !ok {
+		return nil, status.Error(codes.FailedPrecondition, "the store follows no leader")
+	}
+
+	promoter.Promote()
+	return &emptypb.Empty{}, nil
+}
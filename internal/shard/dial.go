@@ -0,0 +1,58 @@
+package shard
+
+import (
+	"github.com/pkg/errors"
+	"to-do-app-v2/internal/rpc"
+)
+
+// Dial connects to the store servers at addrs. A single server is used directly, several are sharded by a
+// Router using the addresses as node names. The returned function closes the connections.
+func Dial(addrs ...string) (rpc.Service, func(), error) {
+	if len(addrs) == 1 {
+		client, err := rpc.Dial(addrs[0])
+		if err != nil {
+			return nil, nil, err
+		}
+		return client, func() { _ = client.Close() }, nil
+	}
+
+	return dialRouter(addrs...)
+}
+
+// Rebalance connects to the store servers at addrs and moves every item to the server owning it, to be run
+// once servers were added to or removed from addrs.
+func Rebalance(addrs ...string) error {
+	router, closeAll, err := dialRouter(addrs...)
+	if err != nil {
+		return err
+	}
+	defer closeAll()
+
+	return errors.Wrap(router.Rebalance(), "rebalance")
+}
+
+func dialRouter(addrs ...string) (*Router, func(), error) {
+	var clients []*rpc.Client
+	closeAll := func() {
+		for _, client := range clients {
+			_ = client.Close()
+		}
+	}
+
+	router := NewRouter(DefaultReplicas)
+	for _, addr := range addrs {
+		client, err := rpc.Dial(addr)
+		if err != nil {
+			closeAll()
+			return nil, nil, err
+		}
+		clients = append(clients, client)
+
+		if err := router.AddNode(addr, client); err != nil {
+			closeAll()
+			return nil, nil, err
+		}
+	}
+
+	return router, closeAll, nil
+}
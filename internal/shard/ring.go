@@ -0,0 +1,83 @@
+package shard
+
+import (
+	"hash/crc32"
+	"slices"
+	"strconv"
+)
+
+const DefaultReplicas = 128
+
+// Ring is a consistent hash ring placing every node at several virtual points, so keys spread evenly and
+// adding or removing a node only moves the keys next to its points.
+type Ring struct {
+	replicas int
+	nodes    []string
+	points   []uint32
+	owners   map[uint32]string
+}
+
+// NewRing returns an empty ring placing every node at replicas points.
+func NewRing(replicas int) *Ring {
+	return &Ring{
+		replicas: max(replicas, 1),
+		owners:   make(map[uint32]string),
+	}
+}
+
+func (r *Ring) Add(node string) {
+	if slices.Contains(r.nodes, node) {
+		return
+	}
+	r.nodes = append(r.nodes, node)
+	slices.Sort(r.nodes)
+	r.build()
+}
+
+func (r *Ring) Remove(node string) {
+	r.nodes = slices.DeleteFunc(r.nodes, func(other string) bool {
+		return other == node
+	})
+	r.build()
+}
+
+// Get returns the node owning key, the node at the first point after the key hash, or an empty string
+// when the ring has no nodes.
+func (r *Ring) Get(key string) string {
+	if len(r.points) == 0 {
+		return ""
+	}
+
+	i, _ := slices.BinarySearch(r.points, hash(key))
+	if i == len(r.points) {
+		i = 0
+	}
+	return r.owners[r.points[i]]
+}
+
+// Nodes returns the nodes on the ring in sorted order.
+func (r *Ring) Nodes() []string {
+	return slices.Clone(r.nodes)
+}
+
+// build places the nodes in sorted order, so on a hash collision the point goes to the same node whatever
+// order the nodes were added in.
+func (r *Ring) build() {
+	r.points = r.points[:0]
+	r.owners = make(map[uint32]string)
+	for _, node := range r.nodes {
+		for i := range r.replicas {
+			point := hash(node + "#" + strconv.Itoa(i))
+			if _, found := r.owners[point]; found {
+				continue
+			}
+			r.owners[point] = node
+			r.points = append(r.points, point)
+		}
+	}
+	slices.Sort(r.points)
+}
+
+func hash(key string) uint32 {
+	return crc32.ChecksumIEEE([]byte(key))
+}
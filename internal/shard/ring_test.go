@@ -0,0 +1,79 @@
+package shard
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strconv"
+	"testing"
+)
+
+func Test_Ring_GetReturnsEmptyWithoutNodes(t *testing.T) {
+	ring := NewRing(DefaultReplicas)
+
+	assert.Empty(t, ring.Get("key1"))
+}
+
+func Test_Ring_SpreadsKeysEvenly(t *testing.T) {
+	ring := NewRing(DefaultReplicas)
+	ring.Add("node1")
+	ring.Add("node2")
+	ring.Add("node3")
+
+	counts := make(map[string]int)
+	for i := range 30000 {
+		counts[ring.Get("key"+strconv.Itoa(i))]++
+	}
+
+	assert.Len(t, counts, 3)
+	for _, count := range counts {
+		assert.InDelta(t, 10000, count, 2500)
+	}
+}
+
+func Test_Ring_AddingNodeOnlyMovesKeysToIt(t *testing.T) {
+	ring := NewRing(DefaultReplicas)
+	ring.Add("node1")
+	ring.Add("node2")
+	before := make(map[string]string)
+	for i := range 1000 {
+		key := "key" + strconv.Itoa(i)
+		before[key] = ring.Get(key)
+	}
+
+	ring.Add("node3")
+
+	moved := 0
+	for key, owner := range before {
+		if ring.Get(key) != owner {
+			assert.Equal(t, "node3", ring.Get(key))
+			moved++
+		}
+	}
+	assert.InDelta(t, 333, moved, 150)
+}
+
+func Test_Ring_RemovingNodeRestoresPlacement(t *testing.T) {
+	ring := NewRing(DefaultReplicas)
+	ring.Add("node1")
+	ring.Add("node2")
+	owner := ring.Get("key1")
+
+	ring.Add("node3")
+	ring.Remove("node3")
+
+	assert.Equal(t, owner, ring.Get("key1"))
+	assert.Equal(t, []string{"node1", "node2"}, ring.Nodes())
+}
+
+func Test_Ring_PlacementDoesNotDependOnOrder(t *testing.T) {
+	ring1 := NewRing(DefaultReplicas)
+	ring1.Add("node1")
+	ring1.Add("node2")
+	ring2 := NewRing(DefaultReplicas)
+	ring2.Add("node2")
+	ring2.Add("node1")
+
+	for i := range 100 {
+		key := "key" + strconv.Itoa(i)
+		assert.Equal(t, ring1.Get(key), ring2.Get(key))
+	}
+}
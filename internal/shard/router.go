@@ -0,0 +1,695 @@
+package shard
+
+import (
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+	"to-do-app-v2/internal/rpc"
+	"to-do-app-v2/internal/store"
+)
+
+var (
+	ErrNoNodes     = errors.New("no store nodes")
+	ErrCrossShard  = errors.New("operations span several shards")
+	ErrUnsupported = errors.New("not supported on a sharded store")
+)
+
+var _ rpc.Service = (*Router)(nil)
+
+// Router spreads items over several stores on a consistent hash ring. Assigned items live on the store
+// owning their assignee, so the items of a user are read from a single store, the others on the store owning
+// their ID. Lists are read from every store and merged. Webhooks are registered on every store, as each store
+// delivers its own events.
+type Router struct {
+	mu       sync.RWMutex
+	ring     *Ring
+	backends map[string]rpc.Service
+	cursors  *cursorLog
+}
+
+func NewRouter(replicas int) *Router {
+	return &Router{
+		ring:     NewRing(replicas),
+		backends: make(map[string]rpc.Service),
+		cursors:  newCursorLog(store.MaxBufferedEvents),
+	}
+}
+
+// AddNode adds a store to the ring. The items it now owns stay where they are until Rebalance moves them,
+// they are still found by ID but left out of the items of their assignee.
+func (r *Router) AddNode(name string, backend rpc.Service) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, found := r.backends[name]; found {
+		return errors.Errorf("node '%s' is already on the ring", name)
+	}
+
+	if nodes := r.ring.Nodes(); len(nodes) > 0 {
+		webhooks, err := r.backends[nodes[0]].ReadWebhooks()
+		if err != nil {
+			return errors.Wrap(err, "read webhooks")
+		}
+		for _, webhook := range webhooks {
+			if _, err := backend.AddWebhook(webhook); err != nil {
+				return errors.Wrap(err, "add webhook")
+			}
+		}
+	}
+
+	r.backends[name] = backend
+	r.ring.Add(name)
+
+	return nil
+}
+
+// Rebalance moves every item to the store owning it, to be run once the nodes on the ring have changed.
+// Items in the trash and archive stay behind.
+func (r *Router) Rebalance() error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, node := range r.ring.Nodes() {
+		if err := r.moveMisplaced(node, r.backends[node]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RemoveNode takes a store off the ring and moves its items to the stores that own them now. Items in
+// its trash and archive stay behind.
+func (r *Router) RemoveNode(name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	backend, found := r.backends[name]
+	if !found {
+		return errors.Errorf("node '%s' is not on the ring", name)
+	}
+	if len(r.backends) == 1 {
+		return errors.Errorf("node '%s' is the last node on the ring", name)
+	}
+
+	r.ring.Remove(name)
+	delete(r.backends, name)
+
+	return r.moveMisplaced(name, backend)
+}
+
+// Nodes returns the names of the stores on the ring.
+func (r *Router) Nodes() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.ring.Nodes()
+}
+
+// moveMisplaced moves the items of a store that another store owns to that store, with their comments and
+// history. Items are evicted only once imported, so a failed move can be repeated.
+func (r *Router) moveMisplaced(name string, backend rpc.Service) error {
+	items, err := backend.ReadAll()
+	if err != nil {
+		return errors.Wrapf(err, "read items of node '%s'", name)
+	}
+
+	return r.move(name, backend, items...)
+}
+
+// move moves the items of a store that another store owns to that store.
+func (r *Router) move(name string, backend rpc.Service, items ...store.Item) error {
+	moves := make(map[string][]store.ItemID)
+	for _, item := range items {
+		if owner := r.owner(item); owner != name {
+			moves[owner] = append(moves[owner], store.ItemID(item.ID))
+		}
+	}
+
+	for owner, ids := range moves {
+		records, err := backend.Export(ids...)
+		if err != nil {
+			return errors.Wrapf(err, "export items of node '%s'", name)
+		}
+		if err := r.backends[owner].Import(records...); err != nil {
+			return errors.Wrapf(err, "import items to node '%s'", owner)
+		}
+		if err := backend.Evict(ids...); err != nil {
+			return errors.Wrapf(err, "evict items of node '%s'", name)
+		}
+	}
+
+	return nil
+}
+
+// owner returns the node owning the item, the node owning its assignee or its ID when it has none.
+func (r *Router) owner(item store.Item) string {
+	if item.Assignee != "" {
+		return r.ring.Get(userKey(item.Assignee))
+	}
+	return r.ring.Get(item.ID)
+}
+
+// userKey places users on the ring apart from item IDs.
+func userKey(user string) string {
+	return "user:" + user
+}
+
+// idOn returns a new item ID owned by node.
+func (r *Router) idOn(node string) store.ItemID {
+	for {
+		if id := store.NewItemID(); r.ring.Get(string(id)) == node {
+			return id
+		}
+	}
+}
+
+// routed calls f on the store owning id. When the item is not found there the other stores are asked, as
+// assigned items live with their assignee and trashed and archived items stay where they were when the ring
+// changed.
+func routed[T any](r *Router, id store.ItemID, f func(rpc.Service) (T, error)) (T, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result, _, err := find(r, id, f)
+	return result, err
+}
+
+// settled calls f like routed and then moves the item it returns to the store owning it, as changing the
+// assignee of an item changes its owner.
+func (r *Router) settled(id store.ItemID, f func(rpc.Service) (store.Item, error)) (store.Item, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	item, node, err := find(r, id, f)
+	if err != nil {
+		return store.Item{}, err
+	}
+
+	return item, r.move(node, r.backends[node], item)
+}
+
+// find calls f on the stores until one finds the item, starting with the store owning id, and returns the
+// node it found the item on.
+func find[T any](r *Router, id store.ItemID, f func(rpc.Service) (T, error)) (T, string, error) {
+	owner := r.ring.Get(string(id))
+	if owner == "" {
+		var zero T
+		return zero, "", ErrNoNodes
+	}
+
+	result, err := f(r.backends[owner])
+	if !errors.Is(err, store.ErrNotFound) {
+		return result, owner, err
+	}
+
+	for _, node := range r.ring.Nodes() {
+		if node == owner {
+			continue
+		}
+		if otherResult, otherErr := f(r.backends[node]); !errors.Is(otherErr, store.ErrNotFound) {
+			return otherResult, node, otherErr
+		}
+	}
+
+	return result, owner, err
+}
+
+// fanOut calls f on every store concurrently and merges the results in node order.
+func fanOut[T any](r *Router, f func(rpc.Service) ([]T, error)) ([]T, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	nodes := r.ring.Nodes()
+	results := make([][]T, len(nodes))
+	errs := make([]error, len(nodes))
+
+	var wg sync.WaitGroup
+	for i, node := range nodes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], errs[i] = f(r.backends[node])
+		}()
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			return nil, errors.Wrapf(err, "node '%s'", nodes[i])
+		}
+	}
+
+	return slices.Concat(results...), nil
+}
+
+// Create adds the item to the store owning it. An item without an ID gets a new ID owned by the same store,
+// so it is found without asking the other stores.
+func (r *Router) Create(item store.Item) (store.Item, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if item.ID == "" {
+		item.ID = string(store.NewItemID())
+		if item.Assignee != "" {
+			item.ID = string(r.idOn(r.owner(item)))
+		}
+	}
+
+	owner := r.owner(item)
+	if owner == "" {
+		return store.Item{}, ErrNoNodes
+	}
+
+	return r.backends[owner].Create(item)
+}
+
+// CreateIdempotent creates the item on the store owning its assignee or, for an unassigned item, the key,
+// under a new ID owned by the same store so a retry with the key reaches the store that remembers it.
+func (r *Router) CreateIdempotent(user, key string, item store.Item) (store.Item, bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	owner := r.ring.Get(key)
+	if item.Assignee != "" {
+		owner = r.ring.Get(userKey(item.Assignee))
+	}
+	if owner == "" {
+		return store.Item{}, false, ErrNoNodes
+	}
+
+	item.ID = string(r.idOn(owner))
+	return r.backends[owner].CreateIdempotent(user, key, item)
+}
+
+func (r *Router) ReadAll() ([]store.Item, error) {
+	return fanOut(r, rpc.Service.ReadAll)
+}
+
+func (r *Router) Read(id store.ItemID) (store.Item, error) {
+	return routed(r, id, func(backend rpc.Service) (store.Item, error) {
+		return backend.Read(id)
+	})
+}
+
+func (r *Router) Update(id store.ItemID, item store.Item) (store.Item, error) {
+	return r.settled(id, func(backend rpc.Service) (store.Item, error) {
+		return backend.Update(id, item)
+	})
+}
+
+func (r *Router) Delete(id store.ItemID) error {
+	_, err := routed(r, id, func(backend rpc.Service) (struct{}, error) {
+		return struct{}{}, backend.Delete(id)
+	})
+	return err
+}
+
+func (r *Router) Assign(id store.ItemID, assignee string) (store.Item, error) {
+	return r.settled(id, func(backend rpc.Service) (store.Item, error) {
+		return backend.Assign(id, assignee)
+	})
+}
+
+// ReadAssigned reads the items of the assignee from the store owning the assignee.
+func (r *Router) ReadAssigned(assignee string) ([]store.Item, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	owner := r.ring.Get(userKey(assignee))
+	if owner == "" {
+		return nil, ErrNoNodes
+	}
+
+	return r.backends[owner].ReadAssigned(assignee)
+}
+
+func (r *Router) AddComment(id store.ItemID, comment store.Comment) (store.Comment, error) {
+	return routed(r, id, func(backend rpc.Service) (store.Comment, error) {
+		return backend.AddComment(id, comment)
+	})
+}
+
+func (r *Router) ReadComments(id store.ItemID) ([]store.Comment, error) {
+	return routed(r, id, func(backend rpc.Service) ([]store.Comment, error) {
+		return backend.ReadComments(id)
+	})
+}
+
+func (r *Router) ReadHistory(id store.ItemID) ([]store.Activity, error) {
+	return routed(r, id, func(backend rpc.Service) ([]store.Activity, error) {
+		return backend.ReadHistory(id)
+	})
+}
+
+// Restore restores every store as of the time, each store only knows the changes of its own items.
+func (r *Router) Restore(at time.Time) ([]store.Item, error) {
+	return fanOut(r, func(backend rpc.Service) ([]store.Item, error) {
+		return backend.Restore(at)
+	})
+}
+
+func (r *Router) ReadTrash() ([]store.TrashedItem, error) {
+	return fanOut(r, rpc.Service.ReadTrash)
+}
+
+func (r *Router) RestoreDeleted(id store.ItemID) (store.Item, error) {
+	return r.settled(id, func(backend rpc.Service) (store.Item, error) {
+		return backend.RestoreDeleted(id)
+	})
+}
+
+func (r *Router) Archive(id store.ItemID) (store.Item, error) {
+	return routed(r, id, func(backend rpc.Service) (store.Item, error) {
+		return backend.Archive(id)
+	})
+}
+
+func (r *Router) ArchiveCompleted() ([]store.Item, error) {
+	return fanOut(r, rpc.Service.ArchiveCompleted)
+}
+
+func (r *Router) ReadArchived() ([]store.Item, error) {
+	return fanOut(r, rpc.Service.ReadArchived)
+}
+
+func (r *Router) Unarchive(id store.ItemID) (store.Item, error) {
+	return r.settled(id, func(backend rpc.Service) (store.Item, error) {
+		return backend.Unarchive(id)
+	})
+}
+
+// Batch sends the operations to the store holding all the items they touch, new items get IDs owned by the
+// same store. Operations touching items of several stores are rejected as they could not be applied atomically.
+// The items the batch leaves with an assignee owned by another store are moved to that store afterwards.
+func (r *Router) Batch(operations []store.Operation) ([]store.OperationResult, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var node string
+	for i, operation := range operations {
+		if operation.ID == "" {
+			continue
+		}
+		owner, err := r.holder(operation)
+		if err != nil {
+			return nil, err
+		}
+		if node != "" && owner != node {
+			return nil, &store.BatchError{Index: i, Err: ErrCrossShard}
+		}
+		node = owner
+	}
+
+	for _, operation := range operations {
+		if node == "" && operation.Op == store.OpCreate && operation.Item != nil && operation.Item.Assignee != "" {
+			node = r.ring.Get(userKey(operation.Item.Assignee))
+		}
+	}
+	if node == "" {
+		node = r.ring.Get(string(store.NewItemID()))
+	}
+	if node == "" {
+		return nil, ErrNoNodes
+	}
+
+	operations = slices.Clone(operations)
+	for i, operation := range operations {
+		if operation.Op == store.OpCreate && operation.ID == "" {
+			operations[i].ID = r.idOn(node)
+		}
+	}
+
+	results, err := r.backends[node].Batch(operations)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []store.Item
+	for _, result := range results {
+		if result.Op != store.OpDelete && result.Item != nil {
+			items = append(items, *result.Item)
+		}
+	}
+
+	return results, r.move(node, r.backends[node], items...)
+}
+
+// holder returns the node holding the item an operation touches, the node owning a new item or the node
+// holding an existing one.
+func (r *Router) holder(operation store.Operation) (string, error) {
+	if operation.Op == store.OpCreate && operation.Item != nil {
+		item := *operation.Item
+		item.ID = string(operation.ID)
+		return r.owner(item), nil
+	}
+
+	_, node, err := find(r, operation.ID, func(backend rpc.Service) (store.Item, error) {
+		return backend.Read(operation.ID)
+	})
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		return "", errors.Wrapf(err, "find item '%s'", operation.ID)
+	}
+
+	return node, nil
+}
+
+// Undo is not supported as the stores keep separate undo histories.
+func (r *Router) Undo() error {
+	return errors.Wrap(ErrUnsupported, "undo")
+}
+
+// Redo is not supported as the stores keep separate undo histories.
+func (r *Router) Redo() error {
+	return errors.Wrap(ErrUnsupported, "redo")
+}
+
+func (r *Router) Export(ids ...store.ItemID) ([]store.ItemRecord, error) {
+	var records []store.ItemRecord
+	for _, id := range ids {
+		record, err := routed(r, id, func(backend rpc.Service) ([]store.ItemRecord, error) {
+			return backend.Export(id)
+		})
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record...)
+	}
+	return records, nil
+}
+
+func (r *Router) Import(records ...store.ItemRecord) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	owned := make(map[string][]store.ItemRecord)
+	for _, record := range records {
+		owner := r.owner(record.Item)
+		if owner == "" {
+			return ErrNoNodes
+		}
+		owned[owner] = append(owned[owner], record)
+	}
+
+	for owner, ownedRecords := range owned {
+		if err := r.backends[owner].Import(ownedRecords...); err != nil {
+			return errors.Wrapf(err, "node '%s'", owner)
+		}
+	}
+
+	return nil
+}
+
+func (r *Router) Evict(ids ...store.ItemID) error {
+	_, err := fanOut(r, func(backend rpc.Service) ([]struct{}, error) {
+		return nil, backend.Evict(ids...)
+	})
+	return err
+}
+
+// AddWebhook registers the webhook on every store under the same ID.
+func (r *Router) AddWebhook(webhook store.Webhook) (store.Webhook, error) {
+	if webhook.ID == "" {
+		webhook.ID = uuid.NewString()
+	}
+
+	webhooks, err := fanOut(r, func(backend rpc.Service) ([]store.Webhook, error) {
+		added, err := backend.AddWebhook(webhook)
+		return []store.Webhook{added}, err
+	})
+	if err != nil {
+		return store.Webhook{}, err
+	}
+	if len(webhooks) == 0 {
+		return store.Webhook{}, ErrNoNodes
+	}
+
+	return webhooks[0], nil
+}
+
+func (r *Router) ReadWebhooks() ([]store.Webhook, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	nodes := r.ring.Nodes()
+	if len(nodes) == 0 {
+		return nil, ErrNoNodes
+	}
+
+	return r.backends[nodes[0]].ReadWebhooks()
+}
+
+func (r *Router) DeleteWebhook(id string) error {
+	_, err := fanOut(r, func(backend rpc.Service) ([]struct{}, error) {
+		return nil, backend.DeleteWebhook(id)
+	})
+	return err
+}
+
+// ReadDeliveries merges the deliveries of the webhook by every store in time order.
+func (r *Router) ReadDeliveries(id string) ([]store.Delivery, error) {
+	deliveries, err := fanOut(r, func(backend rpc.Service) ([]store.Delivery, error) {
+		return backend.ReadDeliveries(id)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortStableFunc(deliveries, func(a, b store.Delivery) int {
+		return a.Time.Compare(b.Time)
+	})
+	return deliveries, nil
+}
+
+// Subscribe merges the events of every store on the ring when subscribing. The subscription closes as soon
+// as one of the stores closes its subscription. Event IDs are numbered by the router and stand for the
+// position reached in the events of every store, so a lastEventID resumes every store from where it was.
+// A store that had sent no events by then and an ID the router no longer remembers, such as one from before
+// it was restarted, resume with no events.
+func (r *Router) Subscribe(lastEventID uint64) (*store.Subscription, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	cursors := r.cursors.lookup(lastEventID)
+	nodes := r.ring.Nodes()
+	subs := make(map[string]*store.Subscription)
+	for _, node := range nodes {
+		sub, err := r.backends[node].Subscribe(cursors[node])
+		if err != nil {
+			for _, opened := range subs {
+				opened.Close()
+			}
+			return nil, errors.Wrapf(err, "node '%s'", node)
+		}
+		subs[node] = sub
+	}
+
+	type shardEvent struct {
+		node  string
+		event store.Event
+	}
+
+	received := make(chan shardEvent)
+	events := make(chan store.Event, store.SubscriptionBufferSize)
+	done := make(chan struct{})
+	stop := sync.OnceFunc(func() { close(done) })
+
+	var wg sync.WaitGroup
+	for node, sub := range subs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer stop()
+			for {
+				select {
+				case <-done:
+					return
+				case event, ok := <-sub.Events:
+					if !ok {
+						return
+					}
+					select {
+					case received <- shardEvent{node: node, event: event}:
+					case <-done:
+						return
+					}
+				}
+			}
+		}()
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-done:
+				return
+			case next := <-received:
+				cursors[next.node] = next.event.ID
+				event := next.event
+				event.ID = r.cursors.record(cursors)
+				select {
+				case events <- event:
+				case <-done:
+					return
+				}
+			}
+		}
+	}()
+
+	go func() {
+		wg.Wait()
+		for _, sub := range subs {
+			sub.Close()
+		}
+		close(events)
+	}()
+
+	return store.NewSubscription(events, stop), nil
+}
+
+// cursorLog remembers the position reached in the events of every store for the latest event IDs the
+// router handed out.
+type cursorLog struct {
+	mu      sync.Mutex
+	size    int
+	seq     uint64
+	cursors map[uint64]map[string]uint64
+}
+
+func newCursorLog(size int) *cursorLog {
+	return &cursorLog{
+		size:    size,
+		cursors: make(map[uint64]map[string]uint64),
+	}
+}
+
+// record returns a new event ID standing for the cursors.
+func (l *cursorLog) record(cursors map[string]uint64) uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.seq++
+	l.cursors[l.seq] = maps.Clone(cursors)
+	if l.seq > uint64(l.size) {
+		delete(l.cursors, l.seq-uint64(l.size))
+	}
+
+	return l.seq
+}
+
+// lookup returns a copy of the cursors the event ID stands for, empty when the ID is not remembered.
+func (l *cursorLog) lookup(id uint64) map[string]uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	cursors := maps.Clone(l.cursors[id])
+	if cursors == nil {
+		cursors = make(map[string]uint64)
+	}
+	return cursors
+}
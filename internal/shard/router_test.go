@@ -0,0 +1,318 @@
+package shard
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strconv"
+	"testing"
+	"to-do-app-v2/internal/store"
+)
+
+// newTestBackend returns a store in a temporary directory that is closed with the test.
+func newTestBackend(t *testing.T) *store.Store {
+	t.Helper()
+
+	backend := store.NewStore(store.WithDir(t.TempDir()), store.WithJanitorInterval(0))
+	t.Cleanup(backend.Close)
+
+	return backend
+}
+
+func newTestRouter(t *testing.T, nodes ...string) (*Router, map[string]*store.Store) {
+	t.Helper()
+
+	router := NewRouter(DefaultReplicas)
+	backends := make(map[string]*store.Store)
+	for _, node := range nodes {
+		backends[node] = newTestBackend(t)
+		require.NoError(t, router.AddNode(node, backends[node]))
+	}
+
+	return router, backends
+}
+
+func createItems(t *testing.T, router *Router, count int) []store.Item {
+	t.Helper()
+
+	var items []store.Item
+	for i := range count {
+		item, err := router.Create(store.Item{Name: "name" + strconv.Itoa(i)})
+		require.NoError(t, err)
+		items = append(items, item)
+	}
+
+	return items
+}
+
+func Test_Router_CreatePlacesItemsOnOwner(t *testing.T) {
+	router, backends := newTestRouter(t, "node1", "node2", "node3")
+
+	items := createItems(t, router, 30)
+
+	for _, item := range items {
+		owner := router.ring.Get(item.ID)
+		actualItem, err := backends[owner].Read(store.ItemID(item.ID))
+		assert.NoError(t, err)
+		assert.Equal(t, item, actualItem)
+	}
+	for _, backend := range backends {
+		backendItems, err := backend.ReadAll()
+		assert.NoError(t, err)
+		assert.NotEmpty(t, backendItems)
+	}
+}
+
+func Test_Router_ReadAllMergesShards(t *testing.T) {
+	router, _ := newTestRouter(t, "node1", "node2", "node3")
+	items := createItems(t, router, 30)
+
+	actualItems, err := router.ReadAll()
+
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, items, actualItems)
+}
+
+func Test_Router_AddNodeLeavesItemsWhereTheyAre(t *testing.T) {
+	router, backends := newTestRouter(t, "node1", "node2")
+	items := createItems(t, router, 30)
+
+	backends["node3"] = newTestBackend(t)
+	require.NoError(t, router.AddNode("node3", backends["node3"]))
+
+	moved, err := backends["node3"].ReadAll()
+	assert.NoError(t, err)
+	assert.Empty(t, moved)
+	for _, item := range items {
+		actualItem, err := router.Read(store.ItemID(item.ID))
+		assert.NoError(t, err)
+		assert.Equal(t, item, actualItem)
+	}
+}
+
+func Test_Router_RebalanceMovesItemsToTheirOwners(t *testing.T) {
+	router, backends := newTestRouter(t, "node1", "node2")
+	items := createItems(t, router, 30)
+	_, err := router.AddComment(store.ItemID(items[0].ID), store.Comment{Author: "user1", Text: "text1"})
+	require.NoError(t, err)
+	backends["node3"] = newTestBackend(t)
+	require.NoError(t, router.AddNode("node3", backends["node3"]))
+
+	require.NoError(t, router.Rebalance())
+
+	moved, err := backends["node3"].ReadAll()
+	assert.NoError(t, err)
+	assert.NotEmpty(t, moved)
+	for _, item := range items {
+		owner := router.ring.Get(item.ID)
+		actualItem, err := backends[owner].Read(store.ItemID(item.ID))
+		assert.NoError(t, err)
+		assert.Equal(t, item, actualItem)
+	}
+	actualItems, err := router.ReadAll()
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, items, actualItems)
+	comments, err := router.ReadComments(store.ItemID(items[0].ID))
+	assert.NoError(t, err)
+	assert.Len(t, comments, 1)
+}
+
+func Test_Router_RemoveNodeMovesItsItems(t *testing.T) {
+	router, backends := newTestRouter(t, "node1", "node2", "node3")
+	items := createItems(t, router, 30)
+
+	require.NoError(t, router.RemoveNode("node2"))
+
+	remaining, err := backends["node2"].ReadAll()
+	assert.NoError(t, err)
+	assert.Empty(t, remaining)
+	actualItems, err := router.ReadAll()
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, items, actualItems)
+	assert.Equal(t, []string{"node1", "node3"}, router.Nodes())
+}
+
+func Test_Router_RemoveNodeKeepsLastNode(t *testing.T) {
+	router, _ := newTestRouter(t, "node1")
+
+	assert.Error(t, router.RemoveNode("node1"))
+	assert.Error(t, router.RemoveNode("node2"))
+}
+
+func Test_Router_ReadFallsBackToOtherNodes(t *testing.T) {
+	router, backends := newTestRouter(t, "node1", "node2")
+	items := createItems(t, router, 10)
+	id := store.ItemID(items[0].ID)
+	require.NoError(t, router.Delete(id))
+
+	backends["node3"] = newTestBackend(t)
+	require.NoError(t, router.AddNode("node3", backends["node3"]))
+	restored, err := router.RestoreDeleted(id)
+
+	assert.NoError(t, err)
+	assert.Equal(t, items[0].ID, restored.ID)
+	_, err = backends[router.ring.Get(string(id))].Read(id)
+	assert.NoError(t, err)
+	actualItem, err := router.Read(id)
+	assert.NoError(t, err)
+	assert.Equal(t, restored, actualItem)
+}
+
+func Test_Router_ReadReturnsErrorWhenNotFound(t *testing.T) {
+	router, _ := newTestRouter(t, "node1", "node2")
+
+	_, err := router.Read("id1")
+
+	assert.ErrorIs(t, err, store.ErrNotFound)
+}
+
+func Test_Router_BatchStaysOnOneShard(t *testing.T) {
+	router, backends := newTestRouter(t, "node1", "node2", "node3")
+	items := createItems(t, router, 30)
+	owner := router.ring.Get(items[0].ID)
+
+	results, err := router.Batch([]store.Operation{
+		{Op: store.OpDelete, ID: store.ItemID(items[0].ID)},
+		{Op: store.OpCreate, Item: &store.Item{Name: "name"}},
+	})
+
+	assert.NoError(t, err)
+	require.Len(t, results, 2)
+	_, err = backends[owner].Read(store.ItemID(results[1].Item.ID))
+	assert.NoError(t, err)
+}
+
+func Test_Router_BatchReturnsErrorAcrossShards(t *testing.T) {
+	router, _ := newTestRouter(t, "node1", "node2", "node3")
+	items := createItems(t, router, 30)
+	var other store.Item
+	for _, item := range items[1:] {
+		if router.ring.Get(item.ID) != router.ring.Get(items[0].ID) {
+			other = item
+			break
+		}
+	}
+
+	_, err := router.Batch([]store.Operation{
+		{Op: store.OpDelete, ID: store.ItemID(items[0].ID)},
+		{Op: store.OpDelete, ID: store.ItemID(other.ID)},
+	})
+
+	var batchErr *store.BatchError
+	require.ErrorAs(t, err, &batchErr)
+	assert.Equal(t, 1, batchErr.Index)
+	assert.ErrorIs(t, err, ErrCrossShard)
+}
+
+func Test_Router_CreateIdempotentReplaysOnSameShard(t *testing.T) {
+	router, _ := newTestRouter(t, "node1", "node2", "node3")
+
+	item, replayed, err := router.CreateIdempotent("user1", "key1", store.Item{Name: "name1"})
+	require.NoError(t, err)
+	assert.False(t, replayed)
+	replayedItem, replayed, err := router.CreateIdempotent("user1", "key1", store.Item{Name: "name1"})
+
+	assert.NoError(t, err)
+	assert.True(t, replayed)
+	assert.Equal(t, item, replayedItem)
+}
+
+func Test_Router_SubscribeMergesShards(t *testing.T) {
+	router, _ := newTestRouter(t, "node1", "node2", "node3")
+	sub, err := router.Subscribe(0)
+	require.NoError(t, err)
+	defer sub.Close()
+
+	items := createItems(t, router, 10)
+
+	ids := make([]string, 0, len(items))
+	for range items {
+		event := <-sub.Events
+		assert.Equal(t, store.EventCreate, event.Type)
+		ids = append(ids, string(event.ItemID))
+	}
+	for _, item := range items {
+		assert.Contains(t, ids, item.ID)
+	}
+}
+
+func Test_Router_AddNodeCopiesWebhooks(t *testing.T) {
+	router, backends := newTestRouter(t, "node1", "node2")
+	webhook, err := router.AddWebhook(store.Webhook{URL: "http://localhost/hook", Secret: "secret1"})
+	require.NoError(t, err)
+
+	backends["node3"] = newTestBackend(t)
+	require.NoError(t, router.AddNode("node3", backends["node3"]))
+
+	for _, backend := range backends {
+		webhooks, err := backend.ReadWebhooks()
+		assert.NoError(t, err)
+		require.Len(t, webhooks, 1)
+		assert.Equal(t, webhook.ID, webhooks[0].ID)
+	}
+}
+
+func Test_Router_ReadAssignedReadsOwnerOfAssignee(t *testing.T) {
+	router, backends := newTestRouter(t, "node1", "node2", "node3")
+	var assigned []store.Item
+	for i := range 10 {
+		item, err := router.Create(store.Item{Name: "name" + strconv.Itoa(i), Assignee: "user" + strconv.Itoa(i%2)})
+		require.NoError(t, err)
+		if item.Assignee == "user0" {
+			assigned = append(assigned, item)
+		}
+	}
+	item, err := router.Create(store.Item{Name: "name10"})
+	require.NoError(t, err)
+	item, err = router.Assign(store.ItemID(item.ID), "user0")
+	require.NoError(t, err)
+	assigned = append(assigned, item)
+
+	actualItems, err := router.ReadAssigned("user0")
+
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, assigned, actualItems)
+	ownerItems, err := backends[router.ring.Get(userKey("user0"))].ReadAssigned("user0")
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, assigned, ownerItems)
+	actualItem, err := router.Read(store.ItemID(item.ID))
+	assert.NoError(t, err)
+	assert.Equal(t, item, actualItem)
+}
+
+func Test_Router_SubscribeResumesEveryShardFromItsCursor(t *testing.T) {
+	router, _ := newTestRouter(t, "node1", "node2", "node3")
+	sub, err := router.Subscribe(0)
+	require.NoError(t, err)
+	createItems(t, router, 30)
+	var last store.Event
+	for range 30 {
+		last = <-sub.Events
+	}
+	sub.Close()
+
+	items := createItems(t, router, 10)
+	resumed, err := router.Subscribe(last.ID)
+	require.NoError(t, err)
+	defer resumed.Close()
+
+	ids := make([]string, 0, len(items))
+	for range items {
+		event := <-resumed.Events
+		assert.Greater(t, event.ID, last.ID)
+		ids = append(ids, string(event.ItemID))
+	}
+	assert.ElementsMatch(t, itemIDs(items), ids)
+	select {
+	case event := <-resumed.Events:
+		assert.Fail(t, "unexpected event", "%+v", event)
+	default:
+	}
+}
+
+func itemIDs(items []store.Item) []string {
+	ids := make([]string, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.ID)
+	}
+	return ids
+}
@@ -0,0 +1,212 @@
+package store
+
+import (
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"time"
+)
+
+type Comment struct {
+	ID     string    `json:"id"`
+	Author string    `json:"author"`
+	Text   string    `json:"text"`
+	Time   time.Time `json:"time"`
+}
+
+func (comment Comment) String() string {
+	return fmt.Sprintf("%s %s: %s", comment.Time.Format(time.DateTime), comment.Author, comment.Text)
+}
+
+type Activity struct {
+	Time    time.Time `json:"time"`
+	Message string    `json:"message"`
+}
+
+func (activity Activity) String() string {
+	return fmt.Sprintf("%s %s", activity.Time.Format(time.DateTime), activity.Message)
+}
+
+const (
+	CommentsFilename = "comments.json"
+	HistoryFilename  = "history.json"
+)
+
+func (s *Store) addComment(id ItemID, comment Comment) response {
+	if err := s.loadItems(); err != nil {
+		return response{
+			err: errors.Wrap(err, "load items"),
+		}
+	}
+
+	item, found := s.items[id]
+	if !found {
+		return response{
+			err: errors.Wrapf(ErrNotFound, "item '%s'", id),
+		}
+	}
+
+	if err := s.backend.load(CommentsFilename, &s.comments); err != nil {
+		return response{
+			err: errors.Wrap(err, "load comments"),
+		}
+	}
+
+	comment.ID = uuid.NewString()
+	comment.Time = time.Now().UTC()
+	s.comments[id] = append(s.comments[id], comment)
+
+	if err := s.backend.save(CommentsFilename, s.comments); err != nil {
+		return response{
+			err: errors.Wrap(err, "save comments"),
+		}
+	}
+
+	s.queueEvent(Event{
+		Type:    EventComment,
+		ItemID:  id,
+		Item:    &item,
+		Time:    comment.Time,
+		Comment: &comment,
+	})
+
+	return response{
+		comment: comment,
+	}
+}
+
+func (s *Store) AddComment(id ItemID, comment Comment) (Comment, error) {
+	responseChan := make(chan response, 1)
+	req := request{
+		action:       "addComment",
+		responseChan: responseChan,
+		id:           id,
+		comment:      comment,
+	}
+	res := s.send(req)
+
+	return res.comment, res.err
+}
+
+func (s *Store) readComments(id ItemID) response {
+	if err := s.loadItems(); err != nil {
+		return response{
+			err: errors.Wrap(err, "load items"),
+		}
+	}
+
+	if _, found := s.items[id]; !found {
+		return response{
+			err: errors.Wrapf(ErrNotFound, "item '%s'", id),
+		}
+	}
+
+	if err := s.backend.load(CommentsFilename, &s.comments); err != nil {
+		return response{
+			err: errors.Wrap(err, "load comments"),
+		}
+	}
+
+	comments := make([]Comment, len(s.comments[id]))
+	copy(comments, s.comments[id])
+
+	return response{
+		comments: comments,
+	}
+}
+
+func (s *Store) ReadComments(id ItemID) ([]Comment, error) {
+	responseChan := make(chan response, 1)
+	req := request{
+		action:       "readComments",
+		responseChan: responseChan,
+		id:           id,
+	}
+	res := s.send(req)
+
+	return res.comments, res.err
+}
+
+func (s *Store) readHistory(id ItemID) response {
+	if err := s.loadItems(); err != nil {
+		return response{
+			err: errors.Wrap(err, "load items"),
+		}
+	}
+
+	if err := s.backend.load(HistoryFilename, &s.history); err != nil {
+		return response{
+			err: errors.Wrap(err, "load history"),
+		}
+	}
+
+	// History outlives deleted items, and items created before history was recorded have none
+	activities, found := s.history[id]
+	if _, exists := s.items[id]; !found && !exists {
+		return response{
+			err: errors.Wrapf(ErrNotFound, "history for item '%s'", id),
+		}
+	}
+
+	history := make([]Activity, len(activities))
+	copy(history, activities)
+
+	return response{
+		history: history,
+	}
+}
+
+func (s *Store) ReadHistory(id ItemID) ([]Activity, error) {
+	responseChan := make(chan response, 1)
+	req := request{
+		action:       "readHistory",
+		responseChan: responseChan,
+		id:           id,
+	}
+	res := s.send(req)
+
+	return res.history, res.err
+}
+
+func (s *Store) recordActivity(id ItemID, messages ...string) error {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	if err := s.backend.load(HistoryFilename, &s.history); err != nil {
+		return errors.Wrap(err, "load history")
+	}
+
+	now := time.Now().UTC()
+	for _, message := range messages {
+		s.history[id] = append(s.history[id], Activity{Time: now, Message: message})
+	}
+
+	if err := s.backend.save(HistoryFilename, s.history); err != nil {
+		return errors.Wrap(err, "save history")
+	}
+
+	return nil
+}
+
+func changes(oldItem, newItem Item) []string {
+	var messages []string
+	if oldItem.Name != newItem.Name {
+		messages = append(messages, fmt.Sprintf("renamed from '%s' to '%s'", oldItem.Name, newItem.Name))
+	}
+	if oldItem.Desc != newItem.Desc {
+		messages = append(messages, "description changed")
+	}
+	if oldItem.Status != newItem.Status {
+		messages = append(messages, fmt.Sprintf("status changed from '%s' to '%s'", oldItem.Status, newItem.Status))
+	}
+	if oldItem.Assignee != newItem.Assignee {
+		if newItem.Assignee == "" {
+			messages = append(messages, "unassigned")
+		} else {
+			messages = append(messages, fmt.Sprintf("assigned to '%s'", newItem.Assignee))
+		}
+	}
+
+	return messages
+}
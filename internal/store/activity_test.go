@@ -0,0 +1,98 @@
+package store
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_AddComment_ReturnsComment(t *testing.T) {
+	t.Cleanup(setupTest())
+
+	data := map[ItemID]Item{
+		"id1": {ID: "id1", Name: "name1", Desc: "desc1", Status: "status1"},
+	}
+	store := newTestStore()
+	store.items = data
+
+	comment, err := store.AddComment("id1", Comment{Author: "user1", Text: "text1"})
+
+	assert.NoError(t, err)
+	assert.NotEmpty(t, comment.ID)
+	assert.False(t, comment.Time.IsZero())
+	assert.Equal(t, "user1", comment.Author)
+	assert.Equal(t, "text1", comment.Text)
+}
+
+func Test_AddComment_ReturnsErrorWhenNotFound(t *testing.T) {
+	t.Cleanup(setupTest())
+
+	store := newTestStore()
+
+	_, err := store.AddComment("id1", Comment{Author: "user1", Text: "text1"})
+
+	assert.Error(t, err)
+}
+
+func Test_ReadComments_ReturnsCommentsInOrder(t *testing.T) {
+	t.Cleanup(setupTest())
+
+	data := map[ItemID]Item{
+		"id1": {ID: "id1", Name: "name1", Desc: "desc1", Status: "status1"},
+		"id2": {ID: "id2", Name: "name2", Desc: "desc2", Status: "status2"},
+	}
+	store := newTestStore()
+	store.items = data
+
+	_, err := store.AddComment("id1", Comment{Author: "user1", Text: "text1"})
+	assert.NoError(t, err)
+	_, err = store.AddComment("id2", Comment{Author: "user1", Text: "text2"})
+	assert.NoError(t, err)
+	_, err = store.AddComment("id1", Comment{Author: "user2", Text: "text3"})
+	assert.NoError(t, err)
+
+	comments, err := store.ReadComments("id1")
+
+	assert.NoError(t, err)
+	assert.Len(t, comments, 2)
+	assert.Equal(t, "text1", comments[0].Text)
+	assert.Equal(t, "text3", comments[1].Text)
+}
+
+func Test_ReadHistory_RecordsActivity(t *testing.T) {
+	t.Cleanup(setupTest())
+
+	store := newTestStore()
+
+	item, err := store.Create(Item{Name: "name1", Desc: "desc1", Status: "not started"})
+	assert.NoError(t, err)
+	id := ItemID(item.ID)
+
+	_, err = store.Update(id, Item{Name: "name2", Desc: "desc1", Status: "started"})
+	assert.NoError(t, err)
+	_, err = store.Assign(id, "user1")
+	assert.NoError(t, err)
+
+	history, err := store.ReadHistory(id)
+
+	assert.NoError(t, err)
+	messages := make([]string, 0, len(history))
+	for _, activity := range history {
+		messages = append(messages, activity.Message)
+	}
+	assert.Equal(t, []string{
+		"created",
+		"renamed from 'name1' to 'name2'",
+		"status changed from 'not started' to 'started'",
+		"assigned to 'user1'",
+	}, messages)
+}
+
+func Test_ReadHistory_ReturnsErrorWhenNotFound(t *testing.T) {
+	t.Cleanup(setupTest())
+
+	store := newTestStore()
+
+	_, err := store.ReadHistory("id1")
+
+	assert.Error(t, err)
+}
@@ -0,0 +1,275 @@
+package store
+
+import (
+	"github.com/pkg/errors"
+	"time"
+)
+
+const (
+	ArchiveFilename     = "archive.json"
+	DefaultArchiveAfter = 7 * 24 * time.Hour
+)
+
+// WithArchiveAfter sets how long completed items stay in the item list before being archived, a
+// non-positive duration disables automatic archiving.
+func WithArchiveAfter(after time.Duration) Option {
+	return func(s *Store) {
+		s.archiveAfter = after
+	}
+}
+
+// withManagedFields sets the store managed version and timestamps of an item written to the item list.
+func withManagedFields(oldItem, item Item) Item {
+	item.Version = oldItem.Version + 1
+	item.ArchivedAt = nil
+	switch {
+	case item.Status != StatusCompleted:
+		item.CompletedAt = nil
+	case oldItem.Status == StatusCompleted && oldItem.CompletedAt != nil:
+		item.CompletedAt = oldItem.CompletedAt
+	default:
+		now := time.Now().UTC()
+		item.CompletedAt = &now
+	}
+
+	return item
+}
+
+func (s *Store) archiveItem(id ItemID) response {
+	if err := s.loadItems(); err != nil {
+		return response{
+			err: errors.Wrap(err, "load items"),
+		}
+	}
+
+	item, found := s.items[id]
+	if !found {
+		return response{
+			err: errors.Wrapf(ErrNotFound, "item '%s'", id),
+		}
+	}
+
+	archived, err := s.moveToArchive(item)
+	if err != nil {
+		return response{
+			err: errors.Wrap(err, "move to archive"),
+		}
+	}
+
+	return response{
+		item: archived[0],
+	}
+}
+
+func (s *Store) Archive(id ItemID) (Item, error) {
+	responseChan := make(chan response, 1)
+	req := request{
+		action:       "archive",
+		responseChan: responseChan,
+		id:           id,
+	}
+	res := s.send(req)
+
+	return res.item, res.err
+}
+
+func (s *Store) archiveCompleted() response {
+	if s.archiveAfter <= 0 {
+		return response{
+			items: []Item{},
+		}
+	}
+
+	if err := s.loadItems(); err != nil {
+		return response{
+			err: errors.Wrap(err, "load items"),
+		}
+	}
+
+	if err := s.backend.load(HistoryFilename, &s.history); err != nil {
+		return response{
+			err: errors.Wrap(err, "load history"),
+		}
+	}
+
+	cutoff := time.Now().UTC().Add(-s.archiveAfter)
+	var completed []Item
+	for id, item := range s.items {
+		if item.Status != StatusCompleted {
+			continue
+		}
+
+		// Items completed before the store kept completion times were completed by their last change at the latest
+		completedAt := item.CompletedAt
+		if history := s.history[id]; completedAt == nil && len(history) > 0 {
+			completedAt = &history[len(history)-1].Time
+		}
+		if completedAt != nil && completedAt.Before(cutoff) {
+			completed = append(completed, item)
+		}
+	}
+
+	archived, err := s.moveToArchive(completed...)
+	if err != nil {
+		return response{
+			err: errors.Wrap(err, "move to archive"),
+		}
+	}
+
+	return response{
+		items: archived,
+	}
+}
+
+func (s *Store) ArchiveCompleted() ([]Item, error) {
+	responseChan := make(chan response, 1)
+	req := request{
+		action:       "archiveCompleted",
+		responseChan: responseChan,
+	}
+	res := s.send(req)
+
+	return res.items, res.err
+}
+
+func (s *Store) readArchived() response {
+	if err := s.backend.load(ArchiveFilename, &s.archive); err != nil {
+		return response{
+			err: errors.Wrap(err, "load archive"),
+		}
+	}
+
+	items := make([]Item, 0, len(s.archive))
+	for _, item := range s.archive {
+		items = append(items, item)
+	}
+
+	return response{
+		items: items,
+	}
+}
+
+func (s *Store) ReadArchived() ([]Item, error) {
+	responseChan := make(chan response, 1)
+	req := request{
+		action:       "readArchived",
+		responseChan: responseChan,
+	}
+	res := s.send(req)
+
+	return res.items, res.err
+}
+
+func (s *Store) unarchive(id ItemID) response {
+	if err := s.loadItems(); err != nil {
+		return response{
+			err: errors.Wrap(err, "load items"),
+		}
+	}
+
+	if err := s.backend.load(ArchiveFilename, &s.archive); err != nil {
+		return response{
+			err: errors.Wrap(err, "load archive"),
+		}
+	}
+
+	item, found := s.archive[id]
+	if !found {
+		return response{
+			err: errors.Wrapf(ErrNotFound, "item '%s' in archive", id),
+		}
+	}
+
+	archived := item
+	item.ArchivedAt = nil
+	s.items[id] = item
+
+	if err := s.saveItems(); err != nil {
+		return response{
+			err: errors.Wrap(err, "save items"),
+		}
+	}
+
+	delete(s.archive, id)
+
+	if err := s.backend.save(ArchiveFilename, s.archive); err != nil {
+		return response{
+			err: errors.Wrap(err, "save archive"),
+		}
+	}
+
+	if err := s.recordActivity(id, "unarchived"); err != nil {
+		return response{
+			err: errors.Wrap(err, "record activity"),
+		}
+	}
+
+	if err := s.recordMutation("unarchive", Change{ID: id, Before: &archived, After: &item}); err != nil {
+		return response{
+			err: errors.Wrap(err, "record mutation"),
+		}
+	}
+
+	return response{
+		item: item,
+	}
+}
+
+func (s *Store) Unarchive(id ItemID) (Item, error) {
+	responseChan := make(chan response, 1)
+	req := request{
+		action:       "unarchive",
+		responseChan: responseChan,
+		id:           id,
+	}
+	res := s.send(req)
+
+	return res.item, res.err
+}
+
+// moveToArchive moves items from the item list to the archive, the archive is saved first so a failure
+// never loses an item.
+func (s *Store) moveToArchive(items ...Item) ([]Item, error) {
+	archived := make([]Item, 0, len(items))
+	if len(items) == 0 {
+		return archived, nil
+	}
+
+	if err := s.backend.load(ArchiveFilename, &s.archive); err != nil {
+		return nil, errors.Wrap(err, "load archive")
+	}
+
+	now := time.Now().UTC()
+	changes := make([]Change, 0, len(items))
+	for _, item := range items {
+		before, after := item, item
+		after.ArchivedAt = &now
+		s.archive[ItemID(item.ID)] = after
+		archived = append(archived, after)
+		changes = append(changes, Change{ID: ItemID(item.ID), Before: &before, After: &after})
+	}
+
+	if err := s.backend.save(ArchiveFilename, s.archive); err != nil {
+		return nil, errors.Wrap(err, "save archive")
+	}
+
+	for _, item := range items {
+		delete(s.items, ItemID(item.ID))
+	}
+
+	if err := s.saveItems(); err != nil {
+		return nil, errors.Wrap(err, "save items")
+	}
+
+	for _, item := range archived {
+		if err := s.recordActivity(ItemID(item.ID), "archived"); err != nil {
+			return nil, errors.Wrap(err, "record activity")
+		}
+	}
+
+	if err := s.recordMutation("archive", changes...); err != nil {
+		return nil, errors.Wrap(err, "record mutation")
+	}
+
+	return archived, nil
+}
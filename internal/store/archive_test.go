@@ -0,0 +1,132 @@
+package store
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func Test_Update_SetsCompletedAt(t *testing.T) {
+	t.Cleanup(setupTest())
+
+	data := map[ItemID]Item{
+		"id1": {ID: "id1", Name: "name1", Desc: "desc1", Status: "started"},
+	}
+	store := newTestStore()
+	store.items = data
+
+	item, err := store.Update("id1", Item{Name: "name1", Desc: "desc1", Status: StatusCompleted})
+	assert.NoError(t, err)
+	assert.NotNil(t, item.CompletedAt)
+
+	item, err = store.Update("id1", Item{Name: "name1", Desc: "desc1", Status: "started"})
+	assert.NoError(t, err)
+	assert.Nil(t, item.CompletedAt)
+}
+
+func Test_Archive_MovesItemToArchive(t *testing.T) {
+	t.Cleanup(setupTest())
+
+	data := map[ItemID]Item{
+		"id1": {ID: "id1", Name: "name1", Desc: "desc1", Status: "status1"},
+		"id2": {ID: "id2", Name: "name2", Desc: "desc2", Status: "status2"},
+	}
+	store := newTestStore()
+	store.items = data
+
+	item, err := store.Archive("id2")
+	assert.NoError(t, err)
+	assert.NotNil(t, item.ArchivedAt)
+
+	items, err := store.ReadAll()
+	assert.NoError(t, err)
+	archived, err := store.ReadArchived()
+	assert.NoError(t, err)
+
+	assert.Equal(t, []Item{{ID: "id1", Name: "name1", Desc: "desc1", Status: "status1"}}, items)
+	assert.Equal(t, []Item{item}, archived)
+}
+
+func Test_ArchiveCompleted_ArchivesOldCompletedItems(t *testing.T) {
+	t.Cleanup(setupTest())
+
+	old := time.Now().UTC().Add(-8 * 24 * time.Hour)
+	recent := time.Now().UTC().Add(-time.Hour)
+	data := map[ItemID]Item{
+		"id1": {ID: "id1", Name: "name1", Status: StatusCompleted, CompletedAt: &old},
+		"id2": {ID: "id2", Name: "name2", Status: StatusCompleted, CompletedAt: &recent},
+		"id3": {ID: "id3", Name: "name3", Status: "started"},
+	}
+	store := newTestStore()
+	store.items = data
+
+	archived, err := store.ArchiveCompleted()
+
+	assert.NoError(t, err)
+	assert.Len(t, archived, 1)
+	assert.Equal(t, "id1", archived[0].ID)
+	items, err := store.ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, items, 2)
+}
+
+func Test_ArchiveCompleted_ArchivesItemsCompletedBeforeCompletionTimesByTheirLastChange(t *testing.T) {
+	t.Cleanup(setupTest())
+
+	old := time.Now().UTC().Add(-8 * 24 * time.Hour)
+	recent := time.Now().UTC().Add(-time.Hour)
+	store := newTestStore()
+	store.items = map[ItemID]Item{
+		"id1": {ID: "id1", Name: "name1", Status: StatusCompleted},
+		"id2": {ID: "id2", Name: "name2", Status: StatusCompleted},
+	}
+	store.history = map[ItemID][]Activity{
+		"id1": {{Time: old, Message: "created"}},
+		"id2": {{Time: old, Message: "created"}, {Time: recent, Message: "updated"}},
+	}
+
+	archived, err := store.ArchiveCompleted()
+
+	assert.NoError(t, err)
+	assert.Len(t, archived, 1)
+	assert.Equal(t, "id1", archived[0].ID)
+}
+
+func Test_ArchiveCompleted_DoesNothingWhenDisabled(t *testing.T) {
+	t.Cleanup(setupTest())
+
+	old := time.Now().UTC().Add(-8 * 24 * time.Hour)
+	data := map[ItemID]Item{
+		"id1": {ID: "id1", Name: "name1", Status: StatusCompleted, CompletedAt: &old},
+	}
+	store := newTestStore(WithArchiveAfter(0))
+	store.items = data
+
+	archived, err := store.ArchiveCompleted()
+
+	assert.NoError(t, err)
+	assert.Empty(t, archived)
+}
+
+func Test_Unarchive_ReturnsItemToList(t *testing.T) {
+	t.Cleanup(setupTest())
+
+	data := map[ItemID]Item{
+		"id1": {ID: "id1", Name: "name1", Desc: "desc1", Status: "status1"},
+	}
+	store := newTestStore()
+	store.items = data
+
+	_, err := store.Archive("id1")
+	assert.NoError(t, err)
+
+	item, err := store.Unarchive("id1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, data["id1"], item)
+	archived, err := store.ReadArchived()
+	assert.NoError(t, err)
+	assert.Empty(t, archived)
+	_, err = store.Read("id1")
+	assert.NoError(t, err)
+}
@@ -0,0 +1,348 @@
+package store
+
+import (
+	"encoding/json"
+	"github.com/pkg/errors"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+)
+
+// backend keeps the items and the other collections of the store, each collection under the name of its
+// file. Loading a collection that was never saved leaves the value as it is. Every request of the store runs
+// between begin and end, end commits the changes of the request or, on failure, discards them where the
+// backend can.
+type backend interface {
+	begin() error
+	end(commit bool) error
+	loadItems(items map[ItemID]Item) error
+	saveItems(items map[ItemID]Item) error
+	load(name string, v any) error
+	save(name string, v any) error
+	exists(name string) (bool, error)
+	close() error
+}
+
+const (
+	BackendFile   = "file"
+	BackendSQLite = "sqlite"
+	BackendBolt   = "bolt"
+	// BackendPostgres is chosen by configuration with the URL of the database
+	BackendPostgres = "postgres"
+)
+
+// BackendOption returns the option that keeps the store in the named backend, for backends chosen by
+// configuration. A postgres:// URL names the PostgreSQL database to use.
+func BackendOption(name string) (Option, error) {
+	if isPostgresURL(name) {
+		return WithPostgres(name), nil
+	}
+
+	switch name {
+	case BackendFile:
+		return func(s *Store) {
+			s.backendName = BackendFile
+		}, nil
+	case BackendSQLite:
+		return WithSQLite(), nil
+	case BackendBolt:
+		return WithBolt(), nil
+	default:
+		return nil, errors.Errorf("unknown backend '%s', expected %s, %s, %s or a postgres:// URL", name, BackendFile, BackendSQLite, BackendBolt)
+	}
+}
+
+func newBackend(name, dir, postgresURL string, key *EncryptionKey) (backend, error) {
+	if key != nil && name != "" && name != BackendFile {
+		return nil, errors.Errorf("the %s backend does not encrypt, only the %s backend does", name, BackendFile)
+	}
+
+	switch name {
+	case BackendPostgres:
+		return &postgresBackend{url: postgresURL}, nil
+	case BackendSQLite:
+		return &sqliteBackend{path: filepath.Join(dir, SQLiteFilename)}, nil
+	case BackendBolt:
+		return &boltBackend{path: filepath.Join(dir, BoltFilename)}, nil
+	default:
+		return &fileBackend{dir: dir, cipher: newFileCipher(key)}, nil
+	}
+}
+
+// assigneeIndex is implemented by backends that keep the items of every assignee apart, so that reading them
+// does not read every item. It reports false when the items of the assignee must be read from all items.
+type assigneeIndex interface {
+	assigned(assignee string) ([]Item, bool, error)
+}
+
+var _ backend = (*fileBackend)(nil)
+
+// fileBackend keeps every collection in a JSON file of its own, rewriting the whole file on every save. With
+// a cipher the files are encrypted. Every request holds the lock file of the directory, so that stores in
+// other processes never load the files between the load and save of a request. The files a request saves
+// are staged and only written once it commits, so a request that fails leaves the files as they were.
+type fileBackend struct {
+	dir    string
+	cipher *fileCipher
+	lock   *fileLock
+	staged map[string][]byte
+}
+
+func (b *fileBackend) path(filename string) string {
+	return filepath.Join(b.dir, filename)
+}
+
+func (b *fileBackend) loadItems(items map[ItemID]Item) error {
+	data, err := b.read(ItemsFilename)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+
+		if err = b.saveItems(items); err != nil {
+			return errors.Wrap(err, "save items")
+		}
+
+		data, err = b.read(ItemsFilename)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Older files are migrated on every load until items are saved again
+	loaded, _, err := decodeItemsFile(data)
+	if err != nil {
+		return errors.Wrapf(err, "decode %s", ItemsFilename)
+	}
+
+	clear(items)
+	maps.Copy(items, loaded)
+
+	return nil
+}
+
+func (b *fileBackend) saveItems(items map[ItemID]Item) error {
+	return b.save(ItemsFilename, itemsFile{Version: ItemsFileVersion, Items: items})
+}
+
+func (b *fileBackend) load(name string, v any) error {
+	data, err := b.read(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return errors.Wrapf(decode(data, v), "decode %s", name)
+}
+
+func (b *fileBackend) save(name string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return errors.Wrapf(err, "encode %s", name)
+	}
+
+	return b.write(name, data)
+}
+
+// read returns the content of the file as staged by the request, or as written, decrypted when the backend
+// has a cipher.
+func (b *fileBackend) read(name string) ([]byte, error) {
+	if data, found := b.staged[name]; found {
+		return data, nil
+	}
+
+	data, err := os.ReadFile(b.path(name))
+	if err != nil {
+		return nil, errors.Wrapf(err, "read %s", name)
+	}
+
+	data, err = b.cipher.open(data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "decrypt %s", name)
+	}
+
+	return data, nil
+}
+
+// write stages the content of the file until the request commits, outside of a request it replaces the
+// content right away. The content is encrypted when the backend has a cipher.
+func (b *fileBackend) write(name string, data []byte) error {
+	if b.staged != nil {
+		b.staged[name] = data
+		return nil
+	}
+
+	data, err := b.cipher.seal(data)
+	if err != nil {
+		return errors.Wrapf(err, "encrypt %s", name)
+	}
+
+	return errors.Wrapf(writeFile(b.path(name), data, 0o600), "write %s", name)
+}
+
+func (b *fileBackend) exists(name string) (bool, error) {
+	if _, found := b.staged[name]; found {
+		return true, nil
+	}
+
+	_, err := os.Stat(b.path(name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+
+	return err == nil, errors.Wrapf(err, "stat %s", name)
+}
+
+func (b *fileBackend) begin() error {
+	lock, err := lockFile(b.path(LockFilename))
+	if err != nil {
+		return err
+	}
+	b.lock = lock
+
+	// A commit or key rotation that was interrupted is finished before the files are used
+	if err := b.finishReplacement(CommitFilename, commitSuffix); err != nil {
+		_ = b.end(false)
+		return errors.Wrap(err, "finish commit")
+	}
+	if err := b.finishRotation(); err != nil {
+		_ = b.end(false)
+		return errors.Wrap(err, "finish key rotation")
+	}
+	b.staged = make(map[string][]byte)
+
+	return nil
+}
+
+func (b *fileBackend) end(commit bool) error {
+	staged := b.staged
+	b.staged = nil
+
+	var err error
+	if commit {
+		err = b.commit(staged)
+	}
+
+	if b.lock == nil {
+		return err
+	}
+
+	lock := b.lock
+	b.lock = nil
+
+	if unlockErr := lock.unlock(); err == nil {
+		err = unlockErr
+	}
+
+	return err
+}
+
+// CommitFilename is the marker a request leaves while it replaces the files it saved, listing the files whose
+// new content is complete.
+const CommitFilename = "commit.json"
+
+const commitSuffix = ".commit"
+
+// commit writes the files staged by a request next to the files they replace, then a marker listing them,
+// and replaces the files. A failure before the marker is written changes nothing, when replacing the files
+// fails partway the next request, in this process or the next to open the store, finishes the commit.
+func (b *fileBackend) commit(staged map[string][]byte) error {
+	if len(staged) == 0 {
+		return nil
+	}
+
+	names := slices.Sorted(maps.Keys(staged))
+	for _, name := range names {
+		data, err := b.cipher.seal(staged[name])
+		if err != nil {
+			return errors.Wrapf(err, "encrypt %s", name)
+		}
+		if err := writeFile(b.path(name+commitSuffix), data, 0o600); err != nil {
+			return errors.Wrapf(err, "write %s", name)
+		}
+	}
+
+	if err := b.writeReplacement(CommitFilename, names); err != nil {
+		return errors.Wrap(err, "write commit")
+	}
+
+	return errors.Wrap(b.finishReplacement(CommitFilename, commitSuffix), "commit")
+}
+
+// replacement is the content of the marker left while files are replaced by the complete copies written next
+// to them.
+type replacement struct {
+	Files []string `json:"files"`
+}
+
+// writeFile and renameFile write and replace the files of the store, tests swap them to fail partway through.
+var (
+	writeFile  = os.WriteFile
+	renameFile = os.Rename
+)
+
+// writeReplacement writes the marker listing the files whose copy is complete, all at once.
+func (b *fileBackend) writeReplacement(marker string, names []string) error {
+	data, err := json.Marshal(replacement{Files: names})
+	if err != nil {
+		return errors.Wrapf(err, "encode %s", marker)
+	}
+	if err := writeFile(b.path(marker+".tmp"), data, 0o600); err != nil {
+		return errors.Wrapf(err, "write %s", marker)
+	}
+
+	return errors.Wrapf(os.Rename(b.path(marker+".tmp"), b.path(marker)), "replace %s", marker)
+}
+
+// finishReplacement replaces the files listed in the marker by their copies with the suffix and removes the
+// marker. Files replaced before the replacement was interrupted have no copy left.
+func (b *fileBackend) finishReplacement(marker, suffix string) error {
+	data, err := os.ReadFile(b.path(marker))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrapf(err, "read %s", marker)
+	}
+
+	var listed replacement
+	if err := json.Unmarshal(data, &listed); err != nil {
+		return errors.Wrapf(err, "decode %s", marker)
+	}
+
+	for _, name := range listed.Files {
+		err := renameFile(b.path(name+suffix), b.path(name))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return errors.Wrapf(err, "replace %s", name)
+		}
+	}
+
+	return errors.Wrapf(os.Remove(b.path(marker)), "remove %s", marker)
+}
+
+func (b *fileBackend) close() error {
+	return nil
+}
+
+// decode replaces the value v points to with the one in data. Decoding into the value itself would merge
+// maps into what the store held, bringing back entries that stores in other processes removed since.
+func decode(data []byte, v any) error {
+	target := reflect.ValueOf(v).Elem()
+	decoded := reflect.New(target.Type())
+	if err := json.Unmarshal(data, decoded.Interface()); err != nil {
+		return err
+	}
+
+	// The store adds to its maps, so a map saved as null comes back empty
+	if decoded.Elem().Kind() == reflect.Map && decoded.Elem().IsNil() {
+		decoded.Elem().Set(reflect.MakeMap(target.Type()))
+	}
+	target.Set(decoded.Elem())
+
+	return nil
+}
@@ -0,0 +1,167 @@
+package store
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"maps"
+)
+
+const (
+	OpCreate = "create"
+	OpUpdate = "update"
+	OpDelete = "delete"
+)
+
+// Operation is a create, update or delete of a batch. A delete carrying an item with a non-zero version only
+// deletes the item at that version.
+type Operation struct {
+	Op   string `json:"op"`
+	ID   ItemID `json:"id,omitempty"`
+	Item *Item  `json:"item,omitempty"`
+}
+
+type OperationResult struct {
+	Op   string `json:"op"`
+	ID   ItemID `json:"id"`
+	Item *Item  `json:"item,omitempty"`
+}
+
+// BatchError reports the invalid operation that caused a batch to be rejected, none of the operations are
+// applied. Failures of the backend are returned as they are.
+type BatchError struct {
+	Index int
+	Err   error
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("operation %d: %s", e.Index, e.Err.Error())
+}
+
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
+
+func (s *Store) batch(operations []Operation) response {
+	if err := s.loadItems(); err != nil {
+		return response{
+			err: errors.Wrap(err, "load items"),
+		}
+	}
+
+	// Operations are applied to a copy of the items so a rejected batch leaves the store untouched
+	items := maps.Clone(s.items)
+	results := make([]OperationResult, 0, len(operations))
+	applied := make([]Change, 0, len(operations))
+	var trashed []Item
+
+	for i, operation := range operations {
+		switch operation.Op {
+		case OpCreate:
+			if operation.Item == nil {
+				return response{err: &BatchError{Index: i, Err: errors.New("item is required")}}
+			}
+			// The copy also holds the items created earlier in the batch
+			id, err := s.newID(operation.ID)
+			if _, found := items[id]; err == nil && found {
+				err = errors.Wrapf(ErrAlreadyExists, "item '%s'", id)
+			}
+			if errors.Is(err, ErrAlreadyExists) {
+				return response{err: &BatchError{Index: i, Err: err}}
+			}
+			if err != nil {
+				return response{err: errors.Wrapf(err, "operation %d", i)}
+			}
+			item := withManagedFields(Item{}, *operation.Item)
+			item.ID = string(id)
+			items[id] = item
+			applied = append(applied, Change{ID: id, After: &item})
+			results = append(results, OperationResult{Op: operation.Op, ID: id, Item: &item})
+		case OpUpdate:
+			if operation.Item == nil {
+				return response{err: &BatchError{Index: i, Err: errors.New("item is required")}}
+			}
+			oldItem, found := items[operation.ID]
+			if !found {
+				return response{err: &BatchError{Index: i, Err: errors.Wrapf(ErrNotFound, "item '%s'", operation.ID)}}
+			}
+			if err := checkVersion(oldItem, operation.Item.Version); err != nil {
+				return response{err: &BatchError{Index: i, Err: err}}
+			}
+			item := withManagedFields(oldItem, *operation.Item)
+			item.ID = string(operation.ID)
+			items[operation.ID] = item
+			applied = append(applied, Change{ID: operation.ID, Before: &oldItem, After: &item})
+			results = append(results, OperationResult{Op: operation.Op, ID: operation.ID, Item: &item})
+		case OpDelete:
+			oldItem, found := items[operation.ID]
+			if !found {
+				return response{err: &BatchError{Index: i, Err: errors.Wrapf(ErrNotFound, "item '%s'", operation.ID)}}
+			}
+			if operation.Item != nil {
+				if err := checkVersion(oldItem, operation.Item.Version); err != nil {
+					return response{err: &BatchError{Index: i, Err: err}}
+				}
+			}
+			delete(items, operation.ID)
+			trashed = append(trashed, oldItem)
+			applied = append(applied, Change{ID: operation.ID, Before: &oldItem})
+			results = append(results, OperationResult{Op: operation.Op, ID: operation.ID})
+		default:
+			return response{err: &BatchError{Index: i, Err: errors.Errorf("unknown operation '%s'", operation.Op)}}
+		}
+	}
+
+	if err := s.trashItems(trashed...); err != nil {
+		return response{
+			err: errors.Wrap(err, "trash items"),
+		}
+	}
+
+	s.items = items
+
+	if err := s.saveItems(); err != nil {
+		return response{
+			err: errors.Wrap(err, "save items"),
+		}
+	}
+
+	for _, change := range applied {
+		var messages []string
+		switch {
+		case change.Before == nil:
+			messages = []string{"created"}
+		case change.After == nil:
+			messages = []string{"deleted"}
+		default:
+			messages = changes(*change.Before, *change.After)
+		}
+		if err := s.recordActivity(change.ID, messages...); err != nil {
+			return response{
+				err: errors.Wrap(err, "record activity"),
+			}
+		}
+	}
+
+	if err := s.recordMutation("batch", applied...); err != nil {
+		return response{
+			err: errors.Wrap(err, "record mutation"),
+		}
+	}
+
+	return response{
+		results: results,
+	}
+}
+
+// Batch applies the operations in order as a single change, either all of them are applied or none are.
+func (s *Store) Batch(operations []Operation) ([]OperationResult, error) {
+	responseChan := make(chan response, 1)
+	req := request{
+		action:       "batch",
+		responseChan: responseChan,
+		operations:   operations,
+	}
+	res := s.send(req)
+
+	return res.results, res.err
+}
@@ -0,0 +1,186 @@
+package store
+
+import (
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_Batch_AppliesAllOperations(t *testing.T) {
+	t.Cleanup(setupTest())
+
+	data := map[ItemID]Item{
+		"id1": {ID: "id1", Name: "name1", Desc: "desc1", Status: "status1"},
+		"id2": {ID: "id2", Name: "name2", Desc: "desc2", Status: "status2"},
+	}
+	store := newTestStore()
+	store.items = data
+
+	results, err := store.Batch([]Operation{
+		{Op: OpCreate, Item: &Item{Name: "name3", Desc: "desc3", Status: "status3"}},
+		{Op: OpUpdate, ID: "id1", Item: &Item{Name: "name4", Desc: "desc4", Status: "status4"}},
+		{Op: OpDelete, ID: "id2"},
+	})
+
+	assert.NoError(t, err)
+	assert.Len(t, results, 3)
+	assert.Equal(t, "name3", results[0].Item.Name)
+	assert.Equal(t, &Item{ID: "id1", Name: "name4", Desc: "desc4", Status: "status4", Version: 1}, results[1].Item)
+	assert.Equal(t, OperationResult{Op: OpDelete, ID: "id2"}, results[2])
+
+	items, err := store.ReadAll()
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []Item{*results[0].Item, *results[1].Item}, items)
+	trash, err := store.ReadTrash()
+	assert.NoError(t, err)
+	assert.Len(t, trash, 1)
+}
+
+func Test_Batch_AppliesNothingWhenAnOperationFails(t *testing.T) {
+	t.Cleanup(setupTest())
+
+	data := map[ItemID]Item{
+		"id1": {ID: "id1", Name: "name1", Desc: "desc1", Status: "status1"},
+	}
+	store := newTestStore()
+	store.items = data
+
+	_, err := store.Batch([]Operation{
+		{Op: OpCreate, Item: &Item{Name: "name2", Desc: "desc2", Status: "status2"}},
+		{Op: OpDelete, ID: "id1"},
+		{Op: OpUpdate, ID: "missing", Item: &Item{Name: "name3"}},
+	})
+
+	var batchError *BatchError
+	assert.True(t, errors.As(err, &batchError))
+	assert.Equal(t, 2, batchError.Index)
+	assert.True(t, errors.Is(err, ErrNotFound))
+
+	items, err := store.ReadAll()
+	assert.NoError(t, err)
+	assert.Equal(t, []Item{data["id1"]}, items)
+}
+
+func Test_Batch_CanBeUndoneAtOnce(t *testing.T) {
+	t.Cleanup(setupTest())
+
+	store := newTestStore()
+
+	_, err := store.Batch([]Operation{
+		{Op: OpCreate, Item: &Item{Name: "name1"}},
+		{Op: OpCreate, Item: &Item{Name: "name2"}},
+	})
+	assert.NoError(t, err)
+
+	err = store.Undo()
+
+	assert.NoError(t, err)
+	items, err := store.ReadAll()
+	assert.NoError(t, err)
+	assert.Empty(t, items)
+}
+
+func Test_Batch_ReturnsBackendFailuresAsTheyAre(t *testing.T) {
+	dir := t.TempDir()
+	store := NewStore(WithDir(dir), WithJanitorInterval(0))
+	t.Cleanup(store.Close)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, TrashFilename), []byte("{"), 0o600))
+
+	_, err := store.Batch([]Operation{
+		{Op: OpCreate, ID: "id1", Item: &Item{Name: "name1"}},
+	})
+
+	var batchError *BatchError
+	assert.Error(t, err)
+	assert.False(t, errors.As(err, &batchError))
+}
+
+func Test_Batch_LeavesFilesAsTheyWereWhenSavingFails(t *testing.T) {
+	dir := t.TempDir()
+	store := NewStore(WithDir(dir), WithJanitorInterval(0))
+	t.Cleanup(store.Close)
+	item1, err := store.Create(Item{Name: "name1"})
+	require.NoError(t, err)
+	item2, err := store.Create(Item{Name: "name2"})
+	require.NoError(t, err)
+
+	writeFile = func(name string, data []byte, perm os.FileMode) error {
+		if filepath.Base(name) == ItemsFilename+commitSuffix {
+			return errors.New("disk full")
+		}
+		return os.WriteFile(name, data, perm)
+	}
+	_, err = store.Batch([]Operation{
+		{Op: OpDelete, ID: ItemID(item1.ID)},
+		{Op: OpUpdate, ID: ItemID(item2.ID), Item: &Item{Name: "name3"}},
+	})
+	writeFile = os.WriteFile
+
+	assert.ErrorContains(t, err, "disk full")
+	items, err := store.ReadAll()
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []Item{item1, item2}, items)
+	trash, err := store.ReadTrash()
+	assert.NoError(t, err)
+	assert.Empty(t, trash)
+	history, err := store.ReadHistory(ItemID(item1.ID))
+	assert.NoError(t, err)
+	assert.Len(t, history, 1)
+	assert.ErrorIs(t, store.Redo(), ErrNothingToRedo)
+	require.NoError(t, store.Undo())
+	items, err = store.ReadAll()
+	assert.NoError(t, err)
+	assert.Equal(t, []Item{item1}, items)
+}
+
+func Test_Batch_IsFinishedByTheNextRequestWhenReplacingFilesFails(t *testing.T) {
+	dir := t.TempDir()
+	store := NewStore(WithDir(dir), WithJanitorInterval(0))
+	t.Cleanup(store.Close)
+	item1, err := store.Create(Item{Name: "name1"})
+	require.NoError(t, err)
+
+	renames := 0
+	renameFile = func(oldpath, newpath string) error {
+		if renames++; renames > 1 {
+			return errors.New("disk full")
+		}
+		return os.Rename(oldpath, newpath)
+	}
+	_, err = store.Batch([]Operation{
+		{Op: OpDelete, ID: ItemID(item1.ID)},
+		{Op: OpCreate, Item: &Item{Name: "name2"}},
+	})
+	renameFile = os.Rename
+
+	assert.ErrorContains(t, err, "disk full")
+	assert.FileExists(t, filepath.Join(dir, CommitFilename))
+	items, err := store.ReadAll()
+	assert.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Equal(t, "name2", items[0].Name)
+	trash, err := store.ReadTrash()
+	assert.NoError(t, err)
+	require.Len(t, trash, 1)
+	assert.Equal(t, item1, trash[0].Item)
+	assert.NoFileExists(t, filepath.Join(dir, CommitFilename))
+}
+
+func Test_Batch_DeletesOnlyTheVersionOfTheItem(t *testing.T) {
+	t.Cleanup(setupTest())
+
+	store := newTestStore()
+	item, err := store.Create(Item{Name: "name1"})
+	require.NoError(t, err)
+
+	_, err = store.Batch([]Operation{{Op: OpDelete, ID: ItemID(item.ID), Item: &Item{Version: item.Version + 1}}})
+
+	assert.ErrorIs(t, err, ErrVersionConflict)
+	_, err = store.Batch([]Operation{{Op: OpDelete, ID: ItemID(item.ID), Item: &Item{Version: item.Version}}})
+	assert.NoError(t, err)
+	_, err = store.Read(ItemID(item.ID))
+	assert.ErrorIs(t, err, ErrNotFound)
+}
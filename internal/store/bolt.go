@@ -0,0 +1,313 @@
+package store
+
+import (
+	"encoding/json"
+	"github.com/pkg/errors"
+	"maps"
+	"reflect"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	BoltFilename = "items.bolt"
+	// boltLockTimeout bounds the wait for another process that holds the database open
+	boltLockTimeout = 5 * time.Second
+)
+
+var (
+	itemsBucket     = []byte("items")
+	documentsBucket = []byte("documents")
+	// usersBucket holds a bucket per assignee with the items assigned to them
+	usersBucket = []byte("users")
+)
+
+// WithBolt makes the store keep its items in an embedded bbolt database in its directory instead of JSON
+// files. Items are keys of their own, the items of every assignee are also kept in a bucket of that user so
+// reading them does not read every item, and every request is a single transaction.
+func WithBolt() Option {
+	return func(s *Store) {
+		s.backendName = BackendBolt
+	}
+}
+
+var (
+	_ backend       = (*boltBackend)(nil)
+	_ assigneeIndex = (*boltBackend)(nil)
+)
+
+// boltBackend opens the database for every request and runs the request in a single read-write transaction,
+// which holds the lock of the database file so that stores in other processes wait for it. A request that
+// fails is rolled back as a whole. It keeps the items as last saved and reads them again only when the
+// sequence of the items bucket moved since.
+type boltBackend struct {
+	path     string
+	db       *bolt.DB
+	tx       *bolt.Tx
+	saved    map[ItemID]Item
+	sequence uint64
+}
+
+func (b *boltBackend) begin() error {
+	db, err := bolt.Open(b.path, 0o600, &bolt.Options{Timeout: boltLockTimeout})
+	if err != nil {
+		return errors.Wrapf(err, "open %s", b.path)
+	}
+
+	tx, err := db.Begin(true)
+	if err != nil {
+		_ = db.Close()
+		return errors.Wrap(err, "begin")
+	}
+
+	for _, name := range [][]byte{itemsBucket, documentsBucket, usersBucket} {
+		if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+			_ = tx.Rollback()
+			_ = db.Close()
+			return errors.Wrapf(err, "create bucket %s", name)
+		}
+	}
+
+	b.db, b.tx = db, tx
+
+	return nil
+}
+
+func (b *boltBackend) end(commit bool) error {
+	if b.tx == nil {
+		return nil
+	}
+
+	db, tx := b.db, b.tx
+	b.db, b.tx = nil, nil
+	defer db.Close()
+
+	if commit {
+		if err := tx.Commit(); err != nil {
+			b.saved = nil
+			return errors.Wrap(err, "commit")
+		}
+		return nil
+	}
+
+	// The items saved by the request are gone again, read them anew with the next request
+	b.saved = nil
+	return errors.Wrap(tx.Rollback(), "rollback")
+}
+
+// conn returns the transaction of the current request.
+func (b *boltBackend) conn() (*bolt.Tx, error) {
+	if b.tx == nil {
+		return nil, errors.Errorf("%s is only open during a request", b.path)
+	}
+
+	return b.tx, nil
+}
+
+// refresh reads the items again when another store saved them since they were last read.
+func (b *boltBackend) refresh(tx *bolt.Tx) error {
+	bucket := tx.Bucket(itemsBucket)
+	if b.saved != nil && bucket.Sequence() == b.sequence {
+		return nil
+	}
+
+	saved := make(map[ItemID]Item)
+	if err := bucket.ForEach(func(key, value []byte) error {
+		var item Item
+		if err := json.Unmarshal(value, &item); err != nil {
+			return errors.Wrapf(err, "decode item '%s'", key)
+		}
+		saved[ItemID(key)] = item
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	b.saved = saved
+	b.sequence = bucket.Sequence()
+
+	return nil
+}
+
+func (b *boltBackend) loadItems(items map[ItemID]Item) error {
+	tx, err := b.conn()
+	if err != nil {
+		return err
+	}
+
+	// Like a missing items file, a new database starts with the items the store holds
+	if tx.Bucket(itemsBucket).Sequence() == 0 {
+		return b.saveItems(items)
+	}
+
+	if err := b.refresh(tx); err != nil {
+		return errors.Wrap(err, "load items")
+	}
+
+	clear(items)
+	maps.Copy(items, b.saved)
+
+	return nil
+}
+
+func (b *boltBackend) saveItems(items map[ItemID]Item) error {
+	tx, err := b.conn()
+	if err != nil {
+		return err
+	}
+
+	if err := b.refresh(tx); err != nil {
+		return err
+	}
+
+	bucket := tx.Bucket(itemsBucket)
+	for id, item := range items {
+		saved, found := b.saved[id]
+		if found && reflect.DeepEqual(saved, item) {
+			continue
+		}
+
+		data, err := json.Marshal(item)
+		if err != nil {
+			return errors.Wrapf(err, "encode item '%s'", id)
+		}
+		if err := bucket.Put([]byte(id), data); err != nil {
+			return errors.Wrapf(err, "put item '%s'", id)
+		}
+
+		if found {
+			if err := unindex(tx, id, saved); err != nil {
+				return err
+			}
+		}
+		if err := index(tx, id, item, data); err != nil {
+			return err
+		}
+	}
+
+	for id, saved := range b.saved {
+		if _, found := items[id]; found {
+			continue
+		}
+		if err := bucket.Delete([]byte(id)); err != nil {
+			return errors.Wrapf(err, "delete item '%s'", id)
+		}
+		if err := unindex(tx, id, saved); err != nil {
+			return err
+		}
+	}
+
+	sequence, err := bucket.NextSequence()
+	if err != nil {
+		return errors.Wrap(err, "advance sequence")
+	}
+
+	b.saved = maps.Clone(items)
+	b.sequence = sequence
+
+	return nil
+}
+
+// index adds the item to the bucket of its assignee, unassigned items are left out.
+func index(tx *bolt.Tx, id ItemID, item Item, data []byte) error {
+	if item.Assignee == "" {
+		return nil
+	}
+
+	bucket, err := tx.Bucket(usersBucket).CreateBucketIfNotExists([]byte(item.Assignee))
+	if err != nil {
+		return errors.Wrapf(err, "create bucket of user '%s'", item.Assignee)
+	}
+
+	return errors.Wrapf(bucket.Put([]byte(id), data), "put item '%s' of user '%s'", id, item.Assignee)
+}
+
+// unindex removes the item from the bucket of its assignee, dropping the bucket once it is empty.
+func unindex(tx *bolt.Tx, id ItemID, item Item) error {
+	users := tx.Bucket(usersBucket)
+	bucket := users.Bucket([]byte(item.Assignee))
+	if item.Assignee == "" || bucket == nil {
+		return nil
+	}
+
+	if err := bucket.Delete([]byte(id)); err != nil {
+		return errors.Wrapf(err, "delete item '%s' of user '%s'", id, item.Assignee)
+	}
+	if key, _ := bucket.Cursor().First(); key != nil {
+		return nil
+	}
+
+	return errors.Wrapf(users.DeleteBucket([]byte(item.Assignee)), "delete bucket of user '%s'", item.Assignee)
+}
+
+// assigned reads the items from the bucket of the assignee. A database that was never saved has no buckets
+// yet, it starts with the items the store holds.
+func (b *boltBackend) assigned(assignee string) ([]Item, bool, error) {
+	tx, err := b.conn()
+	if err != nil {
+		return nil, false, err
+	}
+
+	if tx.Bucket(itemsBucket).Sequence() == 0 {
+		return nil, false, nil
+	}
+
+	items := make([]Item, 0)
+	bucket := tx.Bucket(usersBucket).Bucket([]byte(assignee))
+	if bucket == nil {
+		return items, true, nil
+	}
+	err = bucket.ForEach(func(key, value []byte) error {
+		var item Item
+		if err := json.Unmarshal(value, &item); err != nil {
+			return errors.Wrapf(err, "decode item '%s'", key)
+		}
+		items = append(items, item)
+		return nil
+	})
+
+	return items, true, errors.Wrapf(err, "read items of user '%s'", assignee)
+}
+
+func (b *boltBackend) load(name string, v any) error {
+	tx, err := b.conn()
+	if err != nil {
+		return err
+	}
+
+	data := tx.Bucket(documentsBucket).Get([]byte(name))
+	if data == nil {
+		return nil
+	}
+
+	return errors.Wrapf(decode(data, v), "decode %s", name)
+}
+
+func (b *boltBackend) save(name string, v any) error {
+	tx, err := b.conn()
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return errors.Wrapf(err, "encode %s", name)
+	}
+
+	return errors.Wrapf(tx.Bucket(documentsBucket).Put([]byte(name), data), "put %s", name)
+}
+
+func (b *boltBackend) exists(name string) (bool, error) {
+	tx, err := b.conn()
+	if err != nil {
+		return false, err
+	}
+
+	return tx.Bucket(documentsBucket).Get([]byte(name)) != nil, nil
+}
+
+func (b *boltBackend) close() error {
+	b.saved = nil
+	return nil
+}
@@ -0,0 +1,127 @@
+package store
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func newBoltStore(t *testing.T, dir string) *Store {
+	t.Helper()
+
+	store := NewStore(WithDir(dir), WithJanitorInterval(0), WithBolt())
+	t.Cleanup(store.Close)
+
+	return store
+}
+
+func Test_Bolt_KeepsItemsAcrossRestarts(t *testing.T) {
+	dir := t.TempDir()
+	store := NewStore(WithDir(dir), WithJanitorInterval(0), WithBolt())
+	item, err := store.Create(Item{Name: "name1", Desc: "desc1", Status: StatusCompleted})
+	require.NoError(t, err)
+	_, err = store.AddComment(ItemID(item.ID), Comment{Author: "user1", Text: "text1"})
+	require.NoError(t, err)
+	store.Close()
+
+	reopened := newBoltStore(t, dir)
+	items, err := reopened.ReadAll()
+
+	assert.NoError(t, err)
+	assert.Equal(t, []Item{item}, items)
+	comments, err := reopened.ReadComments(ItemID(item.ID))
+	assert.NoError(t, err)
+	assert.Len(t, comments, 1)
+}
+
+func Test_Bolt_ReadsChangesOfOtherStores(t *testing.T) {
+	dir := t.TempDir()
+	store1 := newBoltStore(t, dir)
+	store2 := newBoltStore(t, dir)
+	item, err := store1.Create(Item{Name: "name1", Status: StatusNotStarted})
+	require.NoError(t, err)
+	_, err = store2.ReadAll()
+	require.NoError(t, err)
+
+	updatedItem, err := store1.Update(ItemID(item.ID), Item{Name: "name2", Status: StatusStarted})
+	require.NoError(t, err)
+	actualItem, err := store2.Read(ItemID(item.ID))
+
+	assert.NoError(t, err)
+	assert.Equal(t, updatedItem, actualItem)
+}
+
+func Test_Bolt_KeepsItemsOfEveryAssigneeInTheirBucket(t *testing.T) {
+	store := newBoltStore(t, t.TempDir())
+	item1, err := store.Create(Item{Name: "name1", Status: StatusNotStarted, Assignee: "user1"})
+	require.NoError(t, err)
+	item2, err := store.Create(Item{Name: "name2", Status: StatusNotStarted, Assignee: "user1"})
+	require.NoError(t, err)
+
+	item1, err = store.Update(ItemID(item1.ID), Item{Name: "name1", Status: StatusStarted, Assignee: "user2"})
+	require.NoError(t, err)
+	require.NoError(t, store.Delete(ItemID(item2.ID)))
+
+	backend := store.backend.(*boltBackend)
+	require.NoError(t, backend.begin())
+	users := make(map[string]int)
+	assert.NoError(t, backend.tx.Bucket(usersBucket).ForEachBucket(func(user []byte) error {
+		users[string(user)] = backend.tx.Bucket(usersBucket).Bucket(user).Stats().KeyN
+		return nil
+	}))
+	require.NoError(t, backend.end(false))
+	assert.Equal(t, map[string]int{"user2": 1}, users)
+	assigned, err := store.ReadAssigned("user2")
+	assert.NoError(t, err)
+	assert.Equal(t, []Item{item1}, assigned)
+	assigned, err = store.ReadAssigned("user1")
+	assert.NoError(t, err)
+	assert.Empty(t, assigned)
+}
+
+func Test_Bolt_RollsBackFailedRequests(t *testing.T) {
+	backend := &boltBackend{path: filepath.Join(t.TempDir(), BoltFilename)}
+	require.NoError(t, backend.begin())
+	require.NoError(t, backend.saveItems(map[ItemID]Item{"id1": {ID: "id1", Name: "name1"}}))
+	require.NoError(t, backend.end(true))
+
+	require.NoError(t, backend.begin())
+	require.NoError(t, backend.saveItems(map[ItemID]Item{"id2": {ID: "id2", Name: "name2"}}))
+	require.NoError(t, backend.save(TrashFilename, map[ItemID]TrashedItem{"id1": {Item: Item{ID: "id1", Name: "name1"}}}))
+	require.NoError(t, backend.end(false))
+
+	require.NoError(t, backend.begin())
+	t.Cleanup(func() { _ = backend.end(false) })
+	items := make(map[ItemID]Item)
+	assert.NoError(t, backend.loadItems(items))
+	assert.Equal(t, map[ItemID]Item{"id1": {ID: "id1", Name: "name1"}}, items)
+	exists, err := backend.exists(TrashFilename)
+	assert.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func Test_Bolt_KeepsItemsCreatedConcurrentlyByOtherStores(t *testing.T) {
+	dir := t.TempDir()
+	stores := []*Store{newBoltStore(t, dir), newBoltStore(t, dir)}
+
+	var wg sync.WaitGroup
+	for _, store := range stores {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 10 {
+				_, err := store.Create(Item{Name: "name1"})
+				assert.NoError(t, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, store := range stores {
+		items, err := store.ReadAll()
+		assert.NoError(t, err)
+		assert.Len(t, items, 20)
+	}
+}
@@ -0,0 +1,297 @@
+package store
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/scrypt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// PassphraseEnv names the environment variable the commands read the passphrase of the store from
+	PassphraseEnv = "TODO_PASSPHRASE"
+	// NewPassphraseEnv names the environment variable the commands read the passphrase to rotate to from
+	NewPassphraseEnv = "TODO_NEW_PASSPHRASE"
+
+	encryptionAES256GCM = "aes-256-gcm"
+	kdfScrypt           = "scrypt"
+	keySize             = 32
+	saltSize            = 16
+	// scrypt parameters recommended for interactive logins
+	scryptN = 32768
+	scryptR = 8
+	scryptP = 1
+)
+
+var (
+	ErrWrongKey     = errors.New("wrong encryption key or damaged file")
+	ErrEncrypted    = errors.New("file is encrypted but no key is set")
+	ErrNotEncrypted = errors.New("file is not encrypted, rotate the key to encrypt it")
+)
+
+// EncryptionKey is what the files of the store are encrypted with, either a 32 byte AES-256 key or a
+// passphrase the key is derived from with scrypt.
+type EncryptionKey struct {
+	Key        []byte
+	Passphrase string
+}
+
+// WithEncryption makes the store encrypt its files with AES-256-GCM. A nil key leaves them unencrypted. Only
+// the file backend encrypts, the databases of the other backends are left to be encrypted where they live.
+func WithEncryption(key *EncryptionKey) Option {
+	return func(s *Store) {
+		s.encryptionKey = key
+	}
+}
+
+// ReadEncryptionKey reads the hex encoded key in keyFile or, without a key file, takes the passphrase in the
+// environment variable env. It returns nil when neither is set.
+func ReadEncryptionKey(keyFile, env string) (*EncryptionKey, error) {
+	if keyFile == "" {
+		if passphrase := os.Getenv(env); passphrase != "" {
+			return &EncryptionKey{Passphrase: passphrase}, nil
+		}
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "read key file")
+	}
+
+	key, err := hex.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, errors.Wrapf(err, "decode key file %s", keyFile)
+	}
+	if len(key) != keySize {
+		return nil, errors.Errorf("key file %s holds %d bytes, expected %d", keyFile, len(key), keySize)
+	}
+
+	return &EncryptionKey{Key: key}, nil
+}
+
+// encryptedFile is the content of an encrypted file. The salt is kept with every file, so that a passphrase
+// derives the key on its own.
+type encryptedFile struct {
+	Encryption string `json:"encryption"`
+	KDF        string `json:"kdf,omitempty"`
+	Salt       []byte `json:"salt,omitempty"`
+	Nonce      []byte `json:"nonce"`
+	Data       []byte `json:"data"`
+}
+
+// fileCipher encrypts and decrypts the files of the file backend, a nil fileCipher leaves them as they are.
+// Keys derived from the passphrase are kept per salt, as deriving takes a while.
+type fileCipher struct {
+	key     EncryptionKey
+	salt    []byte
+	derived map[string][]byte
+}
+
+func newFileCipher(key *EncryptionKey) *fileCipher {
+	if key == nil {
+		return nil
+	}
+
+	return &fileCipher{key: *key, derived: make(map[string][]byte)}
+}
+
+func (c *fileCipher) aead(kdf string, salt []byte) (cipher.AEAD, error) {
+	var key []byte
+	switch {
+	case kdf == kdfScrypt && c.key.Passphrase != "":
+		key = c.derived[string(salt)]
+		if key == nil {
+			var err error
+			if key, err = scrypt.Key([]byte(c.key.Passphrase), salt, scryptN, scryptR, scryptP, keySize); err != nil {
+				return nil, errors.Wrap(err, "derive key")
+			}
+			c.derived[string(salt)] = key
+		}
+	case kdf == kdfScrypt:
+		return nil, errors.New("file is encrypted with a passphrase, not a key")
+	case kdf == "" && c.key.Passphrase != "":
+		return nil, errors.New("file is encrypted with a key, not a passphrase")
+	case kdf == "":
+		key = c.key.Key
+	default:
+		return nil, errors.Errorf("unknown key derivation '%s'", kdf)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.Wrap(err, "create cipher")
+	}
+
+	return cipher.NewGCM(block)
+}
+
+func (c *fileCipher) seal(data []byte) ([]byte, error) {
+	if c == nil {
+		return data, nil
+	}
+
+	file := encryptedFile{Encryption: encryptionAES256GCM}
+	if c.key.Passphrase != "" {
+		if c.salt == nil {
+			c.salt = make([]byte, saltSize)
+			if _, err := rand.Read(c.salt); err != nil {
+				return nil, errors.Wrap(err, "generate salt")
+			}
+		}
+		file.KDF, file.Salt = kdfScrypt, c.salt
+	}
+
+	aead, err := c.aead(file.KDF, file.Salt)
+	if err != nil {
+		return nil, err
+	}
+
+	file.Nonce = make([]byte, aead.NonceSize())
+	if _, err := rand.Read(file.Nonce); err != nil {
+		return nil, errors.Wrap(err, "generate nonce")
+	}
+	file.Data = aead.Seal(nil, file.Nonce, data, nil)
+
+	return json.Marshal(file)
+}
+
+func (c *fileCipher) open(data []byte) ([]byte, error) {
+	// The collections are maps and lists of objects, so an encryption name marks an encrypted file
+	var file encryptedFile
+	encrypted := json.Unmarshal(data, &file) == nil && file.Encryption != ""
+
+	switch {
+	case c == nil && encrypted:
+		return nil, ErrEncrypted
+	case c == nil:
+		return data, nil
+	case !encrypted:
+		return nil, ErrNotEncrypted
+	case file.Encryption != encryptionAES256GCM:
+		return nil, errors.Errorf("unknown encryption '%s'", file.Encryption)
+	}
+
+	aead, err := c.aead(file.KDF, file.Salt)
+	if err != nil {
+		return nil, err
+	}
+	if len(file.Nonce) != aead.NonceSize() {
+		return nil, ErrWrongKey
+	}
+
+	plain, err := aead.Open(nil, file.Nonce, file.Data, nil)
+	if err != nil {
+		return nil, ErrWrongKey
+	}
+
+	return plain, nil
+}
+
+// storeFilenames are the files the file backend keeps the collections of the store in.
+var storeFilenames = []string{ItemsFilename, CommentsFilename, HistoryFilename, MutationsFilename, TrashFilename,
+	ArchiveFilename, WebhooksFilename, DeliveriesFilename, PendingFilename, IdempotencyFilename}
+
+// RotationFilename is the marker a key rotation leaves while it replaces the files of the store, listing the
+// files whose rotated copy is complete.
+const RotationFilename = "rotation.json"
+
+// rotateKey encrypts the files of the store and the backups of migrated items files with key, or decrypts
+// them when key is nil. Every file is read and written to a rotated copy before the first is replaced, so a
+// wrong current key changes nothing. Once the copies are complete a marker is written, and when replacing
+// the files fails partway the next request, in this process or the next to open the store, finishes the
+// rotation from the marker. The store must then be opened with the new key.
+func (b *fileBackend) rotateKey(key *EncryptionKey) error {
+	names := append([]string{}, storeFilenames...)
+	backups, err := filepath.Glob(b.path(ItemsFilename + ".v*"))
+	if err != nil {
+		return errors.Wrap(err, "find backups")
+	}
+	for _, backup := range backups {
+		names = append(names, filepath.Base(backup))
+	}
+
+	contents := make(map[string][]byte)
+	for _, name := range names {
+		data, err := b.read(name)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		contents[name] = data
+	}
+
+	next := newFileCipher(key)
+	var rotated []string
+	for _, name := range names {
+		data, found := contents[name]
+		if !found {
+			continue
+		}
+		sealed, err := next.seal(data)
+		if err != nil {
+			return errors.Wrapf(err, "encrypt %s", name)
+		}
+		if err := writeFile(b.path(name+rotateSuffix), sealed, 0o600); err != nil {
+			return errors.Wrapf(err, "write %s", name)
+		}
+		rotated = append(rotated, name)
+	}
+
+	if err := b.writeReplacement(RotationFilename, rotated); err != nil {
+		return errors.Wrap(err, "write rotation")
+	}
+
+	// From here on the rotation is finished rather than undone
+	b.cipher = next
+
+	return b.finishRotation()
+}
+
+const rotateSuffix = ".rotate"
+
+// finishRotation replaces the files listed in the marker of a key rotation by their rotated copies and
+// removes the marker.
+func (b *fileBackend) finishRotation() error {
+	return errors.Wrap(b.finishReplacement(RotationFilename, rotateSuffix), "rotation")
+}
+
+func (s *Store) rotateKey(key *EncryptionKey) response {
+	backend, ok := s.backend.(*fileBackend)
+	if !ok {
+		return response{
+			err: errors.Errorf("the %s backend does not encrypt", s.backendName),
+		}
+	}
+
+	if err := backend.rotateKey(key); err != nil {
+		return response{
+			err: errors.Wrap(err, "rotate key"),
+		}
+	}
+	s.encryptionKey = key
+
+	return response{}
+}
+
+// RotateKey encrypts the files of the store with key from now on, or decrypts them when key is nil.
+func (s *Store) RotateKey(key *EncryptionKey) error {
+	responseChan := make(chan response, 1)
+	req := request{
+		action:        "rotateKey",
+		responseChan:  responseChan,
+		encryptionKey: key,
+	}
+	res := s.send(req)
+
+	return res.err
+}
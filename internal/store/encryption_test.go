@@ -0,0 +1,171 @@
+package store
+
+import (
+	"bytes"
+	"encoding/hex"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func newEncryptedStore(t *testing.T, dir string, key *EncryptionKey) *Store {
+	t.Helper()
+
+	store := NewStore(WithDir(dir), WithJanitorInterval(0), WithEncryption(key))
+	t.Cleanup(store.Close)
+
+	return store
+}
+
+func testKey(b byte) *EncryptionKey {
+	return &EncryptionKey{Key: bytes.Repeat([]byte{b}, keySize)}
+}
+
+func Test_Encryption_KeepsNoPlainTextInFiles(t *testing.T) {
+	for _, key := range []*EncryptionKey{testKey(1), {Passphrase: "passphrase1"}} {
+		dir := t.TempDir()
+		store := newEncryptedStore(t, dir, key)
+		item, err := store.Create(Item{Name: "customer1", Status: StatusNotStarted})
+		require.NoError(t, err)
+		_, err = store.AddComment(ItemID(item.ID), Comment{Author: "user1", Text: "customer1"})
+		require.NoError(t, err)
+
+		for _, filename := range []string{ItemsFilename, CommentsFilename} {
+			data, err := os.ReadFile(filepath.Join(dir, filename))
+			require.NoError(t, err)
+			assert.NotContains(t, string(data), "customer1")
+		}
+		items, err := newEncryptedStore(t, dir, key).ReadAll()
+		assert.NoError(t, err)
+		assert.Equal(t, []Item{item}, items)
+	}
+}
+
+func Test_Encryption_FailsWithWrongKey(t *testing.T) {
+	dir := t.TempDir()
+	_, err := newEncryptedStore(t, dir, &EncryptionKey{Passphrase: "passphrase1"}).Create(Item{Name: "name1"})
+	require.NoError(t, err)
+
+	_, err = newEncryptedStore(t, dir, &EncryptionKey{Passphrase: "passphrase2"}).ReadAll()
+	assert.ErrorIs(t, err, ErrWrongKey)
+	_, err = newEncryptedStore(t, dir, testKey(1)).ReadAll()
+	assert.ErrorContains(t, err, "encrypted with a passphrase")
+	_, err = newEncryptedStore(t, dir, nil).ReadAll()
+	assert.ErrorIs(t, err, ErrEncrypted)
+}
+
+func Test_Encryption_FailsOnPlainFiles(t *testing.T) {
+	dir := t.TempDir()
+	_, err := newEncryptedStore(t, dir, nil).Create(Item{Name: "name1"})
+	require.NoError(t, err)
+
+	_, err = newEncryptedStore(t, dir, testKey(1)).ReadAll()
+
+	assert.ErrorIs(t, err, ErrNotEncrypted)
+}
+
+func Test_Encryption_FailsOnOtherBackends(t *testing.T) {
+	store := NewStore(WithDir(t.TempDir()), WithJanitorInterval(0), WithSQLite(), WithEncryption(testKey(1)))
+	t.Cleanup(store.Close)
+
+	_, err := store.ReadAll()
+
+	assert.ErrorContains(t, err, "does not encrypt")
+}
+
+func Test_RotateKey_EncryptsWithNewKey(t *testing.T) {
+	dir := t.TempDir()
+	store := newEncryptedStore(t, dir, nil)
+	item, err := store.Create(Item{Name: "name1", Status: StatusNotStarted})
+	require.NoError(t, err)
+	_, err = store.AddComment(ItemID(item.ID), Comment{Author: "user1", Text: "text1"})
+	require.NoError(t, err)
+
+	require.NoError(t, store.RotateKey(testKey(1)))
+	require.NoError(t, store.RotateKey(&EncryptionKey{Passphrase: "passphrase1"}))
+
+	_, err = newEncryptedStore(t, dir, testKey(1)).ReadAll()
+	assert.Error(t, err)
+	reopened := newEncryptedStore(t, dir, &EncryptionKey{Passphrase: "passphrase1"})
+	items, err := reopened.ReadAll()
+	assert.NoError(t, err)
+	assert.Equal(t, []Item{item}, items)
+	comments, err := reopened.ReadComments(ItemID(item.ID))
+	assert.NoError(t, err)
+	assert.Len(t, comments, 1)
+}
+
+func Test_RotateKey_ChangesNothingWithWrongKey(t *testing.T) {
+	dir := t.TempDir()
+	_, err := newEncryptedStore(t, dir, testKey(1)).Create(Item{Name: "name1"})
+	require.NoError(t, err)
+	before, err := os.ReadFile(filepath.Join(dir, ItemsFilename))
+	require.NoError(t, err)
+
+	err = newEncryptedStore(t, dir, testKey(2)).RotateKey(testKey(3))
+
+	assert.ErrorIs(t, err, ErrWrongKey)
+	after, err := os.ReadFile(filepath.Join(dir, ItemsFilename))
+	require.NoError(t, err)
+	assert.Equal(t, before, after)
+}
+
+func Test_RotateKey_FinishesInterruptedRotationOnOpen(t *testing.T) {
+	dir := t.TempDir()
+	store := newEncryptedStore(t, dir, testKey(1))
+	item, err := store.Create(Item{Name: "name1", Status: StatusNotStarted})
+	require.NoError(t, err)
+	_, err = store.AddComment(ItemID(item.ID), Comment{Author: "user1", Text: "text1"})
+	require.NoError(t, err)
+
+	renames := 0
+	renameFile = func(oldpath, newpath string) error {
+		if renames++; renames > 1 {
+			return errors.New("disk full")
+		}
+		return os.Rename(oldpath, newpath)
+	}
+	err = store.RotateKey(testKey(2))
+	renameFile = os.Rename
+	store.Close()
+
+	assert.ErrorContains(t, err, "disk full")
+	assert.FileExists(t, filepath.Join(dir, RotationFilename))
+	reopened := newEncryptedStore(t, dir, testKey(2))
+	items, err := reopened.ReadAll()
+	assert.NoError(t, err)
+	assert.Equal(t, []Item{item}, items)
+	comments, err := reopened.ReadComments(ItemID(item.ID))
+	assert.NoError(t, err)
+	assert.Len(t, comments, 1)
+	assert.NoFileExists(t, filepath.Join(dir, RotationFilename))
+}
+
+func Test_ReadEncryptionKey_PrefersKeyFile(t *testing.T) {
+	keyFile := filepath.Join(t.TempDir(), "key")
+	require.NoError(t, os.WriteFile(keyFile, []byte(hex.EncodeToString(testKey(1).Key)+"\n"), 0o600))
+	t.Setenv(PassphraseEnv, "passphrase1")
+
+	key, err := ReadEncryptionKey(keyFile, PassphraseEnv)
+	assert.NoError(t, err)
+	assert.Equal(t, testKey(1), key)
+	key, err = ReadEncryptionKey("", PassphraseEnv)
+	assert.NoError(t, err)
+	assert.Equal(t, &EncryptionKey{Passphrase: "passphrase1"}, key)
+	t.Setenv(PassphraseEnv, "")
+	key, err = ReadEncryptionKey("", PassphraseEnv)
+	assert.NoError(t, err)
+	assert.Nil(t, key)
+}
+
+func Test_ReadEncryptionKey_RejectsShortKeys(t *testing.T) {
+	keyFile := filepath.Join(t.TempDir(), "key")
+	require.NoError(t, os.WriteFile(keyFile, []byte("abcd"), 0o600))
+
+	_, err := ReadEncryptionKey(keyFile, PassphraseEnv)
+
+	assert.ErrorContains(t, err, "holds 2 bytes")
+}
@@ -0,0 +1,163 @@
+package store
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	EventCreate    = "create"
+	EventUpdate    = "update"
+	EventDelete    = "delete"
+	EventArchive   = "archive"
+	EventUnarchive = "unarchive"
+	EventComment   = "comment"
+
+	MaxBufferedEvents      = 1000
+	SubscriptionBufferSize = 64
+)
+
+type Event struct {
+	ID      uint64    `json:"id"`
+	Type    string    `json:"type"`
+	ItemID  ItemID    `json:"item_id"`
+	Item    *Item     `json:"item,omitempty"`
+	Time    time.Time `json:"time"`
+	Comment *Comment  `json:"comment,omitempty"`
+}
+
+// Subscription receives the events published by the store. Its channel is closed when the subscription
+// is closed or when the subscriber falls too far behind, in which case it should resubscribe from the
+// ID of the last event it received.
+type Subscription struct {
+	Events <-chan Event
+	close  func()
+}
+
+// NewSubscription returns a subscription over events for stores living elsewhere, close is called once
+// when the subscription is closed.
+func NewSubscription(events <-chan Event, close func()) *Subscription {
+	return &Subscription{Events: events, close: sync.OnceFunc(close)}
+}
+
+func (sub *Subscription) Close() {
+	sub.close()
+}
+
+func (s *Store) subscribe(lastEventID uint64) response {
+	var backlog []Event
+	if lastEventID > 0 {
+		for _, event := range s.events {
+			if event.ID > lastEventID {
+				backlog = append(backlog, event)
+			}
+		}
+	}
+
+	events := make(chan Event, len(backlog)+SubscriptionBufferSize)
+	for _, event := range backlog {
+		events <- event
+	}
+
+	s.subscriptionSeq++
+	id := s.subscriptionSeq
+	s.subscribers[id] = events
+
+	return response{
+		subscription: NewSubscription(events, func() { s.closeSubscription(id) }),
+	}
+}
+
+// Subscribe returns a subscription to item changes, a non-zero lastEventID first replays the buffered
+// events published after it.
+func (s *Store) Subscribe(lastEventID uint64) (*Subscription, error) {
+	responseChan := make(chan response, 1)
+	req := request{
+		action:       "subscribe",
+		responseChan: responseChan,
+		lastEventID:  lastEventID,
+	}
+	res := s.send(req)
+
+	return res.subscription, res.err
+}
+
+func (s *Store) closeSubscription(id uint64) {
+	responseChan := make(chan response, 1)
+	req := request{
+		action:         "unsubscribe",
+		responseChan:   responseChan,
+		subscriptionID: id,
+	}
+	s.send(req)
+}
+
+func (s *Store) unsubscribe(id uint64) response {
+	if events, found := s.subscribers[id]; found {
+		close(events)
+		delete(s.subscribers, id)
+	}
+
+	return response{}
+}
+
+func (s *Store) publishChanges(changes ...Change) {
+	for _, change := range changes {
+		switch {
+		case change.archives():
+			s.publish(EventArchive, change.ID, change.After)
+		case change.unarchives():
+			s.publish(EventUnarchive, change.ID, change.After)
+		case change.Before == nil:
+			s.publish(EventCreate, change.ID, change.After)
+		case change.After == nil:
+			s.publish(EventDelete, change.ID, nil)
+		default:
+			s.publish(EventUpdate, change.ID, change.After)
+		}
+	}
+}
+
+func (s *Store) publish(eventType string, id ItemID, item *Item) {
+	s.queueEvent(Event{
+		Type:   eventType,
+		ItemID: id,
+		Item:   item,
+		Time:   time.Now().UTC(),
+	})
+}
+
+// queueEvent queues an event of the request being handled, it is published once the request commits.
+func (s *Store) queueEvent(event Event) {
+	s.unpublished = append(s.unpublished, event)
+}
+
+// flushEvents publishes the events queued by a request that committed, or drops them when it did not, so
+// that subscribers never see changes that were rolled back.
+func (s *Store) flushEvents(committed bool) {
+	unpublished := s.unpublished
+	s.unpublished = nil
+	if !committed {
+		return
+	}
+
+	for _, event := range unpublished {
+		s.eventSeq++
+		event.ID = s.eventSeq
+
+		s.events = append(s.events, event)
+		if len(s.events) > MaxBufferedEvents {
+			s.events = s.events[len(s.events)-MaxBufferedEvents:]
+		}
+
+		for subscriptionID, events := range s.subscribers {
+			select {
+			case events <- event:
+			default:
+				// Never block the actor on a slow subscriber, it can resume from its last event ID
+				close(events)
+				delete(s.subscribers, subscriptionID)
+			}
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package store
+
+import (
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func receive(t *testing.T, sub *Subscription) Event {
+	select {
+	case event, ok := <-sub.Events:
+		require.True(t, ok, "subscription closed")
+		return event
+	case <-time.After(time.Second):
+		require.FailNow(t, "no event received")
+		return Event{}
+	}
+}
+
+func Test_Subscribe_ReceivesChanges(t *testing.T) {
+	t.Cleanup(setupTest())
+
+	store := newTestStore()
+	sub, err := store.Subscribe(0)
+	require.NoError(t, err)
+	t.Cleanup(sub.Close)
+
+	item, err := store.Create(Item{Name: "name1"})
+	require.NoError(t, err)
+	updatedItem, err := store.Update(ItemID(item.ID), Item{Name: "name2"})
+	require.NoError(t, err)
+	require.NoError(t, store.Delete(ItemID(item.ID)))
+
+	created := receive(t, sub)
+	updated := receive(t, sub)
+	deleted := receive(t, sub)
+
+	assert.Equal(t, EventCreate, created.Type)
+	assert.Equal(t, &item, created.Item)
+	assert.Equal(t, EventUpdate, updated.Type)
+	assert.Equal(t, &updatedItem, updated.Item)
+	assert.Equal(t, EventDelete, deleted.Type)
+	assert.Equal(t, ItemID(item.ID), deleted.ItemID)
+	assert.Less(t, created.ID, updated.ID)
+	assert.Less(t, updated.ID, deleted.ID)
+}
+
+func Test_Subscribe_ReplaysEventsAfterLastEventID(t *testing.T) {
+	t.Cleanup(setupTest())
+
+	store := newTestStore()
+	sub, err := store.Subscribe(0)
+	require.NoError(t, err)
+
+	_, err = store.Create(Item{Name: "name1"})
+	require.NoError(t, err)
+	first := receive(t, sub)
+	sub.Close()
+
+	_, err = store.Create(Item{Name: "name2"})
+	require.NoError(t, err)
+	_, err = store.Create(Item{Name: "name3"})
+	require.NoError(t, err)
+
+	resumed, err := store.Subscribe(first.ID)
+	require.NoError(t, err)
+	t.Cleanup(resumed.Close)
+
+	assert.Equal(t, "name2", receive(t, resumed).Item.Name)
+	assert.Equal(t, "name3", receive(t, resumed).Item.Name)
+}
+
+func Test_Subscription_Close_ClosesEvents(t *testing.T) {
+	t.Cleanup(setupTest())
+
+	store := newTestStore()
+	sub, err := store.Subscribe(0)
+	require.NoError(t, err)
+
+	sub.Close()
+
+	_, ok := <-sub.Events
+	assert.False(t, ok)
+}
+
+func Test_Subscribe_DropsSlowSubscriber(t *testing.T) {
+	t.Cleanup(setupTest())
+
+	store := newTestStore()
+	sub, err := store.Subscribe(0)
+	require.NoError(t, err)
+
+	for i := 0; i <= SubscriptionBufferSize; i++ {
+		store.publish(EventCreate, "id1", nil)
+	}
+	store.flushEvents(true)
+
+	for range SubscriptionBufferSize {
+		receive(t, sub)
+	}
+	_, ok := <-sub.Events
+	assert.False(t, ok)
+	sub.Close()
+}
+
+// commitFailingBackend fails to commit the changes of every request.
+type commitFailingBackend struct {
+	backend
+}
+
+func (b commitFailingBackend) end(commit bool) error {
+	if err := b.backend.end(commit); err != nil {
+		return err
+	}
+	if commit {
+		return errors.New("disk full")
+	}
+	return nil
+}
+
+func Test_Subscribe_ReceivesNoEventsOfFailedCommits(t *testing.T) {
+	t.Cleanup(setupTest())
+
+	store := newTestStore()
+	sub, err := store.Subscribe(0)
+	require.NoError(t, err)
+	t.Cleanup(sub.Close)
+
+	// The actor reads the backend after receiving the next request, which happens after this write
+	working := store.backend
+	store.backend = commitFailingBackend{working}
+	_, err = store.Create(Item{Name: "name1"})
+	require.ErrorContains(t, err, "disk full")
+	store.backend = working
+
+	_, err = store.Create(Item{Name: "name2"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "name2", receive(t, sub).Item.Name)
+}
+
+func Test_Subscribe_ReceivesComments(t *testing.T) {
+	t.Cleanup(setupTest())
+
+	store := newTestStore()
+	item, err := store.Create(Item{Name: "name1"})
+	require.NoError(t, err)
+	sub, err := store.Subscribe(0)
+	require.NoError(t, err)
+	t.Cleanup(sub.Close)
+
+	_, err = store.AddComment(ItemID(item.ID), Comment{Author: "user1", Text: "text1"})
+	require.NoError(t, err)
+
+	commented := receive(t, sub)
+	assert.Equal(t, EventComment, commented.Type)
+	assert.Equal(t, ItemID(item.ID), commented.ItemID)
+	assert.Equal(t, &item, commented.Item)
+}
@@ -0,0 +1,57 @@
+package store
+
+import (
+	"github.com/pkg/errors"
+	"os"
+	"time"
+)
+
+const (
+	LockFilename = "items.lock"
+	// fileLockTimeout bounds the wait for another process that runs a request on the same files
+	fileLockTimeout = 5 * time.Second
+)
+
+var ErrLocked = errors.New("store is locked by another process")
+
+// fileLock is an advisory lock on the lock file of a store directory, held by one store at a time whether
+// the stores share a process or not.
+type fileLock struct {
+	file *os.File
+}
+
+func lockFile(path string) (*fileLock, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, errors.Wrapf(err, "open %s", path)
+	}
+
+	deadline := time.Now().Add(fileLockTimeout)
+	wait := time.Millisecond
+	for {
+		locked, err := tryLock(file)
+		if err != nil {
+			_ = file.Close()
+			return nil, errors.Wrapf(err, "lock %s", path)
+		}
+		if locked {
+			return &fileLock{file: file}, nil
+		}
+
+		if time.Now().After(deadline) {
+			_ = file.Close()
+			return nil, errors.Wrapf(ErrLocked, "lock %s", path)
+		}
+		time.Sleep(wait)
+		wait = min(2*wait, 20*time.Millisecond)
+	}
+}
+
+func (l *fileLock) unlock() error {
+	err := unlock(l.file)
+	if closeErr := l.file.Close(); err == nil {
+		err = closeErr
+	}
+
+	return errors.Wrapf(err, "unlock %s", l.file.Name())
+}
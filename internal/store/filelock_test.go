@@ -0,0 +1,222 @@
+package store
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// lockHelperDirEnv makes Test_FileLock_HelperProcess create items in the directory it names, as one of the
+// processes of Test_FileLock_SerializesRequestsOfProcesses.
+const lockHelperDirEnv = "STORE_LOCK_HELPER_DIR"
+
+const itemsPerStore = 25
+
+func createItems(store *Store, name string) error {
+	for i := range itemsPerStore {
+		if _, err := store.Create(Item{Name: fmt.Sprintf("%s-%d", name, i), Status: StatusNotStarted}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func Test_FileLock_HelperProcess(t *testing.T) {
+	dir := os.Getenv(lockHelperDirEnv)
+	if dir == "" {
+		t.Skip("only runs as a process of another test")
+	}
+
+	store := NewStore(WithDir(dir), WithJanitorInterval(0))
+	defer store.Close()
+
+	require.NoError(t, createItems(store, fmt.Sprintf("process%d", os.Getpid())))
+}
+
+func Test_FileLock_SerializesRequestsOfProcesses(t *testing.T) {
+	dir := t.TempDir()
+	processes := make([]*exec.Cmd, 3)
+	for i := range processes {
+		processes[i] = exec.Command(os.Args[0], "-test.run=^Test_FileLock_HelperProcess$", "-test.count=1")
+		processes[i].Env = append(os.Environ(), lockHelperDirEnv+"="+dir)
+		require.NoError(t, processes[i].Start())
+	}
+	for _, process := range processes {
+		require.NoError(t, process.Wait())
+	}
+
+	items, err := newFileStore(t, dir).ReadAll()
+
+	assert.NoError(t, err)
+	assert.Len(t, items, len(processes)*itemsPerStore)
+}
+
+func Test_FileLock_SerializesRequestsOfStoresInOneProcess(t *testing.T) {
+	dir := t.TempDir()
+	var wg sync.WaitGroup
+	for i := range 4 {
+		store := newFileStore(t, dir)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, createItems(store, fmt.Sprintf("store%d", i)))
+		}()
+	}
+	wg.Wait()
+
+	items, err := newFileStore(t, dir).ReadAll()
+
+	assert.NoError(t, err)
+	assert.Len(t, items, 4*itemsPerStore)
+}
+
+func Test_FileLock_IsHeldByOneFileAtATime(t *testing.T) {
+	path := filepath.Join(t.TempDir(), LockFilename)
+	lock, err := lockFile(path)
+	require.NoError(t, err)
+	other, err := os.OpenFile(path, os.O_RDWR, 0)
+	require.NoError(t, err)
+	defer other.Close()
+
+	locked, err := tryLock(other)
+	require.NoError(t, err)
+	assert.False(t, locked)
+
+	require.NoError(t, lock.unlock())
+	locked, err = tryLock(other)
+	require.NoError(t, err)
+	assert.True(t, locked)
+}
+
+// newSharedStores returns two stores on the same directory, standing in for the stores of two processes.
+func newSharedStores(t *testing.T, opts ...Option) (*Store, *Store) {
+	t.Helper()
+
+	dir := t.TempDir()
+	opts = append(opts, WithDir(dir), WithJanitorInterval(0))
+	store1, store2 := newTestStore(opts...), newTestStore(opts...)
+	t.Cleanup(store1.Close)
+	t.Cleanup(store2.Close)
+
+	return store1, store2
+}
+
+func Test_SharedStores_SeeItemsRestoredFromTrash(t *testing.T) {
+	store1, store2 := newSharedStores(t)
+	item1, err := store1.Create(Item{Name: "name1", Status: StatusNotStarted})
+	require.NoError(t, err)
+	item2, err := store1.Create(Item{Name: "name2", Status: StatusNotStarted})
+	require.NoError(t, err)
+	require.NoError(t, store1.Delete(ItemID(item1.ID)))
+	_, err = store2.RestoreDeleted(ItemID(item1.ID))
+	require.NoError(t, err)
+
+	require.NoError(t, store1.Delete(ItemID(item2.ID)))
+
+	trash, err := store2.ReadTrash()
+	assert.NoError(t, err)
+	require.Len(t, trash, 1)
+	assert.Equal(t, item2.ID, trash[0].ID)
+}
+
+func Test_SharedStores_SeeItemsUnarchived(t *testing.T) {
+	store1, store2 := newSharedStores(t)
+	item1, err := store1.Create(Item{Name: "name1", Status: StatusCompleted})
+	require.NoError(t, err)
+	item2, err := store1.Create(Item{Name: "name2", Status: StatusCompleted})
+	require.NoError(t, err)
+	_, err = store1.Archive(ItemID(item1.ID))
+	require.NoError(t, err)
+	_, err = store2.Unarchive(ItemID(item1.ID))
+	require.NoError(t, err)
+
+	_, err = store1.Archive(ItemID(item2.ID))
+	require.NoError(t, err)
+
+	archived, err := store2.ReadArchived()
+	assert.NoError(t, err)
+	require.Len(t, archived, 1)
+	assert.Equal(t, item2.ID, archived[0].ID)
+}
+
+func Test_SharedStores_SeeWebhooksAndDeliveriesDeleted(t *testing.T) {
+	store1, store2 := newSharedStores(t)
+	webhook1, err := store1.AddWebhook(Webhook{URL: "http://localhost/1"})
+	require.NoError(t, err)
+	require.NoError(t, store1.RecordDelivery(Delivery{WebhookID: webhook1.ID, EventID: 1}))
+	require.NoError(t, store2.DeleteWebhook(webhook1.ID))
+
+	webhook2, err := store1.AddWebhook(Webhook{URL: "http://localhost/2"})
+	require.NoError(t, err)
+	require.NoError(t, store1.RecordDelivery(Delivery{WebhookID: webhook2.ID, EventID: 2}))
+
+	webhooks, err := store2.ReadWebhooks()
+	assert.NoError(t, err)
+	assert.Equal(t, []Webhook{webhook2}, webhooks)
+	_, err = store2.AddWebhook(Webhook{ID: webhook1.ID, URL: "http://localhost/1"})
+	require.NoError(t, err)
+	deliveries, err := store2.ReadDeliveries(webhook1.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, deliveries)
+}
+
+func Test_SharedStores_SeePendingChangesCleared(t *testing.T) {
+	store1, store2 := newSharedStores(t, WithPendingChanges())
+	item1, err := store1.Create(Item{Name: "name1", Status: StatusNotStarted})
+	require.NoError(t, err)
+	require.NoError(t, store2.ClearPending(ItemID(item1.ID)))
+
+	item2, err := store1.Create(Item{Name: "name2", Status: StatusNotStarted})
+	require.NoError(t, err)
+
+	pending, err := store2.Pending()
+	assert.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.Equal(t, ItemID(item2.ID), pending[0].ID)
+}
+
+func Test_SharedStores_SeeCommentsAndHistoryEvicted(t *testing.T) {
+	store1, store2 := newSharedStores(t)
+	item1, err := store1.Create(Item{Name: "name1", Status: StatusNotStarted})
+	require.NoError(t, err)
+	_, err = store1.AddComment(ItemID(item1.ID), Comment{Author: "user1", Text: "text1"})
+	require.NoError(t, err)
+	require.NoError(t, store2.Evict(ItemID(item1.ID)))
+
+	item2, err := store1.Create(Item{Name: "name2", Status: StatusNotStarted})
+	require.NoError(t, err)
+	_, err = store1.AddComment(ItemID(item2.ID), Comment{Author: "user1", Text: "text2"})
+	require.NoError(t, err)
+
+	// The same ID again starts over without the comments and history of the evicted item
+	_, err = store2.Create(Item{ID: item1.ID, Name: "name1", Status: StatusNotStarted})
+	require.NoError(t, err)
+	comments, err := store2.ReadComments(ItemID(item1.ID))
+	assert.NoError(t, err)
+	assert.Empty(t, comments)
+	history, err := store2.ReadHistory(ItemID(item1.ID))
+	assert.NoError(t, err)
+	assert.Len(t, history, 1)
+}
+
+func Test_SharedStores_SeeChangesUndone(t *testing.T) {
+	store1, store2 := newSharedStores(t)
+	_, err := store1.Create(Item{Name: "name1", Status: StatusNotStarted})
+	require.NoError(t, err)
+	require.NoError(t, store2.Undo())
+
+	_, err = store1.Create(Item{Name: "name2", Status: StatusNotStarted})
+	require.NoError(t, err)
+
+	require.NoError(t, store2.Undo())
+	assert.ErrorIs(t, store2.Undo(), ErrNothingToUndo)
+	items, err := store2.ReadAll()
+	assert.NoError(t, err)
+	assert.Empty(t, items)
+}
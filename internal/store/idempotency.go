@@ -0,0 +1,154 @@
+package store
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"time"
+)
+
+const (
+	IdempotencyFilename   = "idempotency.json"
+	DefaultIdempotencyTTL = 24 * time.Hour
+)
+
+type idempotencyRecord struct {
+	Fingerprint string    `json:"fingerprint"`
+	Item        Item      `json:"item"`
+	Expires     time.Time `json:"expires"`
+}
+
+// idempotencyKeys holds the records of the idempotency keys of every user, the same key of two users names
+// two different records.
+type idempotencyKeys map[string]map[string]idempotencyRecord
+
+func WithIdempotencyTTL(ttl time.Duration) Option {
+	return func(s *Store) {
+		s.idempotencyTTL = ttl
+	}
+}
+
+func (s *Store) createIdempotent(user, key string, item Item) response {
+	// The ID is left out so a router may pick a new one when it retries the request
+	unidentified := item
+	unidentified.ID = ""
+	fingerprint, err := fingerprintOf(unidentified)
+	if err != nil {
+		return response{
+			err: errors.Wrap(err, "fingerprint item"),
+		}
+	}
+
+	if err := s.backend.load(IdempotencyFilename, &s.idempotency); err != nil {
+		return response{
+			err: errors.Wrap(err, "load idempotency keys"),
+		}
+	}
+
+	now := time.Now()
+	if record, found := s.idempotency[user][key]; found && now.Before(record.Expires) {
+		if record.Fingerprint != fingerprint {
+			return response{
+				err: errors.Wrapf(ErrIdempotencyConflict, "idempotency key '%s' reused with a different item", key),
+			}
+		}
+		return response{
+			item:     record.Item,
+			replayed: true,
+		}
+	}
+
+	res := s.create(item)
+	if res.err != nil {
+		return res
+	}
+
+	if s.idempotency[user] == nil {
+		s.idempotency[user] = make(map[string]idempotencyRecord)
+	}
+	s.idempotency[user][key] = idempotencyRecord{
+		Fingerprint: fingerprint,
+		Item:        res.item,
+		Expires:     now.Add(s.idempotencyTTL),
+	}
+
+	if err := s.backend.save(IdempotencyFilename, s.idempotency); err != nil {
+		return response{
+			err: errors.Wrap(err, "save idempotency keys"),
+		}
+	}
+
+	return res
+}
+
+// CreateIdempotent creates the item once per key of the user within the TTL, replays return the originally
+// created item and report true. The keys outlive restarts of the store.
+func (s *Store) CreateIdempotent(user, key string, item Item) (Item, bool, error) {
+	responseChan := make(chan response, 1)
+	req := request{
+		action:          "createIdempotent",
+		responseChan:    responseChan,
+		item:            item,
+		idempotencyUser: user,
+		idempotencyKey:  key,
+	}
+	res := s.send(req)
+
+	return res.item, res.replayed, res.err
+}
+
+func (s *Store) pruneIdempotencyKeys() response {
+	if err := s.backend.load(IdempotencyFilename, &s.idempotency); err != nil {
+		return response{
+			err: errors.Wrap(err, "load idempotency keys"),
+		}
+	}
+
+	now := time.Now()
+	pruned := false
+	for user, records := range s.idempotency {
+		for key, record := range records {
+			if !now.Before(record.Expires) {
+				delete(records, key)
+				pruned = true
+			}
+		}
+		if len(records) == 0 {
+			delete(s.idempotency, user)
+		}
+	}
+
+	if !pruned {
+		return response{}
+	}
+
+	if err := s.backend.save(IdempotencyFilename, s.idempotency); err != nil {
+		return response{
+			err: errors.Wrap(err, "save idempotency keys"),
+		}
+	}
+
+	return response{}
+}
+
+func (s *Store) PruneIdempotencyKeys() error {
+	responseChan := make(chan response, 1)
+	req := request{
+		action:       "pruneIdempotencyKeys",
+		responseChan: responseChan,
+	}
+	res := s.send(req)
+
+	return res.err
+}
+
+func fingerprintOf(item Item) (string, error) {
+	body, err := json.Marshal(item)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,106 @@
+package store
+
+import (
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func Test_CreateIdempotent_ReplaysOriginalItem(t *testing.T) {
+	t.Cleanup(setupTest())
+
+	store := newTestStore()
+	item := Item{Name: "name1", Desc: "desc1", Status: "status1"}
+
+	createdItem, replayed, err := store.CreateIdempotent("user1", "key1", item)
+	assert.NoError(t, err)
+	assert.False(t, replayed)
+
+	replayedItem, replayed, err := store.CreateIdempotent("user1", "key1", item)
+
+	assert.NoError(t, err)
+	assert.True(t, replayed)
+	assert.Equal(t, createdItem, replayedItem)
+	items, err := store.ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, items, 1)
+}
+
+func Test_CreateIdempotent_ReturnsConflictForDifferentItem(t *testing.T) {
+	t.Cleanup(setupTest())
+
+	store := newTestStore()
+
+	_, _, err := store.CreateIdempotent("user1", "key1", Item{Name: "name1"})
+	assert.NoError(t, err)
+
+	_, _, err = store.CreateIdempotent("user1", "key1", Item{Name: "name2"})
+
+	assert.True(t, errors.Is(err, ErrIdempotencyConflict))
+}
+
+func Test_CreateIdempotent_CreatesAgainAfterTTL(t *testing.T) {
+	t.Cleanup(setupTest())
+
+	store := newTestStore(WithIdempotencyTTL(10 * time.Millisecond))
+	item := Item{Name: "name1"}
+
+	createdItem, _, err := store.CreateIdempotent("user1", "key1", item)
+	assert.NoError(t, err)
+	time.Sleep(20 * time.Millisecond)
+
+	newItem, replayed, err := store.CreateIdempotent("user1", "key1", item)
+
+	assert.NoError(t, err)
+	assert.False(t, replayed)
+	assert.NotEqual(t, createdItem.ID, newItem.ID)
+}
+
+func Test_PruneIdempotencyKeys_RemovesExpiredKeys(t *testing.T) {
+	t.Cleanup(setupTest())
+
+	store := newTestStore(WithIdempotencyTTL(10 * time.Millisecond))
+
+	_, _, err := store.CreateIdempotent("user1", "key1", Item{Name: "name1"})
+	assert.NoError(t, err)
+	time.Sleep(20 * time.Millisecond)
+
+	err = store.PruneIdempotencyKeys()
+
+	assert.NoError(t, err)
+	assert.Empty(t, store.idempotency)
+}
+
+func Test_CreateIdempotent_ReplaysAfterReopen(t *testing.T) {
+	t.Cleanup(setupTest())
+
+	store := newTestStore()
+	createdItem, _, err := store.CreateIdempotent("user1", "key1", Item{Name: "name1"})
+	assert.NoError(t, err)
+	store.Close()
+
+	store = newTestStore()
+	replayedItem, replayed, err := store.CreateIdempotent("user1", "key1", Item{Name: "name1"})
+
+	assert.NoError(t, err)
+	assert.True(t, replayed)
+	assert.Equal(t, createdItem, replayedItem)
+}
+
+func Test_CreateIdempotent_KeepsKeysOfUsersApart(t *testing.T) {
+	t.Cleanup(setupTest())
+
+	store := newTestStore()
+	item1, _, err := store.CreateIdempotent("user1", "key1", Item{Name: "name1"})
+	assert.NoError(t, err)
+
+	item2, replayed, err := store.CreateIdempotent("user2", "key1", Item{Name: "name2"})
+
+	assert.NoError(t, err)
+	assert.False(t, replayed)
+	assert.NotEqual(t, item1.ID, item2.ID)
+	items, err := store.ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, items, 2)
+}
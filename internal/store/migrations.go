@@ -0,0 +1,174 @@
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	"io/fs"
+)
+
+// ItemsFileVersion is the version of the items file this build writes. Version 1 is the bare map of items
+// written before the file had a version.
+const ItemsFileVersion = 2
+
+// itemsFile is the envelope of the items file.
+type itemsFile struct {
+	Version int             `json:"version"`
+	Items   map[ItemID]Item `json:"items"`
+}
+
+// itemsMigration upgrades the items of a file by one version. Items are decoded generically, so that a
+// migration can rename, convert or drop fields Item no longer has.
+type itemsMigration func(items map[string]map[string]any) error
+
+// itemsMigrations holds the migration from version i+1 at index i. Append a migration and raise
+// ItemsFileVersion with every change to Item that older files cannot be decoded into as they are.
+var itemsMigrations = []itemsMigration{
+	// Items of bare maps may lack their id, which is their key
+	func(items map[string]map[string]any) error {
+		for id, item := range items {
+			if item == nil {
+				return errors.Errorf("item '%s' is null", id)
+			}
+			if existing, found := item["id"]; !found || existing == "" {
+				item["id"] = id
+			}
+		}
+		return nil
+	},
+}
+
+// ItemsMigration tells from and to which version the items file was migrated, or would be on a dry run.
+type ItemsMigration struct {
+	From  int
+	To    int
+	Items int
+}
+
+// itemsMigrator is implemented by backends that keep the items in a versioned file rather than a schema
+// migrated when it opens.
+type itemsMigrator interface {
+	migrateItems(dryRun bool) (ItemsMigration, error)
+}
+
+// decodeItemsFile decodes the items of a file of any version up to ItemsFileVersion, migrating older ones,
+// and returns the version the file had. Fields Item does not know are an error rather than dropped.
+func decodeItemsFile(data []byte) (map[ItemID]Item, int, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, 0, err
+	}
+
+	// A bare map of items holds objects only, so a version number marks the envelope
+	version, raw := 1, json.RawMessage(data)
+	if json.Unmarshal(fields["version"], new(int)) == nil {
+		var envelope struct {
+			Version int             `json:"version"`
+			Items   json.RawMessage `json:"items"`
+		}
+		if err := json.Unmarshal(data, &envelope); err != nil {
+			return nil, 0, err
+		}
+		version, raw = envelope.Version, envelope.Items
+	}
+
+	if version < 1 || version > ItemsFileVersion {
+		return nil, version, errors.Errorf("version %d is unknown, this build reads versions up to %d", version, ItemsFileVersion)
+	}
+
+	if version < ItemsFileVersion {
+		var items map[string]map[string]any
+		decoder := json.NewDecoder(bytes.NewReader(raw))
+		decoder.UseNumber()
+		if err := decoder.Decode(&items); err != nil {
+			return nil, version, err
+		}
+
+		for from := version; from < ItemsFileVersion; from++ {
+			if err := itemsMigrations[from-1](items); err != nil {
+				return nil, version, errors.Wrapf(err, "migrate from version %d", from)
+			}
+		}
+
+		var err error
+		if raw, err = json.Marshal(items); err != nil {
+			return nil, version, err
+		}
+	}
+
+	items := make(map[ItemID]Item)
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&items); err != nil {
+		return nil, version, err
+	}
+
+	return items, version, nil
+}
+
+// migrateItems rewrites an items file of an older version, keeping the file as it was next to it with the
+// version appended to its name.
+func (b *fileBackend) migrateItems(dryRun bool) (ItemsMigration, error) {
+	data, err := b.read(ItemsFilename)
+	if errors.Is(err, fs.ErrNotExist) {
+		return ItemsMigration{From: ItemsFileVersion, To: ItemsFileVersion}, nil
+	}
+	if err != nil {
+		return ItemsMigration{}, err
+	}
+
+	items, version, err := decodeItemsFile(data)
+	if err != nil {
+		return ItemsMigration{}, errors.Wrapf(err, "decode %s", ItemsFilename)
+	}
+
+	migration := ItemsMigration{From: version, To: ItemsFileVersion, Items: len(items)}
+	if dryRun || version == ItemsFileVersion {
+		return migration, nil
+	}
+
+	if err := b.write(fmt.Sprintf("%s.v%d", ItemsFilename, version), data); err != nil {
+		return ItemsMigration{}, errors.Wrapf(err, "back up %s", ItemsFilename)
+	}
+
+	if err := b.saveItems(items); err != nil {
+		return ItemsMigration{}, errors.Wrap(err, "save items")
+	}
+
+	return migration, nil
+}
+
+func (s *Store) migrateItems(dryRun bool) response {
+	migrator, ok := s.backend.(itemsMigrator)
+	if !ok {
+		return response{
+			err: errors.Errorf("the %s backend migrates its schema when it opens", s.backendName),
+		}
+	}
+
+	migration, err := migrator.migrateItems(dryRun)
+	if err != nil {
+		return response{
+			err: errors.Wrap(err, "migrate items"),
+		}
+	}
+
+	return response{
+		migration: migration,
+	}
+}
+
+// MigrateItems upgrades the items file to ItemsFileVersion. Loading reads older files as well, but only
+// saving items rewrites them. A dry run only tells what the migration would do.
+func (s *Store) MigrateItems(dryRun bool) (ItemsMigration, error) {
+	responseChan := make(chan response, 1)
+	req := request{
+		action:       "migrateItems",
+		responseChan: responseChan,
+		dryRun:       dryRun,
+	}
+	res := s.send(req)
+
+	return res.migration, res.err
+}
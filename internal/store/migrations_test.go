@@ -0,0 +1,137 @@
+package store
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func newFileStore(t *testing.T, dir string) *Store {
+	t.Helper()
+
+	store := NewStore(WithDir(dir), WithJanitorInterval(0))
+	t.Cleanup(store.Close)
+
+	return store
+}
+
+func writeItemsFile(t *testing.T, dir, content string) {
+	t.Helper()
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ItemsFilename), []byte(content), 0o644))
+}
+
+func readItemsFile(t *testing.T, dir string) itemsFile {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join(dir, ItemsFilename))
+	require.NoError(t, err)
+	var file itemsFile
+	require.NoError(t, json.Unmarshal(data, &file))
+
+	return file
+}
+
+func Test_ItemsFile_HasMigrationForEveryVersion(t *testing.T) {
+	assert.Len(t, itemsMigrations, ItemsFileVersion-1)
+}
+
+func Test_ItemsFile_SavesVersionWithItems(t *testing.T) {
+	dir := t.TempDir()
+	store := newFileStore(t, dir)
+
+	item, err := store.Create(Item{Name: "name1", Status: StatusNotStarted})
+	require.NoError(t, err)
+	file := readItemsFile(t, dir)
+
+	assert.Equal(t, ItemsFileVersion, file.Version)
+	assert.Equal(t, map[ItemID]Item{ItemID(item.ID): item}, file.Items)
+}
+
+func Test_ItemsFile_ReadsBareMapOfItems(t *testing.T) {
+	dir := t.TempDir()
+	writeItemsFile(t, dir, `{"id1": {"name": "name1", "description": "desc1", "status": "completed", "version": 9007199254740993}}`)
+	store := newFileStore(t, dir)
+
+	items, err := store.ReadAll()
+
+	assert.NoError(t, err)
+	assert.Equal(t, []Item{{ID: "id1", Name: "name1", Desc: "desc1", Status: StatusCompleted, Version: 9007199254740993}}, items)
+}
+
+func Test_ItemsFile_RejectsNewerVersions(t *testing.T) {
+	dir := t.TempDir()
+	writeItemsFile(t, dir, `{"version": 99, "items": {}}`)
+	store := newFileStore(t, dir)
+
+	_, err := store.ReadAll()
+
+	assert.ErrorContains(t, err, "version 99 is unknown")
+}
+
+func Test_ItemsFile_RejectsUnknownFields(t *testing.T) {
+	dir := t.TempDir()
+	writeItemsFile(t, dir, `{"version": 2, "items": {"id1": {"id": "id1", "name": "name1", "priority": "high"}}}`)
+	store := newFileStore(t, dir)
+
+	_, err := store.ReadAll()
+
+	assert.ErrorContains(t, err, "priority")
+}
+
+func Test_MigrateItems_LeavesFileAloneOnDryRun(t *testing.T) {
+	dir := t.TempDir()
+	content := `{"id1": {"name": "name1", "description": "", "status": "started"}}`
+	writeItemsFile(t, dir, content)
+	store := newFileStore(t, dir)
+
+	migration, err := store.MigrateItems(true)
+
+	assert.NoError(t, err)
+	assert.Equal(t, ItemsMigration{From: 1, To: ItemsFileVersion, Items: 1}, migration)
+	data, err := os.ReadFile(filepath.Join(dir, ItemsFilename))
+	require.NoError(t, err)
+	assert.Equal(t, content, string(data))
+}
+
+func Test_MigrateItems_RewritesOlderFileAndKeepsBackup(t *testing.T) {
+	dir := t.TempDir()
+	content := `{"id1": {"name": "name1", "description": "", "status": "started"}}`
+	writeItemsFile(t, dir, content)
+	store := newFileStore(t, dir)
+
+	migration, err := store.MigrateItems(false)
+
+	assert.NoError(t, err)
+	assert.Equal(t, ItemsMigration{From: 1, To: ItemsFileVersion, Items: 1}, migration)
+	file := readItemsFile(t, dir)
+	assert.Equal(t, ItemsFileVersion, file.Version)
+	assert.Equal(t, map[ItemID]Item{"id1": {ID: "id1", Name: "name1", Status: StatusStarted}}, file.Items)
+	backup, err := os.ReadFile(filepath.Join(dir, ItemsFilename+".v1"))
+	require.NoError(t, err)
+	assert.Equal(t, content, string(backup))
+}
+
+func Test_MigrateItems_DoesNothingForCurrentVersion(t *testing.T) {
+	dir := t.TempDir()
+	store := newFileStore(t, dir)
+	_, err := store.Create(Item{Name: "name1", Status: StatusNotStarted})
+	require.NoError(t, err)
+
+	migration, err := store.MigrateItems(false)
+
+	assert.NoError(t, err)
+	assert.Equal(t, ItemsMigration{From: ItemsFileVersion, To: ItemsFileVersion, Items: 1}, migration)
+	assert.NoFileExists(t, filepath.Join(dir, ItemsFilename+".v1"))
+}
+
+func Test_MigrateItems_FailsOnOtherBackends(t *testing.T) {
+	store := newSQLiteStore(t, t.TempDir())
+
+	_, err := store.MigrateItems(true)
+
+	assert.Error(t, err)
+}
@@ -0,0 +1,167 @@
+package store
+
+import (
+	"github.com/pkg/errors"
+	"slices"
+	"time"
+)
+
+const PendingFilename = "pending.json"
+
+// PendingChange is an item changed since the store last synced with a server. Base is the item as last
+// synced, nil for an item created since, and Item is the item now, nil for an item deleted since. Archiving
+// is not tracked, each store archives its own items.
+type PendingChange struct {
+	ID   ItemID    `json:"id"`
+	Base *Item     `json:"base,omitempty"`
+	Item *Item     `json:"item,omitempty"`
+	Time time.Time `json:"time"`
+}
+
+// WithPendingChanges makes the store keep the item changes made since it last synced with a server. A store
+// that starts keeping them records its existing items as created, so the first sync sends the ones the server
+// does not have yet.
+func WithPendingChanges() Option {
+	return func(s *Store) {
+		s.trackPending = true
+	}
+}
+
+func (s *Store) loadPending() error {
+	if !s.trackPending {
+		return s.backend.load(PendingFilename, &s.pending)
+	}
+
+	exists, err := s.backend.exists(PendingFilename)
+	if err != nil {
+		return errors.Wrap(err, "check pending changes")
+	}
+	if exists {
+		return s.backend.load(PendingFilename, &s.pending)
+	}
+
+	now := time.Now().UTC()
+	for id := range s.items {
+		s.pending[id] = PendingChange{ID: id, Time: now}
+	}
+
+	return s.backend.save(PendingFilename, s.pending)
+}
+
+// recordPending keeps the item as last synced for every changed item until the next sync.
+func (s *Store) recordPending(changes []Change) error {
+	if !s.trackPending {
+		return nil
+	}
+
+	if err := s.loadPending(); err != nil {
+		return errors.Wrap(err, "load pending changes")
+	}
+
+	for _, change := range changes {
+		// Archiving is local to every store, the server archives its items itself
+		if change.archives() || change.unarchives() {
+			continue
+		}
+
+		pending, found := s.pending[change.ID]
+		if !found {
+			pending = PendingChange{ID: change.ID, Base: change.Before}
+		}
+
+		if pending.Base == nil && change.After == nil {
+			// Created and deleted again since the last sync, the server never saw it
+			delete(s.pending, change.ID)
+			continue
+		}
+
+		pending.Time = time.Now().UTC()
+		s.pending[change.ID] = pending
+	}
+
+	if err := s.backend.save(PendingFilename, s.pending); err != nil {
+		return errors.Wrap(err, "save pending changes")
+	}
+
+	return nil
+}
+
+func (s *Store) readPending() response {
+	if err := s.loadItems(); err != nil {
+		return response{
+			err: errors.Wrap(err, "load items"),
+		}
+	}
+
+	if err := s.loadPending(); err != nil {
+		return response{
+			err: errors.Wrap(err, "load pending changes"),
+		}
+	}
+
+	pending := make([]PendingChange, 0, len(s.pending))
+	for id, change := range s.pending {
+		if item, found := s.items[id]; found {
+			change.Item = &item
+		}
+		pending = append(pending, change)
+	}
+	slices.SortFunc(pending, func(a, b PendingChange) int {
+		return a.Time.Compare(b.Time)
+	})
+
+	return response{
+		pending: pending,
+	}
+}
+
+// Pending returns the item changes made since the last sync, oldest first.
+func (s *Store) Pending() ([]PendingChange, error) {
+	responseChan := make(chan response, 1)
+	req := request{
+		action:       "readPending",
+		responseChan: responseChan,
+	}
+	res := s.send(req)
+
+	return res.pending, res.err
+}
+
+func (s *Store) clearPending(ids []ItemID) response {
+	if err := s.loadItems(); err != nil {
+		return response{
+			err: errors.Wrap(err, "load items"),
+		}
+	}
+
+	if err := s.loadPending(); err != nil {
+		return response{
+			err: errors.Wrap(err, "load pending changes"),
+		}
+	}
+
+	for _, id := range ids {
+		delete(s.pending, id)
+	}
+
+	if err := s.backend.save(PendingFilename, s.pending); err != nil {
+		return response{
+			err: errors.Wrap(err, "save pending changes"),
+		}
+	}
+
+	return response{}
+}
+
+// ClearPending forgets the changes of the items once they are synced.
+func (s *Store) ClearPending(ids ...ItemID) error {
+	responseChan := make(chan response, 1)
+	req := request{
+		action:       "clearPending",
+		responseChan: responseChan,
+		ids:          ids,
+	}
+	res := s.send(req)
+
+	return res.err
+}
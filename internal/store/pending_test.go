@@ -0,0 +1,88 @@
+package store
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func Test_Pending_KeepsItemAsLastSynced(t *testing.T) {
+	t.Cleanup(setupTest())
+
+	store := newTestStore(WithPendingChanges())
+	item, err := store.Create(Item{Name: "name1"})
+	require.NoError(t, err)
+	require.NoError(t, store.ClearPending(ItemID(item.ID)))
+
+	_, err = store.Update(ItemID(item.ID), Item{Name: "name2"})
+	require.NoError(t, err)
+	updatedItem, err := store.Update(ItemID(item.ID), Item{Name: "name3"})
+	require.NoError(t, err)
+	pending, err := store.Pending()
+
+	assert.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.Equal(t, ItemID(item.ID), pending[0].ID)
+	assert.Equal(t, &item, pending[0].Base)
+	assert.Equal(t, &updatedItem, pending[0].Item)
+}
+
+func Test_Pending_RecordsCreatesAndDeletes(t *testing.T) {
+	t.Cleanup(setupTest())
+
+	store := newTestStore(WithPendingChanges())
+	item1, err := store.Create(Item{Name: "name1"})
+	require.NoError(t, err)
+	item2, err := store.Create(Item{Name: "name2"})
+	require.NoError(t, err)
+	require.NoError(t, store.ClearPending(ItemID(item1.ID)))
+
+	require.NoError(t, store.Delete(ItemID(item1.ID)))
+	pending, err := store.Pending()
+
+	assert.NoError(t, err)
+	require.Len(t, pending, 2)
+	assert.Equal(t, PendingChange{ID: ItemID(item2.ID), Item: &item2, Time: pending[0].Time}, pending[0])
+	assert.Equal(t, PendingChange{ID: ItemID(item1.ID), Base: &item1, Time: pending[1].Time}, pending[1])
+}
+
+func Test_Pending_ForgetsItemsCreatedAndDeletedSinceSync(t *testing.T) {
+	t.Cleanup(setupTest())
+
+	store := newTestStore(WithPendingChanges())
+	item, err := store.Create(Item{Name: "name1"})
+	require.NoError(t, err)
+
+	require.NoError(t, store.Delete(ItemID(item.ID)))
+	pending, err := store.Pending()
+
+	assert.NoError(t, err)
+	assert.Empty(t, pending)
+}
+
+func Test_Pending_RecordsExistingItemsAsCreated(t *testing.T) {
+	t.Cleanup(setupTest())
+
+	item, err := newTestStore().Create(Item{Name: "name1"})
+	require.NoError(t, err)
+
+	pending, err := newTestStore(WithPendingChanges()).Pending()
+
+	assert.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.Equal(t, ItemID(item.ID), pending[0].ID)
+	assert.Nil(t, pending[0].Base)
+}
+
+func Test_Pending_IsEmptyWithoutTracking(t *testing.T) {
+	t.Cleanup(setupTest())
+
+	store := newTestStore()
+	_, err := store.Create(Item{Name: "name1"})
+	require.NoError(t, err)
+
+	pending, err := store.Pending()
+
+	assert.NoError(t, err)
+	assert.Empty(t, pending)
+}
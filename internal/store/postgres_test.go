@@ -0,0 +1,265 @@
+package store
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// postgresServer is a throwaway PostgreSQL server the tests start on first use and stop once they are done.
+var postgresServer struct {
+	once sync.Once
+	url  string
+	// unavailable tells why no server could be started
+	unavailable string
+	stop        func()
+}
+
+func TestMain(m *testing.M) {
+	flag.Parse()
+
+	// The whole suite runs against postgres with go test ./internal/store -backend postgres
+	if *testBackend == BackendPostgres {
+		if _, unavailable := startPostgres(); unavailable != "" {
+			fmt.Printf("skipping the store tests on postgres: %s\n", unavailable)
+			os.Exit(0)
+		}
+	}
+
+	code := m.Run()
+	if postgresServer.stop != nil {
+		postgresServer.stop()
+	}
+	os.Exit(code)
+}
+
+// startPostgres starts a server from the initdb and pg_ctl binaries of a local postgres installation and
+// returns the URL of its postgres database, or why it could not.
+func startPostgres() (string, string) {
+	postgresServer.once.Do(func() {
+		if os.Geteuid() == 0 {
+			postgresServer.unavailable = "postgres does not run as root"
+			return
+		}
+
+		initdb, err := findPostgresBinary("initdb")
+		if err != nil {
+			postgresServer.unavailable = err.Error()
+			return
+		}
+		pgCtl, err := findPostgresBinary("pg_ctl")
+		if err != nil {
+			postgresServer.unavailable = err.Error()
+			return
+		}
+
+		dir, err := os.MkdirTemp("", "store-postgres")
+		if err != nil {
+			postgresServer.unavailable = err.Error()
+			return
+		}
+		data := filepath.Join(dir, "data")
+
+		port, err := freePort()
+		if err != nil {
+			postgresServer.unavailable = err.Error()
+			return
+		}
+
+		if output, err := exec.Command(initdb, "-D", data, "-U", "postgres", "-A", "trust", "-N").CombinedOutput(); err != nil {
+			postgresServer.unavailable = fmt.Sprintf("initdb: %s: %s", err, output)
+			_ = os.RemoveAll(dir)
+			return
+		}
+
+		options := fmt.Sprintf("-p %d -k %s -c listen_addresses=127.0.0.1 -c max_connections=500 -c fsync=off", port, dir)
+		if output, err := exec.Command(pgCtl, "-D", data, "-l", filepath.Join(dir, "log"), "-o", options, "-w", "start").CombinedOutput(); err != nil {
+			postgresServer.unavailable = fmt.Sprintf("pg_ctl start: %s: %s", err, output)
+			_ = os.RemoveAll(dir)
+			return
+		}
+
+		postgresServer.url = fmt.Sprintf("postgres://postgres@127.0.0.1:%d/postgres?sslmode=disable", port)
+		postgresServer.stop = func() {
+			_ = exec.Command(pgCtl, "-D", data, "-m", "immediate", "-w", "stop").Run()
+			_ = os.RemoveAll(dir)
+		}
+	})
+
+	return postgresServer.url, postgresServer.unavailable
+}
+
+func findPostgresBinary(name string) (string, error) {
+	if path, err := exec.LookPath(name); err == nil {
+		return path, nil
+	}
+
+	// Debian and Ubuntu keep the server binaries out of the PATH
+	matches, _ := filepath.Glob(filepath.Join("/usr/lib/postgresql/*/bin", name))
+	if len(matches) > 0 {
+		return matches[len(matches)-1], nil
+	}
+
+	return "", fmt.Errorf("%s not found, install postgres to run these tests", name)
+}
+
+func freePort() (int, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer listener.Close()
+
+	return listener.Addr().(*net.TCPAddr).Port, nil
+}
+
+// postgresSchema returns the name of the schema that stands in for the store directory dir, so that stores in
+// different directories keep apart as they do on the other backends.
+func postgresSchema(dir string) string {
+	sum := sha256.Sum256([]byte(dir))
+	return "store_" + hex.EncodeToString(sum[:8])
+}
+
+// postgresURL returns the URL of the schema for dir on the test server, created when missing, or skips the
+// test when no server could be started.
+func postgresURL(t *testing.T, dir string) string {
+	t.Helper()
+
+	url, unavailable := startPostgres()
+	if unavailable != "" {
+		t.Skip(unavailable)
+	}
+
+	schemaURL, err := createPostgresSchema(url, dir)
+	require.NoError(t, err)
+
+	return schemaURL
+}
+
+func createPostgresSchema(url, dir string) (string, error) {
+	ctx := context.Background()
+	conn, err := pgx.Connect(ctx, url)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close(ctx)
+
+	schema := postgresSchema(dir)
+	if _, err := conn.Exec(ctx, "CREATE SCHEMA IF NOT EXISTS "+schema); err != nil {
+		return "", err
+	}
+
+	return url + "&search_path=" + schema, nil
+}
+
+// dropPostgresSchema removes what the stores in dir saved, the counterpart of removing their files.
+func dropPostgresSchema(dir string) error {
+	ctx := context.Background()
+	conn, err := pgx.Connect(ctx, postgresServer.url)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(ctx)
+
+	_, err = conn.Exec(ctx, "DROP SCHEMA IF EXISTS "+postgresSchema(dir)+" CASCADE")
+	return err
+}
+
+func newPostgresStore(t *testing.T, url string) *Store {
+	t.Helper()
+
+	store := NewStore(WithJanitorInterval(0), WithPostgres(url))
+	t.Cleanup(store.Close)
+
+	return store
+}
+
+func Test_Postgres_KeepsItemsAcrossRestarts(t *testing.T) {
+	url := postgresURL(t, t.TempDir())
+	store := NewStore(WithJanitorInterval(0), WithPostgres(url))
+	item, err := store.Create(Item{Name: "name1", Desc: "desc1", Status: StatusCompleted})
+	require.NoError(t, err)
+	_, err = store.AddComment(ItemID(item.ID), Comment{Author: "user1", Text: "text1"})
+	require.NoError(t, err)
+	store.Close()
+
+	reopened := newPostgresStore(t, url)
+	items, err := reopened.ReadAll()
+
+	assert.NoError(t, err)
+	assert.Equal(t, []Item{item}, items)
+	comments, err := reopened.ReadComments(ItemID(item.ID))
+	assert.NoError(t, err)
+	assert.Len(t, comments, 1)
+}
+
+func Test_Postgres_ReadsChangesOfOtherStores(t *testing.T) {
+	url := postgresURL(t, t.TempDir())
+	store1 := newPostgresStore(t, url)
+	store2 := newPostgresStore(t, url)
+	item, err := store1.Create(Item{Name: "name1", Status: StatusNotStarted})
+	require.NoError(t, err)
+	_, err = store2.ReadAll()
+	require.NoError(t, err)
+
+	updatedItem, err := store1.Update(ItemID(item.ID), Item{Name: "name2", Status: StatusStarted})
+	require.NoError(t, err)
+	actualItem, err := store2.Read(ItemID(item.ID))
+
+	assert.NoError(t, err)
+	assert.Equal(t, updatedItem, actualItem)
+}
+
+func Test_Postgres_DiscardsChangesOfFailedRequests(t *testing.T) {
+	url := postgresURL(t, t.TempDir())
+	backend := &postgresBackend{url: url}
+	t.Cleanup(func() { _ = backend.close() })
+	require.NoError(t, backend.begin())
+	require.NoError(t, backend.saveItems(map[ItemID]Item{"id1": {ID: "id1", Name: "name1"}}))
+	require.NoError(t, backend.save(CommentsFilename, map[ItemID][]Comment{"id1": {{Text: "text1"}}}))
+
+	require.NoError(t, backend.end(false))
+
+	require.NoError(t, backend.begin())
+	defer backend.end(false)
+	items := make(map[ItemID]Item)
+	assert.NoError(t, backend.loadItems(items))
+	assert.Empty(t, items)
+	exists, err := backend.exists(CommentsFilename)
+	assert.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func Test_Postgres_MigratesSchemaOnce(t *testing.T) {
+	url := postgresURL(t, t.TempDir())
+	var wg sync.WaitGroup
+	for range 3 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := newPostgresStore(t, url).ReadAll()
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	ctx := context.Background()
+	conn, err := pgx.Connect(ctx, url)
+	require.NoError(t, err)
+	defer conn.Close(ctx)
+	var versions int
+	require.NoError(t, conn.QueryRow(ctx, `SELECT COUNT(*) FROM schema_migrations`).Scan(&versions))
+
+	assert.Equal(t, len(postgresMigrations), versions)
+}
@@ -0,0 +1,192 @@
+package store
+
+import (
+	"github.com/pkg/errors"
+	"reflect"
+	"slices"
+	"time"
+)
+
+func (s *Store) replicate(events []Event) response {
+	if err := s.loadItems(); err != nil {
+		return response{
+			err: errors.Wrap(err, "load items"),
+		}
+	}
+
+	if err := s.backend.load(CommentsFilename, &s.comments); err != nil {
+		return response{
+			err: errors.Wrap(err, "load comments"),
+		}
+	}
+
+	if err := s.backend.load(HistoryFilename, &s.history); err != nil {
+		return response{
+			err: errors.Wrap(err, "load history"),
+		}
+	}
+
+	if err := s.backend.load(MutationsFilename, &s.mutations); err != nil {
+		return response{
+			err: errors.Wrap(err, "load mutations"),
+		}
+	}
+
+	if err := s.backend.load(TrashFilename, &s.trash); err != nil {
+		return response{
+			err: errors.Wrap(err, "load trash"),
+		}
+	}
+
+	if err := s.backend.load(ArchiveFilename, &s.archive); err != nil {
+		return response{
+			err: errors.Wrap(err, "load archive"),
+		}
+	}
+
+	now := time.Now().UTC()
+	var replicated []Change
+	for _, event := range events {
+		var before *Item
+		if item, found := s.items[event.ItemID]; found {
+			before = &item
+		}
+
+		switch event.Type {
+		case EventComment:
+			if event.Comment == nil {
+				return response{
+					err: errors.Errorf("comment event %d carries no comment", event.ID),
+				}
+			}
+			comments := s.comments[event.ItemID]
+			if before != nil && !slices.ContainsFunc(comments, func(comment Comment) bool { return comment.ID == event.Comment.ID }) {
+				s.comments[event.ItemID] = append(comments, *event.Comment)
+			}
+			s.queueEvent(event)
+			continue
+
+		case EventDelete:
+			// Snapshots carry the items the other store trashed, events only name them
+			item := event.Item
+			if before != nil {
+				item = before
+			}
+			if _, trashed := s.trash[event.ItemID]; item == nil || (before == nil && trashed) {
+				continue
+			}
+			delete(s.items, event.ItemID)
+			s.trash[event.ItemID] = TrashedItem{Item: *item, DeletedAt: event.Time}
+			s.history[event.ItemID] = append(s.history[event.ItemID], Activity{Time: now, Message: "deleted"})
+			replicated = append(replicated, Change{ID: event.ItemID, Before: before})
+
+		case EventArchive:
+			if event.Item == nil {
+				return response{
+					err: errors.Errorf("%s event %d carries no item", event.Type, event.ID),
+				}
+			}
+			if _, archived := s.archive[event.ItemID]; before == nil && archived {
+				continue
+			}
+			delete(s.items, event.ItemID)
+			s.archive[event.ItemID] = *event.Item
+			s.history[event.ItemID] = append(s.history[event.ItemID], Activity{Time: now, Message: "archived"})
+			replicated = append(replicated, Change{ID: event.ItemID, Before: before, After: event.Item})
+
+		default:
+			if event.Item == nil {
+				return response{
+					err: errors.Errorf("%s event %d carries no item", event.Type, event.ID),
+				}
+			}
+			// Events read before a snapshot was taken may be older than the item
+			if before != nil && (before.Version > event.Item.Version || reflect.DeepEqual(*before, *event.Item)) {
+				continue
+			}
+
+			// Items restored from the trash or unarchived come back to the item list
+			_, trashed := s.trash[event.ItemID]
+			if archived, found := s.archive[event.ItemID]; before == nil && found {
+				before = &archived
+			}
+			delete(s.trash, event.ItemID)
+			delete(s.archive, event.ItemID)
+
+			messages := []string{"created"}
+			switch {
+			case event.Type == EventUnarchive:
+				messages = []string{"unarchived"}
+			case before != nil:
+				messages = changes(*before, *event.Item)
+			case trashed:
+				messages = []string{"restored from trash"}
+			}
+			for _, message := range messages {
+				s.history[event.ItemID] = append(s.history[event.ItemID], Activity{Time: now, Message: message})
+			}
+			s.items[event.ItemID] = *event.Item
+			replicated = append(replicated, Change{ID: event.ItemID, Before: before, After: event.Item})
+		}
+
+		s.queueEvent(event)
+	}
+
+	if err := s.saveItems(); err != nil {
+		return response{
+			err: errors.Wrap(err, "save items"),
+		}
+	}
+
+	if err := s.backend.save(CommentsFilename, s.comments); err != nil {
+		return response{
+			err: errors.Wrap(err, "save comments"),
+		}
+	}
+
+	if err := s.backend.save(HistoryFilename, s.history); err != nil {
+		return response{
+			err: errors.Wrap(err, "save history"),
+		}
+	}
+
+	if err := s.backend.save(TrashFilename, s.trash); err != nil {
+		return response{
+			err: errors.Wrap(err, "save trash"),
+		}
+	}
+
+	if err := s.backend.save(ArchiveFilename, s.archive); err != nil {
+		return response{
+			err: errors.Wrap(err, "save archive"),
+		}
+	}
+
+	// Replicated changes are journaled for restores, but are not undone by this store
+	if len(replicated) > 0 {
+		s.appendJournal(Mutation{Time: now, Action: "replicate", Changes: replicated})
+		if err := s.backend.save(MutationsFilename, s.mutations); err != nil {
+			return response{
+				err: errors.Wrap(err, "save mutations"),
+			}
+		}
+	}
+
+	return response{}
+}
+
+// Replicate applies the events another store published, so that this store holds the items and comments
+// as that store has them. Items the other store deleted or archived move to the trash or archive, a delete
+// event carrying the item trashes it even when this store never had it. Applying an event twice is harmless,
+// the events are published again by this store.
+func (s *Store) Replicate(events ...Event) error {
+	responseChan := make(chan response, 1)
+	req := request{
+		action:       "replicate",
+		responseChan: responseChan,
+		events:       events,
+	}
+	res := s.send(req)
+
+	return res.err
+}
@@ -0,0 +1,118 @@
+package store
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func Test_Replicate_AppliesEventsOfAnotherStore(t *testing.T) {
+	t.Cleanup(setupTest())
+
+	leader := NewStore(WithDir(t.TempDir()), WithJanitorInterval(0))
+	t.Cleanup(leader.Close)
+	sub, err := leader.Subscribe(0)
+	require.NoError(t, err)
+	t.Cleanup(sub.Close)
+	store := newTestStore()
+
+	item1, err := leader.Create(Item{Name: "name1"})
+	require.NoError(t, err)
+	item1, err = leader.Update(ItemID(item1.ID), Item{Name: "name2"})
+	require.NoError(t, err)
+	comment, err := leader.AddComment(ItemID(item1.ID), Comment{Author: "user1", Text: "text1"})
+	require.NoError(t, err)
+	item2, err := leader.Create(Item{Name: "name3"})
+	require.NoError(t, err)
+	require.NoError(t, leader.Delete(ItemID(item2.ID)))
+	events := make([]Event, 5)
+	for i := range events {
+		events[i] = receive(t, sub)
+	}
+
+	require.NoError(t, store.Replicate(events...))
+	require.NoError(t, store.Replicate(events...))
+
+	items, err := store.ReadAll()
+	assert.NoError(t, err)
+	assert.Equal(t, []Item{item1}, items)
+	comments, err := store.ReadComments(ItemID(item1.ID))
+	assert.NoError(t, err)
+	assert.Equal(t, []Comment{comment}, comments)
+	history, err := store.ReadHistory(ItemID(item1.ID))
+	assert.NoError(t, err)
+	assert.Len(t, history, 2)
+	trash, err := store.ReadTrash()
+	assert.NoError(t, err)
+	require.Len(t, trash, 1)
+	assert.Equal(t, item2, trash[0].Item)
+}
+
+func Test_Replicate_MovesItemsBetweenListTrashAndArchive(t *testing.T) {
+	t.Cleanup(setupTest())
+
+	leader := NewStore(WithDir(t.TempDir()), WithJanitorInterval(0))
+	t.Cleanup(leader.Close)
+	sub, err := leader.Subscribe(0)
+	require.NoError(t, err)
+	t.Cleanup(sub.Close)
+	store := newTestStore()
+
+	item1, err := leader.Create(Item{Name: "name1"})
+	require.NoError(t, err)
+	item2, err := leader.Create(Item{Name: "name2"})
+	require.NoError(t, err)
+	require.NoError(t, leader.Delete(ItemID(item1.ID)))
+	archived, err := leader.Archive(ItemID(item2.ID))
+	require.NoError(t, err)
+	events := make([]Event, 4)
+	for i := range events {
+		events[i] = receive(t, sub)
+	}
+	require.NoError(t, store.Replicate(events...))
+
+	items, err := store.ReadAll()
+	assert.NoError(t, err)
+	assert.Empty(t, items)
+	trash, err := store.ReadTrash()
+	assert.NoError(t, err)
+	require.Len(t, trash, 1)
+	assert.Equal(t, item1, trash[0].Item)
+	archive, err := store.ReadArchived()
+	assert.NoError(t, err)
+	assert.Equal(t, []Item{archived}, archive)
+
+	_, err = leader.RestoreDeleted(ItemID(item1.ID))
+	require.NoError(t, err)
+	unarchived, err := leader.Unarchive(ItemID(item2.ID))
+	require.NoError(t, err)
+	require.NoError(t, store.Replicate(receive(t, sub), receive(t, sub)))
+
+	items, err = store.ReadAll()
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []Item{item1, unarchived}, items)
+	trash, err = store.ReadTrash()
+	assert.NoError(t, err)
+	assert.Empty(t, trash)
+	archive, err = store.ReadArchived()
+	assert.NoError(t, err)
+	assert.Empty(t, archive)
+}
+
+func Test_Replicate_KeepsNewerItems(t *testing.T) {
+	t.Cleanup(setupTest())
+
+	store := newTestStore()
+	item, err := store.Create(Item{Name: "name1"})
+	require.NoError(t, err)
+	item, err = store.Update(ItemID(item.ID), Item{Name: "name2"})
+	require.NoError(t, err)
+	older := item
+	older.Name, older.Version = "name1", 1
+
+	require.NoError(t, store.Replicate(Event{ID: 1, Type: EventUpdate, ItemID: ItemID(item.ID), Item: &older}))
+
+	actualItem, err := store.Read(ItemID(item.ID))
+	assert.NoError(t, err)
+	assert.Equal(t, item, actualItem)
+}
@@ -0,0 +1,351 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	"maps"
+	"reflect"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+const SQLiteFilename = "items.db"
+
+// migrations create the schema of the SQLite database, the user_version pragma of the database is the number
+// of migrations applied. Append new migrations, never change applied ones.
+var migrations = []string{
+	`CREATE TABLE items (
+		id           TEXT PRIMARY KEY,
+		name         TEXT NOT NULL,
+		description  TEXT NOT NULL,
+		status       TEXT NOT NULL,
+		assignee     TEXT NOT NULL DEFAULT '',
+		version      INTEGER NOT NULL DEFAULT 0,
+		completed_at TEXT,
+		archived_at  TEXT
+	)`,
+	`CREATE TABLE documents (
+		name TEXT PRIMARY KEY,
+		data BLOB NOT NULL
+	)`,
+	`CREATE INDEX items_status ON items (status)`,
+	`CREATE INDEX items_completed_at ON items (completed_at)`,
+	`CREATE TABLE revision (
+		id       INTEGER PRIMARY KEY CHECK (id = 1),
+		revision INTEGER NOT NULL
+	)`,
+	`INSERT INTO revision (id, revision) VALUES (1, 0)`,
+}
+
+// WithSQLite makes the store keep its items in a SQLite database in its directory instead of JSON files.
+// Items are rows of their own, so a save only writes the items that changed. The other collections are kept
+// as JSON documents in the same database.
+func WithSQLite() Option {
+	return func(s *Store) {
+		s.backendName = BackendSQLite
+	}
+}
+
+var _ backend = (*sqliteBackend)(nil)
+
+// sqliteBackend opens the database for every request and runs the request in a transaction that holds the
+// write lock, so that requests of stores in other processes wait for it. It keeps the items as last saved
+// together with the revision of the database they were saved at, and reads them again only when another
+// store saved items since.
+type sqliteBackend struct {
+	path          string
+	db            *sql.DB
+	tx            *sql.Tx
+	migrated      bool
+	created       bool
+	revision      int64
+	saved         map[ItemID]Item
+	savedRevision int64
+}
+
+func (b *sqliteBackend) begin() error {
+	db, err := sql.Open("sqlite", b.path+"?_pragma=busy_timeout(5000)&_txlock=immediate")
+	if err != nil {
+		return errors.Wrapf(err, "open %s", b.path)
+	}
+	db.SetMaxOpenConns(1)
+
+	if !b.migrated {
+		applied, err := migrate(db)
+		if err != nil {
+			_ = db.Close()
+			return errors.Wrap(err, "migrate")
+		}
+		b.migrated = true
+		b.created = applied == len(migrations)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		_ = db.Close()
+		return errors.Wrap(err, "begin")
+	}
+
+	if err := tx.QueryRow(`SELECT revision FROM revision WHERE id = 1`).Scan(&b.revision); err != nil {
+		_ = tx.Rollback()
+		_ = db.Close()
+		return errors.Wrap(err, "read revision")
+	}
+
+	b.db, b.tx = db, tx
+
+	return nil
+}
+
+func (b *sqliteBackend) end(commit bool) error {
+	if b.tx == nil {
+		return nil
+	}
+
+	db, tx := b.db, b.tx
+	b.db, b.tx = nil, nil
+	defer db.Close()
+
+	if commit {
+		if err := tx.Commit(); err != nil {
+			b.saved = nil
+			return errors.Wrap(err, "commit")
+		}
+		b.created = false
+		return nil
+	}
+
+	// The items saved by the request are gone again, a new database starts over with the next request
+	b.saved = nil
+	return errors.Wrap(tx.Rollback(), "rollback")
+}
+
+// migrate applies the migrations the database lacks and returns how many it applied.
+func migrate(db *sql.DB) (int, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, errors.Wrap(err, "begin")
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	var version int
+	if err := tx.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return 0, errors.Wrap(err, "read schema version")
+	}
+	if version > len(migrations) {
+		return 0, errors.Errorf("schema version %d is newer than %d", version, len(migrations))
+	}
+	if version == len(migrations) {
+		return 0, nil
+	}
+
+	for i, migration := range migrations[version:] {
+		if _, err := tx.Exec(migration); err != nil {
+			return 0, errors.Wrapf(err, "apply migration %d", version+i+1)
+		}
+	}
+
+	if _, err := tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", len(migrations))); err != nil {
+		return 0, errors.Wrap(err, "write schema version")
+	}
+
+	return len(migrations) - version, errors.Wrap(tx.Commit(), "commit")
+}
+
+// conn returns the transaction of the current request.
+func (b *sqliteBackend) conn() (*sql.Tx, error) {
+	if b.tx == nil {
+		return nil, errors.Errorf("%s is only open during a request", b.path)
+	}
+
+	return b.tx, nil
+}
+
+// refresh reads the items again when another store saved them since they were last read.
+func (b *sqliteBackend) refresh(tx *sql.Tx) error {
+	if b.saved != nil && b.savedRevision == b.revision {
+		return nil
+	}
+
+	rows, err := tx.Query(`SELECT id, name, description, status, assignee, version, completed_at, archived_at FROM items`)
+	if err != nil {
+		return errors.Wrap(err, "query items")
+	}
+	defer rows.Close()
+
+	saved := make(map[ItemID]Item)
+	for rows.Next() {
+		var (
+			item                    Item
+			completedAt, archivedAt sql.NullString
+		)
+		if err := rows.Scan(&item.ID, &item.Name, &item.Desc, &item.Status, &item.Assignee, &item.Version, &completedAt, &archivedAt); err != nil {
+			return errors.Wrap(err, "scan item")
+		}
+		if item.CompletedAt, err = parseTime(completedAt); err != nil {
+			return errors.Wrapf(err, "parse completed_at of item '%s'", item.ID)
+		}
+		if item.ArchivedAt, err = parseTime(archivedAt); err != nil {
+			return errors.Wrapf(err, "parse archived_at of item '%s'", item.ID)
+		}
+		saved[ItemID(item.ID)] = item
+	}
+	if err := rows.Err(); err != nil {
+		return errors.Wrap(err, "read items")
+	}
+
+	b.saved = saved
+	b.savedRevision = b.revision
+
+	return nil
+}
+
+func (b *sqliteBackend) loadItems(items map[ItemID]Item) error {
+	tx, err := b.conn()
+	if err != nil {
+		return err
+	}
+
+	// Like a missing items file, a new database starts with the items the store holds
+	if b.created {
+		return b.saveItems(items)
+	}
+
+	if err := b.refresh(tx); err != nil {
+		return err
+	}
+
+	clear(items)
+	maps.Copy(items, b.saved)
+
+	return nil
+}
+
+func (b *sqliteBackend) saveItems(items map[ItemID]Item) error {
+	tx, err := b.conn()
+	if err != nil {
+		return err
+	}
+
+	if err := b.refresh(tx); err != nil {
+		return err
+	}
+
+	for id, item := range items {
+		if saved, found := b.saved[id]; found && reflect.DeepEqual(saved, item) {
+			continue
+		}
+		if _, err := tx.Exec(`INSERT INTO items (id, name, description, status, assignee, version, completed_at, archived_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT (id) DO UPDATE SET name = excluded.name, description = excluded.description,
+				status = excluded.status, assignee = excluded.assignee, version = excluded.version,
+				completed_at = excluded.completed_at, archived_at = excluded.archived_at`,
+			string(id), item.Name, item.Desc, item.Status, item.Assignee, item.Version,
+			formatTime(item.CompletedAt), formatTime(item.ArchivedAt)); err != nil {
+			return errors.Wrapf(err, "save item '%s'", id)
+		}
+	}
+
+	for id := range b.saved {
+		if _, found := items[id]; found {
+			continue
+		}
+		if _, err := tx.Exec(`DELETE FROM items WHERE id = ?`, string(id)); err != nil {
+			return errors.Wrapf(err, "delete item '%s'", id)
+		}
+	}
+
+	if err := tx.QueryRow(`UPDATE revision SET revision = revision + 1 WHERE id = 1 RETURNING revision`).Scan(&b.revision); err != nil {
+		return errors.Wrap(err, "advance revision")
+	}
+
+	b.saved = maps.Clone(items)
+	b.savedRevision = b.revision
+
+	return nil
+}
+
+func (b *sqliteBackend) load(name string, v any) error {
+	tx, err := b.conn()
+	if err != nil {
+		return err
+	}
+
+	var data []byte
+	err = tx.QueryRow(`SELECT data FROM documents WHERE name = ?`, name).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrapf(err, "read %s", name)
+	}
+
+	if err := decode(data, v); err != nil {
+		return errors.Wrapf(err, "decode %s", name)
+	}
+
+	return nil
+}
+
+func (b *sqliteBackend) save(name string, v any) error {
+	tx, err := b.conn()
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return errors.Wrapf(err, "encode %s", name)
+	}
+
+	if _, err := tx.Exec(`INSERT INTO documents (name, data) VALUES (?, ?)
+		ON CONFLICT (name) DO UPDATE SET data = excluded.data`, name, data); err != nil {
+		return errors.Wrapf(err, "write %s", name)
+	}
+
+	return nil
+}
+
+func (b *sqliteBackend) exists(name string) (bool, error) {
+	tx, err := b.conn()
+	if err != nil {
+		return false, err
+	}
+
+	var count int
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM documents WHERE name = ?`, name).Scan(&count); err != nil {
+		return false, errors.Wrapf(err, "read %s", name)
+	}
+
+	return count > 0, nil
+}
+
+func (b *sqliteBackend) close() error {
+	b.saved = nil
+	return nil
+}
+
+func formatTime(t *time.Time) sql.NullString {
+	if t == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: t.Format(time.RFC3339Nano), Valid: true}
+}
+
+func parseTime(s sql.NullString) (*time.Time, error) {
+	if !s.Valid {
+		return nil, nil
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, s.String)
+	if err != nil {
+		return nil, err
+	}
+
+	return &t, nil
+}
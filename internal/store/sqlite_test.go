@@ -0,0 +1,99 @@
+package store
+
+import (
+	"database/sql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newSQLiteStore(t *testing.T, dir string) *Store {
+	t.Helper()
+
+	store := NewStore(WithDir(dir), WithJanitorInterval(0), WithSQLite())
+	t.Cleanup(store.Close)
+
+	return store
+}
+
+func Test_SQLite_KeepsItemsAcrossRestarts(t *testing.T) {
+	dir := t.TempDir()
+	store := NewStore(WithDir(dir), WithJanitorInterval(0), WithSQLite())
+	item, err := store.Create(Item{Name: "name1", Desc: "desc1", Status: StatusNotStarted})
+	require.NoError(t, err)
+	completedItem, err := store.Create(Item{Name: "name2", Status: StatusCompleted})
+	require.NoError(t, err)
+	_, err = store.AddComment(ItemID(item.ID), Comment{Author: "user1", Text: "text1"})
+	require.NoError(t, err)
+	store.Close()
+
+	reopened := newSQLiteStore(t, dir)
+	items, err := reopened.ReadAll()
+
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []Item{item, completedItem}, items)
+	comments, err := reopened.ReadComments(ItemID(item.ID))
+	assert.NoError(t, err)
+	assert.Len(t, comments, 1)
+}
+
+func Test_SQLite_ReadsChangesOfOtherStores(t *testing.T) {
+	dir := t.TempDir()
+	store1 := newSQLiteStore(t, dir)
+	store2 := newSQLiteStore(t, dir)
+	item, err := store1.Create(Item{Name: "name1", Status: StatusNotStarted})
+	require.NoError(t, err)
+	_, err = store2.ReadAll()
+	require.NoError(t, err)
+
+	updatedItem, err := store1.Update(ItemID(item.ID), Item{Name: "name2", Status: StatusStarted})
+	require.NoError(t, err)
+	actualItem, err := store2.Read(ItemID(item.ID))
+
+	assert.NoError(t, err)
+	assert.Equal(t, updatedItem, actualItem)
+}
+
+func Test_SQLite_MigratesSchemaOnce(t *testing.T) {
+	dir := t.TempDir()
+	_, err := newSQLiteStore(t, dir).ReadAll()
+	require.NoError(t, err)
+	_, err = newSQLiteStore(t, dir).ReadAll()
+	require.NoError(t, err)
+
+	db, err := sql.Open("sqlite", filepath.Join(dir, SQLiteFilename))
+	require.NoError(t, err)
+	defer db.Close()
+	var version int
+	require.NoError(t, db.QueryRow("PRAGMA user_version").Scan(&version))
+	var indexes []string
+	rows, err := db.Query(`SELECT name FROM sqlite_master WHERE type = 'index' AND tbl_name = 'items' AND sql IS NOT NULL`)
+	require.NoError(t, err)
+	defer rows.Close()
+	for rows.Next() {
+		var index string
+		require.NoError(t, rows.Scan(&index))
+		indexes = append(indexes, index)
+	}
+
+	assert.Equal(t, len(migrations), version)
+	assert.ElementsMatch(t, []string{"items_status", "items_completed_at"}, indexes)
+}
+
+func Test_SQLite_KeepsCompletionTime(t *testing.T) {
+	dir := t.TempDir()
+	store := NewStore(WithDir(dir), WithJanitorInterval(0), WithSQLite())
+	item, err := store.Create(Item{Name: "name1", Status: StatusCompleted})
+	require.NoError(t, err)
+	require.NotNil(t, item.CompletedAt)
+	store.Close()
+
+	actualItem, err := newSQLiteStore(t, dir).Read(ItemID(item.ID))
+
+	assert.NoError(t, err)
+	require.NotNil(t, actualItem.CompletedAt)
+	assert.True(t, item.CompletedAt.Equal(*actualItem.CompletedAt))
+	assert.Equal(t, time.UTC, actualItem.CompletedAt.Location())
+}
@@ -1,25 +1,50 @@
 package store
 
 import (
-	"encoding/json"
 	"fmt"
 	"github.com/google/uuid"
 	"github.com/pkg/errors"
-	"io/fs"
-	"os"
+	"log/slog"
+	"sync"
+	"time"
 )
 
 type Item struct {
-	ID     string `json:"id"`
-	Name   string `json:"name"`
-	Desc   string `json:"description"`
-	Status string `json:"status"`
+	ID          string     `json:"id"`
+	Name        string     `json:"name"`
+	Desc        string     `json:"description"`
+	Status      string     `json:"status"`
+	Assignee    string     `json:"assignee,omitempty"`
+	Version     int64      `json:"version,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	ArchivedAt  *time.Time `json:"archived_at,omitempty"`
 }
 
 func (item Item) String() string {
-	return fmt.Sprintf("Name: %s, Description: %s, Status: %s", item.Name, item.Desc, item.Status)
+	if item.Assignee == "" {
+		return fmt.Sprintf("Name: %s, Description: %s, Status: %s", item.Name, item.Desc, item.Status)
+	}
+	return fmt.Sprintf("Name: %s, Description: %s, Status: %s, Assignee: %s", item.Name, item.Desc, item.Status, item.Assignee)
 }
 
+const (
+	StatusNotStarted = "not started"
+	StatusStarted    = "started"
+	StatusCompleted  = "completed"
+)
+
+var (
+	ErrNotFound            = errors.New("not found")
+	ErrHistoryUnavailable  = errors.New("history unavailable")
+	ErrIdempotencyConflict = errors.New("idempotency conflict")
+	ErrVersionConflict     = errors.New("version conflict")
+	ErrNothingToUndo       = errors.New("nothing to undo")
+	ErrNothingToRedo       = errors.New("nothing to redo")
+	ErrAlreadyExists       = errors.New("already exists")
+	ErrReadOnly            = errors.New("store is read-only")
+	ErrClosed              = errors.New("store is closed")
+)
+
 type ItemID string
 
 func NewItemID() ItemID {
@@ -27,50 +52,355 @@ func NewItemID() ItemID {
 }
 
 type response struct {
-	items []Item
-	item  Item
-	err   error
+	items        []Item
+	item         Item
+	comments     []Comment
+	comment      Comment
+	history      []Activity
+	trash        []TrashedItem
+	results      []OperationResult
+	replayed     bool
+	subscription *Subscription
+	webhook      Webhook
+	webhooks     []Webhook
+	deliveries   []Delivery
+	records      []ItemRecord
+	pending      []PendingChange
+	migration    ItemsMigration
+	err          error
 }
 
 type request struct {
-	action       string
-	responseChan chan response
-	id           ItemID
-	item         Item
+	action          string
+	responseChan    chan response
+	id              ItemID
+	item            Item
+	assignee        string
+	comment         Comment
+	at              time.Time
+	operations      []Operation
+	idempotencyUser string
+	idempotencyKey  string
+	lastEventID     uint64
+	subscriptionID  uint64
+	webhook         Webhook
+	webhookID       string
+	delivery        Delivery
+	ids             []ItemID
+	records         []ItemRecord
+	dryRun          bool
+	encryptionKey   *EncryptionKey
+	events          []Event
 }
 
 type Store struct {
-	items       map[ItemID]Item
-	requestChan chan request
+	items           map[ItemID]Item
+	comments        map[ItemID][]Comment
+	history         map[ItemID][]Activity
+	mutations       mutationLog
+	trash           map[ItemID]TrashedItem
+	trashRetention  time.Duration
+	archive         map[ItemID]Item
+	archiveAfter    time.Duration
+	janitorEvery    time.Duration
+	janitorStart    chan time.Duration
+	idempotency     idempotencyKeys
+	idempotencyTTL  time.Duration
+	events          []Event
+	unpublished     []Event
+	eventSeq        uint64
+	subscribers     map[uint64]chan Event
+	subscriptionSeq uint64
+	webhooks        map[string]Webhook
+	deliveries      map[string][]Delivery
+	pending         map[ItemID]PendingChange
+	trackPending    bool
+	dir             string
+	backendName     string
+	postgresURL     string
+	encryptionKey   *EncryptionKey
+	backend         backend
+	backendErr      error
+	requestChan     chan request
+	done            chan struct{}
+	closeOnce       sync.Once
+	closeMu         sync.RWMutex
+	closed          bool
+	janitor         sync.WaitGroup
+	processed       chan struct{}
+}
+
+const (
+	ItemsFilename          = "items.json"
+	DefaultJanitorInterval = time.Hour
+)
+
+type Option func(*Store)
+
+// WithDir sets the directory the store keeps its files in, the working directory by default.
+func WithDir(dir string) Option {
+	return func(s *Store) {
+		s.dir = dir
+	}
 }
 
-const ItemsFilename = "items.json"
+func WithJanitorInterval(interval time.Duration) Option {
+	return func(s *Store) {
+		s.janitorEvery = interval
+	}
+}
 
-func NewStore() *Store {
+func NewStore(opts ...Option) *Store {
 	s := &Store{
-		items:       make(map[ItemID]Item),
+		items:          make(map[ItemID]Item),
+		comments:       make(map[ItemID][]Comment),
+		history:        make(map[ItemID][]Activity),
+		trash:          make(map[ItemID]TrashedItem),
+		trashRetention: DefaultTrashRetention,
+		archive:        make(map[ItemID]Item),
+		archiveAfter:   DefaultArchiveAfter,
+		janitorEvery:   DefaultJanitorInterval,
+		janitorStart:   make(chan time.Duration),
+		idempotency:    make(idempotencyKeys),
+		idempotencyTTL: DefaultIdempotencyTTL,
+		// Event IDs start from the current time so they keep increasing across restarts
+		eventSeq:    uint64(time.Now().UnixMicro()),
+		subscribers: make(map[uint64]chan Event),
+		webhooks:    make(map[string]Webhook),
+		deliveries:  make(map[string][]Delivery),
+		pending:     make(map[ItemID]PendingChange),
 		requestChan: make(chan request, 100),
+		done:        make(chan struct{}),
+		processed:   make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(s)
 	}
 
+	s.backend, s.backendErr = newBackend(s.backendName, s.dir, s.postgresURL, s.encryptionKey)
+
 	go s.processRequests()
+	s.janitor.Add(1)
+	go s.runJanitor()
 
 	return s
 }
 
+// Close stops the janitor, lets the requests in flight finish and closes the backend. Requests made after
+// Close fail with ErrClosed, closing twice does nothing.
+func (s *Store) Close() {
+	s.closeOnce.Do(func() {
+		close(s.done)
+		s.janitor.Wait()
+
+		s.closeMu.Lock()
+		s.closed = true
+		close(s.requestChan)
+		s.closeMu.Unlock()
+		<-s.processed
+
+		if s.backend != nil {
+			if err := s.backend.close(); err != nil {
+				slog.Error(err.Error())
+			}
+		}
+	})
+}
+
+// send hands the request to the goroutine processing requests and waits for its response.
+func (s *Store) send(req request) response {
+	s.closeMu.RLock()
+	defer s.closeMu.RUnlock()
+
+	if s.closed {
+		return response{err: ErrClosed}
+	}
+	s.requestChan <- req
+
+	return <-req.responseChan
+}
+
+// StartJanitor starts the janitor of a store made without one, such as a follower once it is promoted. A
+// store whose janitor runs already keeps its interval.
+func (s *Store) StartJanitor(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	select {
+	case s.janitorStart <- interval:
+	case <-s.done:
+	}
+}
+
+func (s *Store) runJanitor() {
+	defer s.janitor.Done()
+
+	// Without a ticker the janitor waits to be started
+	var ticker *time.Ticker
+	var ticks <-chan time.Time
+	defer func() {
+		if ticker != nil {
+			ticker.Stop()
+		}
+	}()
+	if s.janitorEvery > 0 {
+		ticker = time.NewTicker(s.janitorEvery)
+		ticks = ticker.C
+	}
+
+	for {
+		select {
+		case <-ticks:
+			if err := s.PurgeTrash(); err != nil {
+				slog.Error(err.Error())
+			}
+			if _, err := s.ArchiveCompleted(); err != nil {
+				slog.Error(err.Error())
+			}
+			if err := s.PruneIdempotencyKeys(); err != nil {
+				slog.Error(err.Error())
+			}
+		case interval := <-s.janitorStart:
+			if ticker == nil {
+				ticker = time.NewTicker(interval)
+				ticks = ticker.C
+			}
+		case <-s.done:
+			return
+		}
+	}
+}
+
 func (s *Store) processRequests() {
+	defer close(s.processed)
+	// Subscribers learn the store is gone from their channel being closed
+	defer func() {
+		for id := range s.subscribers {
+			s.unsubscribe(id)
+		}
+	}()
+
 	for req := range s.requestChan {
-		switch req.action {
-		case "create":
-			req.responseChan <- s.create(req.item)
-		case "readAll":
-			req.responseChan <- s.readAll()
-		case "read":
-			req.responseChan <- s.read(req.id)
-		case "update":
-			req.responseChan <- s.update(req.id, req.item)
-		case "delete":
-			req.responseChan <- s.delete(req.id)
+		// A store configured with a backend it cannot have fails every request
+		if s.backendErr != nil {
+			req.responseChan <- response{err: s.backendErr}
+			continue
+		}
+
+		// On backends with transactions a request that fails leaves no changes behind
+		if err := s.backend.begin(); err != nil {
+			req.responseChan <- response{err: errors.Wrap(err, "begin")}
+			continue
+		}
+		res := s.handle(req)
+		if err := s.backend.end(res.err == nil); err != nil && res.err == nil {
+			res = response{err: errors.Wrap(err, "commit")}
 		}
+		if res.err != nil {
+			s.discard()
+		}
+		s.flushEvents(res.err == nil)
+		req.responseChan <- res
+	}
+}
+
+// discard forgets the collections a request that failed may have changed, so that the next request reads
+// them as the backend has them. The items are kept, every request reads them again unless the backend holds
+// none yet, in which case the store starts with the items it holds.
+func (s *Store) discard() {
+	s.comments = make(map[ItemID][]Comment)
+	s.history = make(map[ItemID][]Activity)
+	s.mutations = mutationLog{}
+	s.trash = make(map[ItemID]TrashedItem)
+	s.archive = make(map[ItemID]Item)
+	s.idempotency = make(idempotencyKeys)
+	s.webhooks = make(map[string]Webhook)
+	s.deliveries = make(map[string][]Delivery)
+	s.pending = make(map[ItemID]PendingChange)
+}
+
+func (s *Store) handle(req request) response {
+	switch req.action {
+	case "create":
+		return s.create(req.item)
+	case "readAll":
+		return s.readAll()
+	case "read":
+		return s.read(req.id)
+	case "update":
+		return s.update(req.id, req.item)
+	case "delete":
+		return s.delete(req.id)
+	case "assign":
+		return s.assign(req.id, req.assignee)
+	case "readAssigned":
+		return s.readAssigned(req.assignee)
+	case "addComment":
+		return s.addComment(req.id, req.comment)
+	case "readComments":
+		return s.readComments(req.id)
+	case "readHistory":
+		return s.readHistory(req.id)
+	case "undo":
+		return s.undo()
+	case "redo":
+		return s.redo()
+	case "restore":
+		return s.restore(req.at)
+	case "readTrash":
+		return s.readTrash()
+	case "restoreDeleted":
+		return s.restoreDeleted(req.id)
+	case "purgeTrash":
+		return s.purgeTrash()
+	case "archive":
+		return s.archiveItem(req.id)
+	case "archiveCompleted":
+		return s.archiveCompleted()
+	case "readArchived":
+		return s.readArchived()
+	case "unarchive":
+		return s.unarchive(req.id)
+	case "batch":
+		return s.batch(req.operations)
+	case "createIdempotent":
+		return s.createIdempotent(req.idempotencyUser, req.idempotencyKey, req.item)
+	case "pruneIdempotencyKeys":
+		return s.pruneIdempotencyKeys()
+	case "subscribe":
+		return s.subscribe(req.lastEventID)
+	case "unsubscribe":
+		return s.unsubscribe(req.subscriptionID)
+	case "addWebhook":
+		return s.addWebhook(req.webhook)
+	case "readWebhooks":
+		return s.readWebhooks()
+	case "deleteWebhook":
+		return s.deleteWebhook(req.webhookID)
+	case "recordDelivery":
+		return s.recordDelivery(req.delivery)
+	case "readDeliveries":
+		return s.readDeliveries(req.webhookID)
+	case "export":
+		return s.export(req.ids)
+	case "import":
+		return s.importRecords(req.records)
+	case "evict":
+		return s.evict(req.ids)
+	case "replicate":
+		return s.replicate(req.events)
+	case "readPending":
+		return s.readPending()
+	case "clearPending":
+		return s.clearPending(req.ids)
+	case "migrateItems":
+		return s.migrateItems(req.dryRun)
+	case "rotateKey":
+		return s.rotateKey(req.encryptionKey)
+	default:
+		return response{err: errors.Errorf("unknown action '%s'", req.action)}
 	}
 }
 
@@ -81,8 +411,15 @@ func (s *Store) create(item Item) response {
 		}
 	}
 
-	id := NewItemID()
+	id, err := s.newID(ItemID(item.ID))
+	if err != nil {
+		return response{
+			err: err,
+		}
+	}
+
 	item.ID = string(id)
+	item = withManagedFields(Item{}, item)
 	s.items[id] = item
 
 	if err := s.saveItems(); err != nil {
@@ -91,11 +428,24 @@ func (s *Store) create(item Item) response {
 		}
 	}
 
+	if err := s.recordActivity(id, "created"); err != nil {
+		return response{
+			err: errors.Wrap(err, "record activity"),
+		}
+	}
+
+	if err := s.recordMutation("create", Change{ID: id, After: &item}); err != nil {
+		return response{
+			err: errors.Wrap(err, "record mutation"),
+		}
+	}
+
 	return response{
 		item: item,
 	}
 }
 
+// Create adds an item under a new ID, or under the item ID when it is set and not used by another item.
 func (s *Store) Create(item Item) (Item, error) {
 	responseChan := make(chan response, 1)
 	req := request{
@@ -103,8 +453,7 @@ func (s *Store) Create(item Item) (Item, error) {
 		responseChan: responseChan,
 		item:         item,
 	}
-	s.requestChan <- req
-	res := <-responseChan
+	res := s.send(req)
 
 	return res.item, res.err
 }
@@ -132,8 +481,7 @@ func (s *Store) ReadAll() ([]Item, error) {
 		action:       "readAll",
 		responseChan: responseChan,
 	}
-	s.requestChan <- req
-	res := <-responseChan
+	res := s.send(req)
 
 	return res.items, res.err
 }
@@ -148,7 +496,7 @@ func (s *Store) read(id ItemID) response {
 	item, found := s.items[id]
 	if !found {
 		return response{
-			err: errors.Errorf("item '%s' not found", id),
+			err: errors.Wrapf(ErrNotFound, "item '%s'", id),
 		}
 	}
 
@@ -164,8 +512,7 @@ func (s *Store) Read(id ItemID) (Item, error) {
 		responseChan: responseChan,
 		id:           id,
 	}
-	s.requestChan <- req
-	res := <-responseChan
+	res := s.send(req)
 
 	return res.item, res.err
 }
@@ -177,13 +524,21 @@ func (s *Store) update(id ItemID, item Item) response {
 		}
 	}
 
-	if _, found := s.items[id]; !found {
+	oldItem, found := s.items[id]
+	if !found {
+		return response{
+			err: errors.Wrapf(ErrNotFound, "item '%s'", id),
+		}
+	}
+
+	if err := checkVersion(oldItem, item.Version); err != nil {
 		return response{
-			err: errors.Errorf("item '%s' not found", id),
+			err: err,
 		}
 	}
 
 	item.ID = string(id)
+	item = withManagedFields(oldItem, item)
 	s.items[id] = item
 
 	if err := s.saveItems(); err != nil {
@@ -192,11 +547,24 @@ func (s *Store) update(id ItemID, item Item) response {
 		}
 	}
 
+	if err := s.recordActivity(id, changes(oldItem, item)...); err != nil {
+		return response{
+			err: errors.Wrap(err, "record activity"),
+		}
+	}
+
+	if err := s.recordMutation("update", Change{ID: id, Before: &oldItem, After: &item}); err != nil {
+		return response{
+			err: errors.Wrap(err, "record mutation"),
+		}
+	}
+
 	return response{
 		item: item,
 	}
 }
 
+// Update replaces an item, when the item has a non-zero version it must match the stored version.
 func (s *Store) Update(id ItemID, item Item) (Item, error) {
 	responseChan := make(chan response, 1)
 	req := request{
@@ -205,12 +573,43 @@ func (s *Store) Update(id ItemID, item Item) (Item, error) {
 		id:           id,
 		item:         item,
 	}
-	s.requestChan <- req
-	res := <-responseChan
+	res := s.send(req)
 
 	return res.item, res.err
 }
 
+// newID returns id when it is set and unused, or a new ID when it is empty. IDs of trashed and archived
+// items are in use as those items can come back.
+func (s *Store) newID(id ItemID) (ItemID, error) {
+	if id == "" {
+		return NewItemID(), nil
+	}
+
+	if err := s.backend.load(TrashFilename, &s.trash); err != nil {
+		return "", errors.Wrap(err, "load trash")
+	}
+
+	if err := s.backend.load(ArchiveFilename, &s.archive); err != nil {
+		return "", errors.Wrap(err, "load archive")
+	}
+
+	_, inItems := s.items[id]
+	_, inTrash := s.trash[id]
+	_, inArchive := s.archive[id]
+	if inItems || inTrash || inArchive {
+		return "", errors.Wrapf(ErrAlreadyExists, "item '%s'", id)
+	}
+
+	return id, nil
+}
+
+func checkVersion(item Item, version int64) error {
+	if version != 0 && version != item.Version {
+		return errors.Wrapf(ErrVersionConflict, "item '%s' is at version %d, not %d", item.ID, item.Version, version)
+	}
+	return nil
+}
+
 func (s *Store) delete(id ItemID) response {
 	if err := s.loadItems(); err != nil {
 		return response{
@@ -218,9 +617,16 @@ func (s *Store) delete(id ItemID) response {
 		}
 	}
 
-	if _, found := s.items[id]; !found {
+	oldItem, found := s.items[id]
+	if !found {
+		return response{
+			err: errors.Wrapf(ErrNotFound, "item '%s'", id),
+		}
+	}
+
+	if err := s.trashItems(oldItem); err != nil {
 		return response{
-			err: errors.Errorf("item '%s' not found", id),
+			err: errors.Wrap(err, "trash item"),
 		}
 	}
 
@@ -232,6 +638,18 @@ func (s *Store) delete(id ItemID) response {
 		}
 	}
 
+	if err := s.recordActivity(id, "deleted"); err != nil {
+		return response{
+			err: errors.Wrap(err, "record activity"),
+		}
+	}
+
+	if err := s.recordMutation("delete", Change{ID: id, Before: &oldItem}); err != nil {
+		return response{
+			err: errors.Wrap(err, "record mutation"),
+		}
+	}
+
 	return response{}
 }
 
@@ -242,67 +660,115 @@ func (s *Store) Delete(id ItemID) error {
 		responseChan: responseChan,
 		id:           id,
 	}
-	s.requestChan <- req
-	res := <-responseChan
+	res := s.send(req)
 
 	return res.err
 }
 
-func (s *Store) loadItems() (err error) {
-	var file *os.File
-	file, err = os.Open(ItemsFilename)
-	if err != nil {
-		if !errors.Is(err, fs.ErrNotExist) {
-			return errors.Wrapf(err, "open %s", ItemsFilename)
+func (s *Store) assign(id ItemID, assignee string) response {
+	if err := s.loadItems(); err != nil {
+		return response{
+			err: errors.Wrap(err, "load items"),
 		}
+	}
 
-		if err = s.saveItems(); err != nil {
-			return errors.Wrap(err, "save items")
+	item, found := s.items[id]
+	if !found {
+		return response{
+			err: errors.Wrapf(ErrNotFound, "item '%s'", id),
 		}
+	}
 
-		file, err = os.Open(ItemsFilename)
-		if err != nil {
-			return errors.Wrapf(err, "openb %s", ItemsFilename)
+	oldItem := item
+	item.Assignee = assignee
+	item.Version++
+	s.items[id] = item
+
+	if err := s.saveItems(); err != nil {
+		return response{
+			err: errors.Wrap(err, "save items"),
 		}
 	}
 
-	defer func() {
-		closeErr := file.Close()
-		if err == nil {
-			err = errors.Wrapf(closeErr, "close %s", ItemsFilename)
+	if err := s.recordActivity(id, changes(oldItem, item)...); err != nil {
+		return response{
+			err: errors.Wrap(err, "record activity"),
 		}
-	}()
+	}
 
-	decoder := json.NewDecoder(file)
-	err = decoder.Decode(&s.items)
+	if err := s.recordMutation("assign", Change{ID: id, Before: &oldItem, After: &item}); err != nil {
+		return response{
+			err: errors.Wrap(err, "record mutation"),
+		}
+	}
 
-	if err != nil {
-		return errors.Wrapf(err, "decode %s", ItemsFilename)
+	return response{
+		item: item,
+	}
+}
+
+func (s *Store) Assign(id ItemID, assignee string) (Item, error) {
+	responseChan := make(chan response, 1)
+	req := request{
+		action:       "assign",
+		responseChan: responseChan,
+		id:           id,
+		assignee:     assignee,
 	}
+	res := s.send(req)
 
-	return err
+	return res.item, res.err
 }
 
-func (s *Store) saveItems() (err error) {
-	var file *os.File
-	file, err = os.Create(ItemsFilename)
-	if err != nil {
-		return errors.Wrapf(err, "create %s", ItemsFilename)
+func (s *Store) readAssigned(assignee string) response {
+	if index, ok := s.backend.(assigneeIndex); ok && assignee != "" {
+		items, indexed, err := index.assigned(assignee)
+		if err != nil {
+			return response{
+				err: errors.Wrap(err, "read assignee index"),
+			}
+		}
+		if indexed {
+			return response{
+				items: items,
+			}
+		}
 	}
 
-	defer func() {
-		closeErr := file.Close()
-		if err == nil {
-			err = errors.Wrapf(closeErr, "close %s", ItemsFilename)
+	if err := s.loadItems(); err != nil {
+		return response{
+			err: errors.Wrap(err, "load items"),
 		}
-	}()
+	}
 
-	encoder := json.NewEncoder(file)
-	err = encoder.Encode(s.items)
+	items := make([]Item, 0)
+	for _, item := range s.items {
+		if item.Assignee == assignee {
+			items = append(items, item)
+		}
+	}
 
-	if err != nil {
-		return errors.Wrapf(err, "encode %s", ItemsFilename)
+	return response{
+		items: items,
 	}
+}
+
+func (s *Store) ReadAssigned(assignee string) ([]Item, error) {
+	responseChan := make(chan response, 1)
+	req := request{
+		action:       "readAssigned",
+		responseChan: responseChan,
+		assignee:     assignee,
+	}
+	res := s.send(req)
+
+	return res.items, res.err
+}
+
+func (s *Store) loadItems() error {
+	return s.backend.loadItems(s.items)
+}
 
-	return err
+func (s *Store) saveItems() error {
+	return s.backend.saveItems(s.items)
 }
@@ -1,26 +1,91 @@
 package store
 
 import (
+	"flag"
 	"fmt"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"os"
+	"sync"
 	"testing"
+	"time"
 )
 
+var testBackend = flag.String("backend", BackendFile, "backend the store tests run against, file, sqlite, bolt or postgres")
+
+// testStores are the stores opened by newTestStore, which the tear-down of setupTest closes before removing
+// their files.
+var (
+	testStoresMu sync.Mutex
+	testStores   []*Store
+)
+
+// newTestStore returns a store on the backend the tests run against, run them against another backend with
+// go test ./internal/store -backend sqlite.
+func newTestStore(opts ...Option) *Store {
+	if *testBackend == BackendPostgres {
+		// A schema of the test server stands in for the directory of the store
+		probe := &Store{}
+		for _, opt := range opts {
+			opt(probe)
+		}
+		url, err := createPostgresSchema(postgresServer.url, probe.dir)
+		if err != nil {
+			panic(err)
+		}
+		return trackTestStore(NewStore(append(opts, WithPostgres(url))...))
+	}
+
+	option, err := BackendOption(*testBackend)
+	if err != nil {
+		panic(err)
+	}
+
+	return trackTestStore(NewStore(append(opts, option)...))
+}
+
+func trackTestStore(store *Store) *Store {
+	testStoresMu.Lock()
+	defer testStoresMu.Unlock()
+
+	testStores = append(testStores, store)
+	return store
+}
+
+func closeTestStores() {
+	testStoresMu.Lock()
+	stores := testStores
+	testStores = nil
+	testStoresMu.Unlock()
+
+	for _, store := range stores {
+		store.Close()
+	}
+}
+
 func setupTest() func() {
 	// set-up code here
-	err := os.Remove(ItemsFilename)
-	if err != nil && !errors.Is(err, os.ErrNotExist) {
-		fmt.Printf("Error removing items file: %s, error: %s\n", ItemsFilename, err.Error())
-		os.Exit(1)
-	}
+	removeFiles()
 	// tear down later
 	return func() {
 		// tear-down code here
-		err = os.Remove(ItemsFilename)
+		closeTestStores()
+		removeFiles()
+	}
+}
+
+func removeFiles() {
+	if *testBackend == BackendPostgres {
+		if err := dropPostgresSchema(""); err != nil {
+			fmt.Printf("Error dropping schema, error: %s\n", err.Error())
+			os.Exit(1)
+		}
+	}
+
+	for _, filename := range []string{ItemsFilename, CommentsFilename, HistoryFilename, MutationsFilename, TrashFilename, ArchiveFilename, WebhooksFilename, DeliveriesFilename, PendingFilename, IdempotencyFilename, SQLiteFilename, BoltFilename, LockFilename} {
+		err := os.Remove(filename)
 		if err != nil && !errors.Is(err, os.ErrNotExist) {
-			fmt.Printf("Error removing items file: %s, error: %s\n", ItemsFilename, err.Error())
+			fmt.Printf("Error removing file: %s, error: %s\n", filename, err.Error())
 			os.Exit(1)
 		}
 	}
@@ -30,11 +95,11 @@ func Test_ParallelTests(t *testing.T) {
 	t.Cleanup(setupTest())
 
 	data := map[ItemID]Item{
-		"readID":   {"readID", "readName", "readDesc", "readStatus"},
-		"updateID": {"updateID", "updateName", "updateDesc", "updateStatus"},
-		"deleteID": {"deleteID", "deleteName", "deleteDesc", "deleteStatus"},
+		"readID":   {ID: "readID", Name: "readName", Desc: "readDesc", Status: "readStatus"},
+		"updateID": {ID: "updateID", Name: "updateName", Desc: "updateDesc", Status: "updateStatus"},
+		"deleteID": {ID: "deleteID", Name: "deleteName", Desc: "deleteDesc", Status: "deleteStatus"},
 	}
-	store := NewStore()
+	store := newTestStore()
 	store.items = data
 
 	t.Run("ParallelTests", func(t *testing.T) {
@@ -67,10 +132,16 @@ func Test_ParallelTests(t *testing.T) {
 				Desc:   "newUpdateDesc",
 				Status: "newUpdateStatus",
 			}
+			expectedItem := Item{
+				ID:      "updateID",
+				Name:    "newUpdateID",
+				Desc:    "newUpdateDesc",
+				Status:  "newUpdateStatus",
+				Version: 1,
+			}
 			updatedItem, err := store.Update("updateID", item)
 			assert.NoError(t, err)
-			assert.Equal(t, item, updatedItem)
-
+			assert.Equal(t, expectedItem, updatedItem)
 		})
 		t.Run("Delete", func(t *testing.T) {
 			t.Parallel()
@@ -85,14 +156,14 @@ func Test_Create_ReturnsItem(t *testing.T) {
 	t.Cleanup(setupTest())
 
 	data := map[ItemID]Item{
-		"id1": {"id1", "name1", "desc1", "status1"},
-		"id2": {"id2", "name2", "desc2", "status2"},
+		"id1": {ID: "id1", Name: "name1", Desc: "desc1", Status: "status1"},
+		"id2": {ID: "id2", Name: "name2", Desc: "desc2", Status: "status2"},
 	}
-	store := NewStore()
+	store := newTestStore()
 	store.items = data
 
 	expectedItem := Item{
-		ID: "id3", Name: "name3", Desc: "desc3", Status: "status3",
+		ID: "id3", Name: "name3", Desc: "desc3", Status: "status3", Version: 1,
 	}
 
 	item := Item{
@@ -110,10 +181,10 @@ func Test_Create_AddsToItems(t *testing.T) {
 	t.Cleanup(setupTest())
 
 	data := map[ItemID]Item{
-		"id1": {"id1", "name1", "desc1", "status1"},
-		"id2": {"id2", "name2", "desc2", "status2"},
+		"id1": {ID: "id1", Name: "name1", Desc: "desc1", Status: "status1"},
+		"id2": {ID: "id2", Name: "name2", Desc: "desc2", Status: "status2"},
 	}
-	store := NewStore()
+	store := newTestStore()
 	store.items = data
 
 	item := Item{
@@ -130,10 +201,10 @@ func Test_ReadAll_ReturnsItems(t *testing.T) {
 	t.Cleanup(setupTest())
 
 	data := map[ItemID]Item{
-		"id1": {"id1", "name1", "desc1", "status1"},
-		"id2": {"id2", "name2", "desc2", "status2"},
+		"id1": {ID: "id1", Name: "name1", Desc: "desc1", Status: "status1"},
+		"id2": {ID: "id2", Name: "name2", Desc: "desc2", Status: "status2"},
 	}
-	store := NewStore()
+	store := newTestStore()
 	store.items = data
 
 	expectedItems := []Item{
@@ -151,15 +222,15 @@ func Test_ReadAll_DoesNotChangeItems(t *testing.T) {
 	t.Cleanup(setupTest())
 
 	data := map[ItemID]Item{
-		"id1": {"id1", "name1", "desc1", "status1"},
-		"id2": {"id2", "name2", "desc2", "status2"},
+		"id1": {ID: "id1", Name: "name1", Desc: "desc1", Status: "status1"},
+		"id2": {ID: "id2", Name: "name2", Desc: "desc2", Status: "status2"},
 	}
-	store := NewStore()
+	store := newTestStore()
 	store.items = data
 
 	expectedItems := map[ItemID]Item{
-		"id1": {"id1", "name1", "desc1", "status1"},
-		"id2": {"id2", "name2", "desc2", "status2"},
+		"id1": {ID: "id1", Name: "name1", Desc: "desc1", Status: "status1"},
+		"id2": {ID: "id2", Name: "name2", Desc: "desc2", Status: "status2"},
 	}
 
 	_, err := store.ReadAll()
@@ -172,10 +243,10 @@ func Test_Read_ReturnsItem(t *testing.T) {
 	t.Cleanup(setupTest())
 
 	data := map[ItemID]Item{
-		"id1": {"id1", "name1", "desc1", "status1"},
-		"id2": {"id2", "name2", "desc2", "status2"},
+		"id1": {ID: "id1", Name: "name1", Desc: "desc1", Status: "status1"},
+		"id2": {ID: "id2", Name: "name2", Desc: "desc2", Status: "status2"},
 	}
-	store := NewStore()
+	store := newTestStore()
 	store.items = data
 
 	expectedItem := Item{
@@ -192,15 +263,15 @@ func Test_Read_DoesNotChangeItems(t *testing.T) {
 	t.Cleanup(setupTest())
 
 	data := map[ItemID]Item{
-		"id1": {"id1", "name1", "desc1", "status1"},
-		"id2": {"id2", "name2", "desc2", "status2"},
+		"id1": {ID: "id1", Name: "name1", Desc: "desc1", Status: "status1"},
+		"id2": {ID: "id2", Name: "name2", Desc: "desc2", Status: "status2"},
 	}
-	store := NewStore()
+	store := newTestStore()
 	store.items = data
 
 	expectedItems := map[ItemID]Item{
-		"id1": {"id1", "name1", "desc1", "status1"},
-		"id2": {"id2", "name2", "desc2", "status2"},
+		"id1": {ID: "id1", Name: "name1", Desc: "desc1", Status: "status1"},
+		"id2": {ID: "id2", Name: "name2", Desc: "desc2", Status: "status2"},
 	}
 
 	_, err := store.Read("id2")
@@ -213,14 +284,14 @@ func Test_Update_ReturnsItem(t *testing.T) {
 	t.Cleanup(setupTest())
 
 	data := map[ItemID]Item{
-		"id1": {"id1", "name1", "desc1", "status1"},
-		"id2": {"id2", "name2", "desc2", "status2"},
+		"id1": {ID: "id1", Name: "name1", Desc: "desc1", Status: "status1"},
+		"id2": {ID: "id2", Name: "name2", Desc: "desc2", Status: "status2"},
 	}
-	store := NewStore()
+	store := newTestStore()
 	store.items = data
 
 	expectedItem := Item{
-		ID: "id2", Name: "name3", Desc: "desc3", Status: "status3",
+		ID: "id2", Name: "name3", Desc: "desc3", Status: "status3", Version: 1,
 	}
 
 	item := Item{
@@ -237,15 +308,15 @@ func Test_Update_UpdatesItems(t *testing.T) {
 	t.Cleanup(setupTest())
 
 	data := map[ItemID]Item{
-		"id1": {"id1", "name1", "desc1", "status1"},
-		"id2": {"id2", "name2", "desc2", "status2"},
+		"id1": {ID: "id1", Name: "name1", Desc: "desc1", Status: "status1"},
+		"id2": {ID: "id2", Name: "name2", Desc: "desc2", Status: "status2"},
 	}
-	store := NewStore()
+	store := newTestStore()
 	store.items = data
 
 	expectedItems := map[ItemID]Item{
-		"id1": {"id1", "name1", "desc1", "status1"},
-		"id2": {"id2", "name3", "desc3", "status3"},
+		"id1": {ID: "id1", Name: "name1", Desc: "desc1", Status: "status1"},
+		"id2": {ID: "id2", Name: "name3", Desc: "desc3", Status: "status3", Version: 1},
 	}
 
 	item := Item{
@@ -258,14 +329,47 @@ func Test_Update_UpdatesItems(t *testing.T) {
 	assert.Equal(t, expectedItems, store.items)
 }
 
+func Test_Update_IncrementsVersion(t *testing.T) {
+	t.Cleanup(setupTest())
+
+	store := newTestStore()
+
+	item, err := store.Create(Item{Name: "name1", Desc: "desc1", Status: "status1"})
+	assert.NoError(t, err)
+	item.Name = "name2"
+
+	updatedItem, err := store.Update(ItemID(item.ID), item)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), updatedItem.Version)
+}
+
+func Test_Update_ReturnsErrorOnVersionConflict(t *testing.T) {
+	t.Cleanup(setupTest())
+
+	store := newTestStore()
+
+	item, err := store.Create(Item{Name: "name1", Desc: "desc1", Status: "status1"})
+	assert.NoError(t, err)
+	_, err = store.Update(ItemID(item.ID), Item{Name: "name2", Desc: "desc2", Status: "status2"})
+	assert.NoError(t, err)
+
+	_, err = store.Update(ItemID(item.ID), item)
+
+	assert.ErrorIs(t, err, ErrVersionConflict)
+	actualItem, err := store.Read(ItemID(item.ID))
+	assert.NoError(t, err)
+	assert.Equal(t, "name2", actualItem.Name)
+}
+
 func Test_Delete_DoesNotReturnError(t *testing.T) {
 	t.Cleanup(setupTest())
 
 	data := map[ItemID]Item{
-		"id1": {"id1", "name1", "desc1", "status1"},
-		"id2": {"id2", "name2", "desc2", "status2"},
+		"id1": {ID: "id1", Name: "name1", Desc: "desc1", Status: "status1"},
+		"id2": {ID: "id2", Name: "name2", Desc: "desc2", Status: "status2"},
 	}
-	store := NewStore()
+	store := newTestStore()
 	store.items = data
 
 	err := store.Delete("id2")
@@ -277,14 +381,14 @@ func Test_Delete_RemovesFromItems(t *testing.T) {
 	t.Cleanup(setupTest())
 
 	data := map[ItemID]Item{
-		"id1": {"id1", "name1", "desc1", "status1"},
-		"id2": {"id2", "name2", "desc2", "status2"},
+		"id1": {ID: "id1", Name: "name1", Desc: "desc1", Status: "status1"},
+		"id2": {ID: "id2", Name: "name2", Desc: "desc2", Status: "status2"},
 	}
-	store := NewStore()
+	store := newTestStore()
 	store.items = data
 
 	expectedItems := map[ItemID]Item{
-		"id1": {"id1", "name1", "desc1", "status1"},
+		"id1": {ID: "id1", Name: "name1", Desc: "desc1", Status: "status1"},
 	}
 
 	err := store.Delete("id2")
@@ -292,3 +396,76 @@ func Test_Delete_RemovesFromItems(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, expectedItems, store.items)
 }
+
+func Test_Assign_ReturnsItem(t *testing.T) {
+	t.Cleanup(setupTest())
+
+	data := map[ItemID]Item{
+		"id1": {ID: "id1", Name: "name1", Desc: "desc1", Status: "status1"},
+		"id2": {ID: "id2", Name: "name2", Desc: "desc2", Status: "status2"},
+	}
+	store := newTestStore()
+	store.items = data
+
+	expectedItem := Item{
+		ID: "id2", Name: "name2", Desc: "desc2", Status: "status2", Assignee: "user1", Version: 1,
+	}
+
+	actualItem, err := store.Assign("id2", "user1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, expectedItem, actualItem)
+}
+
+func Test_Assign_ReturnsErrorWhenNotFound(t *testing.T) {
+	t.Cleanup(setupTest())
+
+	store := newTestStore()
+
+	_, err := store.Assign("id1", "user1")
+
+	assert.Error(t, err)
+}
+
+func Test_ReadAssigned_ReturnsAssignedItems(t *testing.T) {
+	t.Cleanup(setupTest())
+
+	data := map[ItemID]Item{
+		"id1": {ID: "id1", Name: "name1", Desc: "desc1", Status: "status1", Assignee: "user1"},
+		"id2": {ID: "id2", Name: "name2", Desc: "desc2", Status: "status2", Assignee: "user2"},
+		"id3": {ID: "id3", Name: "name3", Desc: "desc3", Status: "status3", Assignee: "user1"},
+	}
+	store := newTestStore()
+	store.items = data
+
+	expectedItems := []Item{
+		{ID: "id1", Name: "name1", Desc: "desc1", Status: "status1", Assignee: "user1"},
+		{ID: "id3", Name: "name3", Desc: "desc3", Status: "status3", Assignee: "user1"},
+	}
+
+	actualItems, err := store.ReadAssigned("user1")
+
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, expectedItems, actualItems)
+}
+
+func Test_Close_FailsLaterRequests(t *testing.T) {
+	t.Cleanup(setupTest())
+
+	store := newTestStore(WithJanitorInterval(time.Millisecond))
+	sub, err := store.Subscribe(0)
+	assert.NoError(t, err)
+	_, err = store.Create(Item{Name: "name1"})
+	assert.NoError(t, err)
+
+	store.Close()
+	store.Close()
+
+	_, err = store.ReadAll()
+	assert.ErrorIs(t, err, ErrClosed)
+	_, err = store.Create(Item{Name: "name2"})
+	assert.ErrorIs(t, err, ErrClosed)
+	for range sub.Events {
+	}
+	sub.Close()
+}
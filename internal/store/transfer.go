@@ -0,0 +1,191 @@
+package store
+
+import (
+	"github.com/pkg/errors"
+	"slices"
+)
+
+// ItemRecord is an item with its comments and history, as moved between stores.
+type ItemRecord struct {
+	Item     Item       `json:"item"`
+	Comments []Comment  `json:"comments,omitempty"`
+	History  []Activity `json:"history,omitempty"`
+}
+
+func (s *Store) export(ids []ItemID) response {
+	if err := s.loadItems(); err != nil {
+		return response{
+			err: errors.Wrap(err, "load items"),
+		}
+	}
+
+	if err := s.backend.load(CommentsFilename, &s.comments); err != nil {
+		return response{
+			err: errors.Wrap(err, "load comments"),
+		}
+	}
+
+	if err := s.backend.load(HistoryFilename, &s.history); err != nil {
+		return response{
+			err: errors.Wrap(err, "load history"),
+		}
+	}
+
+	records := make([]ItemRecord, 0, len(ids))
+	for _, id := range ids {
+		item, found := s.items[id]
+		if !found {
+			return response{
+				err: errors.Wrapf(ErrNotFound, "item '%s'", id),
+			}
+		}
+		records = append(records, ItemRecord{
+			Item:     item,
+			Comments: slices.Clone(s.comments[id]),
+			History:  slices.Clone(s.history[id]),
+		})
+	}
+
+	return response{
+		records: records,
+	}
+}
+
+// Export returns the items with their comments and history.
+func (s *Store) Export(ids ...ItemID) ([]ItemRecord, error) {
+	responseChan := make(chan response, 1)
+	req := request{
+		action:       "export",
+		responseChan: responseChan,
+		ids:          ids,
+	}
+	res := s.send(req)
+
+	return res.records, res.err
+}
+
+func (s *Store) importRecords(records []ItemRecord) response {
+	if err := s.loadItems(); err != nil {
+		return response{
+			err: errors.Wrap(err, "load items"),
+		}
+	}
+
+	if err := s.backend.load(CommentsFilename, &s.comments); err != nil {
+		return response{
+			err: errors.Wrap(err, "load comments"),
+		}
+	}
+
+	if err := s.backend.load(HistoryFilename, &s.history); err != nil {
+		return response{
+			err: errors.Wrap(err, "load history"),
+		}
+	}
+
+	for _, record := range records {
+		id := ItemID(record.Item.ID)
+		if id == "" {
+			return response{
+				err: errors.New("imported item has no ID"),
+			}
+		}
+		s.items[id] = record.Item
+		s.comments[id] = record.Comments
+		s.history[id] = record.History
+	}
+
+	if err := s.saveItems(); err != nil {
+		return response{
+			err: errors.Wrap(err, "save items"),
+		}
+	}
+
+	if err := s.backend.save(CommentsFilename, s.comments); err != nil {
+		return response{
+			err: errors.Wrap(err, "save comments"),
+		}
+	}
+
+	if err := s.backend.save(HistoryFilename, s.history); err != nil {
+		return response{
+			err: errors.Wrap(err, "save history"),
+		}
+	}
+
+	return response{}
+}
+
+// Import stores the records as they are under their item IDs, replacing items with the same ID. It is not
+// an undoable change and publishes no events as the items only move between stores.
+func (s *Store) Import(records ...ItemRecord) error {
+	responseChan := make(chan response, 1)
+	req := request{
+		action:       "import",
+		responseChan: responseChan,
+		records:      records,
+	}
+	res := s.send(req)
+
+	return res.err
+}
+
+func (s *Store) evict(ids []ItemID) response {
+	if err := s.loadItems(); err != nil {
+		return response{
+			err: errors.Wrap(err, "load items"),
+		}
+	}
+
+	if err := s.backend.load(CommentsFilename, &s.comments); err != nil {
+		return response{
+			err: errors.Wrap(err, "load comments"),
+		}
+	}
+
+	if err := s.backend.load(HistoryFilename, &s.history); err != nil {
+		return response{
+			err: errors.Wrap(err, "load history"),
+		}
+	}
+
+	for _, id := range ids {
+		delete(s.items, id)
+		delete(s.comments, id)
+		delete(s.history, id)
+	}
+
+	if err := s.saveItems(); err != nil {
+		return response{
+			err: errors.Wrap(err, "save items"),
+		}
+	}
+
+	if err := s.backend.save(CommentsFilename, s.comments); err != nil {
+		return response{
+			err: errors.Wrap(err, "save comments"),
+		}
+	}
+
+	if err := s.backend.save(HistoryFilename, s.history); err != nil {
+		return response{
+			err: errors.Wrap(err, "save history"),
+		}
+	}
+
+	return response{}
+}
+
+// Evict removes the items with their comments and history once they moved to another store, without
+// trashing them or publishing events.
+func (s *Store) Evict(ids ...ItemID) error {
+	responseChan := make(chan response, 1)
+	req := request{
+		action:       "evict",
+		responseChan: responseChan,
+		ids:          ids,
+	}
+	res := s.send(req)
+
+	return res.err
+}
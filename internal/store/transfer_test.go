@@ -0,0 +1,59 @@
+package store
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func Test_Import_MovesItemWithCommentsAndHistory(t *testing.T) {
+	t.Cleanup(setupTest())
+
+	source := newTestStore(WithDir(t.TempDir()))
+	target := newTestStore(WithDir(t.TempDir()))
+	item, err := source.Create(Item{Name: "name1", Desc: "desc1", Status: "status1"})
+	require.NoError(t, err)
+	_, err = source.AddComment(ItemID(item.ID), Comment{Author: "user1", Text: "text1"})
+	require.NoError(t, err)
+
+	records, err := source.Export(ItemID(item.ID))
+	require.NoError(t, err)
+	require.NoError(t, target.Import(records...))
+	require.NoError(t, source.Evict(ItemID(item.ID)))
+
+	actualItem, err := target.Read(ItemID(item.ID))
+	assert.NoError(t, err)
+	assert.Equal(t, item, actualItem)
+	comments, err := target.ReadComments(ItemID(item.ID))
+	assert.NoError(t, err)
+	assert.Len(t, comments, 1)
+	_, err = source.Read(ItemID(item.ID))
+	assert.ErrorIs(t, err, ErrNotFound)
+	trash, err := source.ReadTrash()
+	assert.NoError(t, err)
+	assert.Empty(t, trash)
+}
+
+func Test_Create_KeepsGivenID(t *testing.T) {
+	t.Cleanup(setupTest())
+
+	store := newTestStore()
+
+	item, err := store.Create(Item{ID: "id1", Name: "name1"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "id1", item.ID)
+}
+
+func Test_Create_ReturnsErrorWhenIDIsUsed(t *testing.T) {
+	t.Cleanup(setupTest())
+
+	store := newTestStore()
+	_, err := store.Create(Item{ID: "id1", Name: "name1"})
+	require.NoError(t, err)
+	require.NoError(t, store.Delete("id1"))
+
+	_, err = store.Create(Item{ID: "id1", Name: "name2"})
+
+	assert.ErrorIs(t, err, ErrAlreadyExists)
+}
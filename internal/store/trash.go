@@ -0,0 +1,239 @@
+package store
+
+import (
+	"github.com/pkg/errors"
+	"slices"
+	"time"
+)
+
+const (
+	TrashFilename         = "trash.json"
+	DefaultTrashRetention = 30 * 24 * time.Hour
+)
+
+type TrashedItem struct {
+	Item
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+// WithTrashRetention sets how long deleted items stay in the trash, a non-positive retention keeps them forever.
+func WithTrashRetention(retention time.Duration) Option {
+	return func(s *Store) {
+		s.trashRetention = retention
+	}
+}
+
+func (s *Store) readTrash() response {
+	if err := s.backend.load(TrashFilename, &s.trash); err != nil {
+		return response{
+			err: errors.Wrap(err, "load trash"),
+		}
+	}
+
+	trash := make([]TrashedItem, 0, len(s.trash))
+	for _, item := range s.trash {
+		trash = append(trash, item)
+	}
+
+	return response{
+		trash: trash,
+	}
+}
+
+func (s *Store) ReadTrash() ([]TrashedItem, error) {
+	responseChan := make(chan response, 1)
+	req := request{
+		action:       "readTrash",
+		responseChan: responseChan,
+	}
+	res := s.send(req)
+
+	return res.trash, res.err
+}
+
+func (s *Store) restoreDeleted(id ItemID) response {
+	if err := s.loadItems(); err != nil {
+		return response{
+			err: errors.Wrap(err, "load items"),
+		}
+	}
+
+	if err := s.backend.load(TrashFilename, &s.trash); err != nil {
+		return response{
+			err: errors.Wrap(err, "load trash"),
+		}
+	}
+
+	trashed, found := s.trash[id]
+	if !found {
+		return response{
+			err: errors.Wrapf(ErrNotFound, "item '%s' in trash", id),
+		}
+	}
+
+	item := trashed.Item
+	s.items[id] = item
+
+	if err := s.saveItems(); err != nil {
+		return response{
+			err: errors.Wrap(err, "save items"),
+		}
+	}
+
+	delete(s.trash, id)
+
+	if err := s.backend.save(TrashFilename, s.trash); err != nil {
+		return response{
+			err: errors.Wrap(err, "save trash"),
+		}
+	}
+
+	if err := s.recordActivity(id, "restored from trash"); err != nil {
+		return response{
+			err: errors.Wrap(err, "record activity"),
+		}
+	}
+
+	if err := s.recordMutation("restore deleted", Change{ID: id, After: &item}); err != nil {
+		return response{
+			err: errors.Wrap(err, "record mutation"),
+		}
+	}
+
+	return response{
+		item: item,
+	}
+}
+
+func (s *Store) RestoreDeleted(id ItemID) (Item, error) {
+	responseChan := make(chan response, 1)
+	req := request{
+		action:       "restoreDeleted",
+		responseChan: responseChan,
+		id:           id,
+	}
+	res := s.send(req)
+
+	return res.item, res.err
+}
+
+func (s *Store) purgeTrash() response {
+	if s.trashRetention <= 0 {
+		return response{}
+	}
+
+	if err := s.backend.load(TrashFilename, &s.trash); err != nil {
+		return response{
+			err: errors.Wrap(err, "load trash"),
+		}
+	}
+
+	cutoff := time.Now().UTC().Add(-s.trashRetention)
+	var purged []ItemID
+	for id, item := range s.trash {
+		if item.DeletedAt.Before(cutoff) {
+			purged = append(purged, id)
+			delete(s.trash, id)
+		}
+	}
+
+	if len(purged) == 0 {
+		return response{}
+	}
+
+	if err := s.backend.save(TrashFilename, s.trash); err != nil {
+		return response{
+			err: errors.Wrap(err, "save trash"),
+		}
+	}
+
+	// Purged items are gone for good, undoing or restoring must not bring them back
+	if err := s.backend.load(MutationsFilename, &s.mutations); err != nil {
+		return response{
+			err: errors.Wrap(err, "load mutations"),
+		}
+	}
+
+	s.mutations.Journal = withoutItems(s.mutations.Journal, purged)
+	s.mutations.Undo = withoutItems(s.mutations.Undo, purged)
+	s.mutations.Redo = withoutItems(s.mutations.Redo, purged)
+
+	if err := s.backend.save(MutationsFilename, s.mutations); err != nil {
+		return response{
+			err: errors.Wrap(err, "save mutations"),
+		}
+	}
+
+	for _, id := range purged {
+		if err := s.recordActivity(id, "purged"); err != nil {
+			return response{
+				err: errors.Wrap(err, "record activity"),
+			}
+		}
+	}
+
+	return response{}
+}
+
+// withoutItems returns the mutations without their changes of the items, dropping mutations left without
+// changes.
+func withoutItems(mutations []Mutation, ids []ItemID) []Mutation {
+	kept := make([]Mutation, 0, len(mutations))
+	for _, mutation := range mutations {
+		mutation.Changes = slices.DeleteFunc(slices.Clone(mutation.Changes), func(change Change) bool {
+			return slices.Contains(ids, change.ID)
+		})
+		if len(mutation.Changes) > 0 {
+			kept = append(kept, mutation)
+		}
+	}
+
+	return kept
+}
+
+// PurgeTrash removes the items deleted longer than the trash retention ago for good, together with their
+// changes in the journal and the undo and redo stacks.
+func (s *Store) PurgeTrash() error {
+	responseChan := make(chan response, 1)
+	req := request{
+		action:       "purgeTrash",
+		responseChan: responseChan,
+	}
+	res := s.send(req)
+
+	return res.err
+}
+
+// trashItems moves removed items to the trash, it is saved before the items so a failure never loses an item.
+func (s *Store) trashItems(items ...Item) error {
+	if err := s.backend.load(TrashFilename, &s.trash); err != nil {
+		return errors.Wrap(err, "load trash")
+	}
+
+	now := time.Now().UTC()
+	for _, item := range items {
+		s.trash[ItemID(item.ID)] = TrashedItem{Item: item, DeletedAt: now}
+	}
+
+	if err := s.backend.save(TrashFilename, s.trash); err != nil {
+		return errors.Wrap(err, "save trash")
+	}
+
+	return nil
+}
+
+func (s *Store) untrashItems(ids ...ItemID) error {
+	if err := s.backend.load(TrashFilename, &s.trash); err != nil {
+		return errors.Wrap(err, "load trash")
+	}
+
+	for _, id := range ids {
+		delete(s.trash, id)
+	}
+
+	if err := s.backend.save(TrashFilename, s.trash); err != nil {
+		return errors.Wrap(err, "save trash")
+	}
+
+	return nil
+}
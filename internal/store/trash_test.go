@@ -0,0 +1,170 @@
+package store
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func Test_Delete_MovesToTrash(t *testing.T) {
+	t.Cleanup(setupTest())
+
+	data := map[ItemID]Item{
+		"id1": {ID: "id1", Name: "name1", Desc: "desc1", Status: "status1"},
+		"id2": {ID: "id2", Name: "name2", Desc: "desc2", Status: "status2"},
+	}
+	store := newTestStore()
+	store.items = data
+
+	err := store.Delete("id2")
+	assert.NoError(t, err)
+
+	items, err := store.ReadAll()
+	assert.NoError(t, err)
+	trash, err := store.ReadTrash()
+	assert.NoError(t, err)
+
+	assert.Equal(t, []Item{{ID: "id1", Name: "name1", Desc: "desc1", Status: "status1"}}, items)
+	assert.Len(t, trash, 1)
+	assert.Equal(t, Item{ID: "id2", Name: "name2", Desc: "desc2", Status: "status2"}, trash[0].Item)
+	assert.False(t, trash[0].DeletedAt.IsZero())
+}
+
+func Test_RestoreDeleted_ReturnsItemFromTrash(t *testing.T) {
+	t.Cleanup(setupTest())
+
+	data := map[ItemID]Item{
+		"id1": {ID: "id1", Name: "name1", Desc: "desc1", Status: "status1"},
+	}
+	store := newTestStore()
+	store.items = data
+
+	assert.NoError(t, store.Delete("id1"))
+
+	item, err := store.RestoreDeleted("id1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, data["id1"], item)
+	trash, err := store.ReadTrash()
+	assert.NoError(t, err)
+	assert.Empty(t, trash)
+	_, err = store.Read("id1")
+	assert.NoError(t, err)
+}
+
+func Test_RestoreDeleted_ReturnsErrorWhenNotInTrash(t *testing.T) {
+	t.Cleanup(setupTest())
+
+	store := newTestStore()
+
+	_, err := store.RestoreDeleted("id1")
+
+	assert.Error(t, err)
+}
+
+func Test_Undo_RemovesFromTrash(t *testing.T) {
+	t.Cleanup(setupTest())
+
+	data := map[ItemID]Item{
+		"id1": {ID: "id1", Name: "name1", Desc: "desc1", Status: "status1"},
+	}
+	store := newTestStore()
+	store.items = data
+
+	assert.NoError(t, store.Delete("id1"))
+
+	err := store.Undo()
+
+	assert.NoError(t, err)
+	trash, err := store.ReadTrash()
+	assert.NoError(t, err)
+	assert.Empty(t, trash)
+}
+
+func Test_PurgeTrash_RemovesExpiredItems(t *testing.T) {
+	t.Cleanup(setupTest())
+
+	store := newTestStore(WithTrashRetention(time.Hour))
+	store.trash = map[ItemID]TrashedItem{
+		"id1": {Item: Item{ID: "id1", Name: "name1"}, DeletedAt: time.Now().UTC().Add(-2 * time.Hour)},
+		"id2": {Item: Item{ID: "id2", Name: "name2"}, DeletedAt: time.Now().UTC()},
+	}
+
+	err := store.PurgeTrash()
+
+	assert.NoError(t, err)
+	trash, err := store.ReadTrash()
+	assert.NoError(t, err)
+	assert.Len(t, trash, 1)
+	assert.Equal(t, "id2", trash[0].ID)
+}
+
+func Test_Janitor_PurgesTrash(t *testing.T) {
+	t.Cleanup(setupTest())
+
+	store := newTestStore(WithTrashRetention(10*time.Millisecond), WithJanitorInterval(10*time.Millisecond))
+	t.Cleanup(store.Close)
+	_, err := store.Create(Item{Name: "name1"})
+	assert.NoError(t, err)
+	items, err := store.ReadAll()
+	assert.NoError(t, err)
+	assert.NoError(t, store.Delete(ItemID(items[0].ID)))
+
+	assert.Eventually(t, func() bool {
+		trash, err := store.ReadTrash()
+		return err == nil && len(trash) == 0
+	}, time.Second, 10*time.Millisecond)
+}
+
+func Test_StartJanitor_StartsJanitorOfStoreWithoutOne(t *testing.T) {
+	t.Cleanup(setupTest())
+
+	store := newTestStore(WithTrashRetention(10*time.Millisecond), WithJanitorInterval(0))
+	t.Cleanup(store.Close)
+	item, err := store.Create(Item{Name: "name1"})
+	require.NoError(t, err)
+	require.NoError(t, store.Delete(ItemID(item.ID)))
+
+	time.Sleep(50 * time.Millisecond)
+	trash, err := store.ReadTrash()
+	require.NoError(t, err)
+	require.Len(t, trash, 1)
+	store.StartJanitor(10 * time.Millisecond)
+
+	assert.Eventually(t, func() bool {
+		trash, err := store.ReadTrash()
+		return err == nil && len(trash) == 0
+	}, time.Second, 10*time.Millisecond)
+}
+
+func Test_PurgeTrash_LeavesNothingToBringPurgedItemsBack(t *testing.T) {
+	t.Cleanup(setupTest())
+
+	store := newTestStore(WithTrashRetention(10*time.Millisecond), WithJanitorInterval(0))
+	item1, err := store.Create(Item{Name: "name1"})
+	require.NoError(t, err)
+	item2, err := store.Create(Item{Name: "name2"})
+	require.NoError(t, err)
+	beforeDelete := time.Now().UTC()
+	time.Sleep(time.Millisecond)
+	require.NoError(t, store.Delete(ItemID(item1.ID)))
+	time.Sleep(20 * time.Millisecond)
+
+	require.NoError(t, store.PurgeTrash())
+
+	items, err := store.Restore(beforeDelete)
+	assert.NoError(t, err)
+	assert.Equal(t, []Item{item2}, items)
+	assert.NoError(t, store.Undo())
+	assert.ErrorIs(t, store.Undo(), ErrNothingToUndo)
+	items, err = store.ReadAll()
+	assert.NoError(t, err)
+	assert.Empty(t, items)
+	assert.NoError(t, store.Redo())
+	_, err = store.Read(ItemID(item1.ID))
+	assert.ErrorIs(t, err, ErrNotFound)
+	trash, err := store.ReadTrash()
+	assert.NoError(t, err)
+	assert.Empty(t, trash)
+}
@@ -0,0 +1,357 @@
+package store
+
+import (
+	"github.com/pkg/errors"
+	"reflect"
+	"time"
+)
+
+const (
+	MutationsFilename = "mutations.json"
+	MaxMutations      = 100
+)
+
+// Change describes a single item transition, a nil Before means the item was created and a nil After
+// means it was deleted. An item with ArchivedAt set is in the archive rather than in the item list.
+type Change struct {
+	ID     ItemID `json:"id"`
+	Before *Item  `json:"before,omitempty"`
+	After  *Item  `json:"after,omitempty"`
+}
+
+func (c Change) archives() bool {
+	return c.After != nil && c.After.ArchivedAt != nil
+}
+
+func (c Change) unarchives() bool {
+	return c.Before != nil && c.Before.ArchivedAt != nil && c.After != nil && c.After.ArchivedAt == nil
+}
+
+type Mutation struct {
+	Time    time.Time `json:"time"`
+	Action  string    `json:"action"`
+	Changes []Change  `json:"changes"`
+}
+
+// mutationLog keeps the undo and redo stacks together with a journal of every applied mutation which is
+// replayed backwards for point-in-time restores. Horizon is the time of the newest mutation dropped
+// from the journal, the store cannot be restored to any earlier point.
+type mutationLog struct {
+	Journal []Mutation `json:"journal"`
+	Undo    []Mutation `json:"undo"`
+	Redo    []Mutation `json:"redo"`
+	Horizon time.Time  `json:"horizon"`
+}
+
+func (s *Store) undo() response {
+	if err := s.loadItems(); err != nil {
+		return response{
+			err: errors.Wrap(err, "load items"),
+		}
+	}
+
+	if err := s.backend.load(MutationsFilename, &s.mutations); err != nil {
+		return response{
+			err: errors.Wrap(err, "load mutations"),
+		}
+	}
+
+	if len(s.mutations.Undo) == 0 {
+		return response{
+			err: ErrNothingToUndo,
+		}
+	}
+
+	mutation := s.mutations.Undo[len(s.mutations.Undo)-1]
+	s.mutations.Undo = s.mutations.Undo[:len(s.mutations.Undo)-1]
+	s.mutations.Redo = bounded(append(s.mutations.Redo, mutation))
+
+	changes := make([]Change, 0, len(mutation.Changes))
+	for i := len(mutation.Changes) - 1; i >= 0; i-- {
+		change := mutation.Changes[i]
+		changes = append(changes, Change{ID: change.ID, Before: change.After, After: change.Before})
+	}
+
+	return s.applyMutation("undo "+mutation.Action, changes)
+}
+
+func (s *Store) Undo() error {
+	responseChan := make(chan response, 1)
+	req := request{
+		action:       "undo",
+		responseChan: responseChan,
+	}
+	res := s.send(req)
+
+	return res.err
+}
+
+func (s *Store) redo() response {
+	if err := s.loadItems(); err != nil {
+		return response{
+			err: errors.Wrap(err, "load items"),
+		}
+	}
+
+	if err := s.backend.load(MutationsFilename, &s.mutations); err != nil {
+		return response{
+			err: errors.Wrap(err, "load mutations"),
+		}
+	}
+
+	if len(s.mutations.Redo) == 0 {
+		return response{
+			err: ErrNothingToRedo,
+		}
+	}
+
+	mutation := s.mutations.Redo[len(s.mutations.Redo)-1]
+	s.mutations.Redo = s.mutations.Redo[:len(s.mutations.Redo)-1]
+	s.mutations.Undo = bounded(append(s.mutations.Undo, mutation))
+
+	return s.applyMutation("redo "+mutation.Action, mutation.Changes)
+}
+
+func (s *Store) Redo() error {
+	responseChan := make(chan response, 1)
+	req := request{
+		action:       "redo",
+		responseChan: responseChan,
+	}
+	res := s.send(req)
+
+	return res.err
+}
+
+func (s *Store) restore(at time.Time) response {
+	if err := s.loadItems(); err != nil {
+		return response{
+			err: errors.Wrap(err, "load items"),
+		}
+	}
+
+	if err := s.backend.load(MutationsFilename, &s.mutations); err != nil {
+		return response{
+			err: errors.Wrap(err, "load mutations"),
+		}
+	}
+
+	if at.Before(s.mutations.Horizon) {
+		return response{
+			err: errors.Wrapf(ErrHistoryUnavailable, "restore to %s, history only goes back to %s",
+				at.Format(time.RFC3339), s.mutations.Horizon.Format(time.RFC3339)),
+		}
+	}
+
+	// Walk the journal backwards collecting the state of every touched item as of the given time
+	restored := make(map[ItemID]*Item)
+	var order []ItemID
+	for i := len(s.mutations.Journal) - 1; i >= 0 && s.mutations.Journal[i].Time.After(at); i-- {
+		mutation := s.mutations.Journal[i]
+		for j := len(mutation.Changes) - 1; j >= 0; j-- {
+			change := mutation.Changes[j]
+			if _, found := restored[change.ID]; !found {
+				order = append(order, change.ID)
+			}
+			restored[change.ID] = change.Before
+		}
+	}
+
+	if err := s.backend.load(ArchiveFilename, &s.archive); err != nil {
+		return response{
+			err: errors.Wrap(err, "load archive"),
+		}
+	}
+
+	changes := make([]Change, 0, len(order))
+	for _, id := range order {
+		var before *Item
+		if item, found := s.items[id]; found {
+			before = &item
+		} else if item, found := s.archive[id]; found {
+			before = &item
+		}
+		if reflect.DeepEqual(before, restored[id]) {
+			continue
+		}
+		changes = append(changes, Change{ID: id, Before: before, After: restored[id]})
+	}
+
+	// Restoring the current state changes nothing and leaves nothing to undo
+	if len(changes) == 0 {
+		return s.readAll()
+	}
+
+	s.mutations.Undo = bounded(append(s.mutations.Undo, Mutation{
+		Time:    time.Now().UTC(),
+		Action:  "restore",
+		Changes: changes,
+	}))
+	s.mutations.Redo = nil
+
+	res := s.applyMutation("restore", changes)
+	if res.err != nil {
+		return res
+	}
+
+	return s.readAll()
+}
+
+func (s *Store) Restore(at time.Time) ([]Item, error) {
+	responseChan := make(chan response, 1)
+	req := request{
+		action:       "restore",
+		responseChan: responseChan,
+		at:           at,
+	}
+	res := s.send(req)
+
+	return res.items, res.err
+}
+
+// applyMutation applies the changes to the items and journals them, removed items are moved to the
+// trash and archived ones to the archive. The undo and redo stacks must already have been updated by the
+// caller.
+func (s *Store) applyMutation(action string, changes []Change) response {
+	changes = s.versioned(changes)
+
+	if err := s.backend.load(ArchiveFilename, &s.archive); err != nil {
+		return response{
+			err: errors.Wrap(err, "load archive"),
+		}
+	}
+
+	var trashed []Item
+	var untrashed []ItemID
+	for _, change := range changes {
+		if change.After == nil {
+			if change.Before != nil {
+				item := *change.Before
+				item.ArchivedAt = nil
+				trashed = append(trashed, item)
+			}
+			continue
+		}
+		untrashed = append(untrashed, change.ID)
+	}
+
+	if err := s.trashItems(trashed...); err != nil {
+		return response{
+			err: errors.Wrap(err, "trash items"),
+		}
+	}
+
+	// The archive is saved first so a failure never loses an item
+	for _, change := range changes {
+		delete(s.archive, change.ID)
+		if change.archives() {
+			s.archive[change.ID] = *change.After
+		}
+	}
+
+	if err := s.backend.save(ArchiveFilename, s.archive); err != nil {
+		return response{
+			err: errors.Wrap(err, "save archive"),
+		}
+	}
+
+	for _, change := range changes {
+		if change.After == nil || change.archives() {
+			delete(s.items, change.ID)
+			continue
+		}
+		s.items[change.ID] = *change.After
+	}
+
+	if err := s.saveItems(); err != nil {
+		return response{
+			err: errors.Wrap(err, "save items"),
+		}
+	}
+
+	if err := s.untrashItems(untrashed...); err != nil {
+		return response{
+			err: errors.Wrap(err, "untrash items"),
+		}
+	}
+
+	for _, change := range changes {
+		if err := s.recordActivity(change.ID, action); err != nil {
+			return response{
+				err: errors.Wrap(err, "record activity"),
+			}
+		}
+	}
+
+	s.appendJournal(Mutation{Time: time.Now().UTC(), Action: action, Changes: changes})
+	s.publishChanges(changes...)
+
+	if err := s.recordPending(changes); err != nil {
+		return response{
+			err: errors.Wrap(err, "record pending changes"),
+		}
+	}
+
+	if err := s.backend.save(MutationsFilename, s.mutations); err != nil {
+		return response{
+			err: errors.Wrap(err, "save mutations"),
+		}
+	}
+
+	return response{}
+}
+
+// versioned returns the changes with a new version for every item that is replaced, so clients holding the
+// replaced version see a conflict. Items that are brought back keep the version they had.
+func (s *Store) versioned(changes []Change) []Change {
+	versioned := make([]Change, len(changes))
+	for i, change := range changes {
+		versioned[i] = change
+		if change.After == nil {
+			continue
+		}
+		if current, found := s.items[change.ID]; found {
+			after := *change.After
+			after.Version = max(current.Version, after.Version) + 1
+			versioned[i].After = &after
+		}
+	}
+	return versioned
+}
+
+func (s *Store) recordMutation(action string, changes ...Change) error {
+	if err := s.backend.load(MutationsFilename, &s.mutations); err != nil {
+		return errors.Wrap(err, "load mutations")
+	}
+
+	mutation := Mutation{Time: time.Now().UTC(), Action: action, Changes: changes}
+	s.appendJournal(mutation)
+	s.publishChanges(changes...)
+	s.mutations.Undo = bounded(append(s.mutations.Undo, mutation))
+	s.mutations.Redo = nil
+
+	if err := s.backend.save(MutationsFilename, s.mutations); err != nil {
+		return errors.Wrap(err, "save mutations")
+	}
+
+	if err := s.recordPending(changes); err != nil {
+		return errors.Wrap(err, "record pending changes")
+	}
+
+	return nil
+}
+
+func (s *Store) appendJournal(mutation Mutation) {
+	s.mutations.Journal = append(s.mutations.Journal, mutation)
+	if dropped := len(s.mutations.Journal) - MaxMutations; dropped > 0 {
+		s.mutations.Horizon = s.mutations.Journal[dropped-1].Time
+		s.mutations.Journal = s.mutations.Journal[dropped:]
+	}
+}
+
+func bounded(mutations []Mutation) []Mutation {
+	if len(mutations) > MaxMutations {
+		return mutations[len(mutations)-MaxMutations:]
+	}
+	return mutations
+}
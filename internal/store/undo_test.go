@@ -0,0 +1,270 @@
+package store
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func Test_Undo_RevertsDelete(t *testing.T) {
+	t.Cleanup(setupTest())
+
+	store := newTestStore()
+
+	item, err := store.Create(Item{Name: "name1", Desc: "desc1", Status: "status1"})
+	assert.NoError(t, err)
+	assert.NoError(t, store.Delete(ItemID(item.ID)))
+
+	err = store.Undo()
+
+	assert.NoError(t, err)
+	actualItem, err := store.Read(ItemID(item.ID))
+	assert.NoError(t, err)
+	assert.Equal(t, item, actualItem)
+}
+
+func Test_Undo_RevertsUpdate(t *testing.T) {
+	t.Cleanup(setupTest())
+
+	store := newTestStore()
+
+	item, err := store.Create(Item{Name: "name1", Desc: "desc1", Status: "status1"})
+	assert.NoError(t, err)
+	_, err = store.Update(ItemID(item.ID), Item{Name: "name2", Desc: "desc2", Status: "status2"})
+	assert.NoError(t, err)
+
+	err = store.Undo()
+
+	assert.NoError(t, err)
+	actualItem, err := store.Read(ItemID(item.ID))
+	assert.NoError(t, err)
+	item.Version = 3
+	assert.Equal(t, item, actualItem)
+}
+
+func Test_Undo_BumpsVersionOfReplacedItem(t *testing.T) {
+	t.Cleanup(setupTest())
+
+	store := newTestStore()
+
+	item, err := store.Create(Item{Name: "name1", Desc: "desc1", Status: "status1"})
+	assert.NoError(t, err)
+	updatedItem, err := store.Update(ItemID(item.ID), Item{Name: "name2", Desc: "desc2", Status: "status2"})
+	assert.NoError(t, err)
+	assert.NoError(t, store.Undo())
+
+	_, err = store.Update(ItemID(item.ID), updatedItem)
+
+	assert.ErrorIs(t, err, ErrVersionConflict)
+}
+
+func Test_Undo_ReturnsErrorWhenNothingToUndo(t *testing.T) {
+	t.Cleanup(setupTest())
+
+	store := newTestStore()
+
+	err := store.Undo()
+
+	assert.Error(t, err)
+}
+
+func Test_Redo_ReappliesUndoneChange(t *testing.T) {
+	t.Cleanup(setupTest())
+
+	store := newTestStore()
+
+	item, err := store.Create(Item{Name: "name1", Desc: "desc1", Status: "status1"})
+	assert.NoError(t, err)
+	assert.NoError(t, store.Undo())
+	_, err = store.Read(ItemID(item.ID))
+	assert.Error(t, err)
+
+	err = store.Redo()
+
+	assert.NoError(t, err)
+	actualItem, err := store.Read(ItemID(item.ID))
+	assert.NoError(t, err)
+	assert.Equal(t, item, actualItem)
+}
+
+func Test_Redo_ReturnsErrorAfterNewChange(t *testing.T) {
+	t.Cleanup(setupTest())
+
+	store := newTestStore()
+
+	_, err := store.Create(Item{Name: "name1", Desc: "desc1", Status: "status1"})
+	assert.NoError(t, err)
+	assert.NoError(t, store.Undo())
+	_, err = store.Create(Item{Name: "name2", Desc: "desc2", Status: "status2"})
+	assert.NoError(t, err)
+
+	err = store.Redo()
+
+	assert.Error(t, err)
+}
+
+func Test_Undo_IsBounded(t *testing.T) {
+	t.Cleanup(setupTest())
+
+	store := newTestStore()
+
+	for i := 0; i < MaxMutations+5; i++ {
+		_, err := store.Create(Item{Name: "name", Desc: "desc", Status: "status"})
+		assert.NoError(t, err)
+	}
+
+	for i := 0; i < MaxMutations; i++ {
+		assert.NoError(t, store.Undo())
+	}
+
+	assert.Error(t, store.Undo())
+	items, err := store.ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, items, 5)
+}
+
+func Test_Restore_ReturnsItemsAsOfTime(t *testing.T) {
+	t.Cleanup(setupTest())
+
+	store := newTestStore()
+
+	item1, err := store.Create(Item{Name: "name1", Desc: "desc1", Status: "status1"})
+	assert.NoError(t, err)
+	item2, err := store.Create(Item{Name: "name2", Desc: "desc2", Status: "status2"})
+	assert.NoError(t, err)
+
+	time.Sleep(time.Millisecond)
+	at := time.Now().UTC()
+	time.Sleep(time.Millisecond)
+
+	assert.NoError(t, store.Delete(ItemID(item1.ID)))
+	_, err = store.Update(ItemID(item2.ID), Item{Name: "name3", Desc: "desc3", Status: "status3"})
+	assert.NoError(t, err)
+	_, err = store.Create(Item{Name: "name4", Desc: "desc4", Status: "status4"})
+	assert.NoError(t, err)
+
+	items, err := store.Restore(at)
+
+	assert.NoError(t, err)
+	item2.Version = 3
+	assert.ElementsMatch(t, []Item{item1, item2}, items)
+}
+
+func Test_Restore_CanBeUndone(t *testing.T) {
+	t.Cleanup(setupTest())
+
+	store := newTestStore()
+
+	at := time.Now().UTC()
+	time.Sleep(time.Millisecond)
+
+	item, err := store.Create(Item{Name: "name1", Desc: "desc1", Status: "status1"})
+	assert.NoError(t, err)
+	items, err := store.Restore(at)
+	assert.NoError(t, err)
+	assert.Empty(t, items)
+
+	err = store.Undo()
+
+	assert.NoError(t, err)
+	items, err = store.ReadAll()
+	assert.NoError(t, err)
+	assert.Equal(t, []Item{item}, items)
+}
+
+func Test_Restore_NothingToRestoreLeavesNothingToUndo(t *testing.T) {
+	t.Cleanup(setupTest())
+
+	store := newTestStore()
+
+	item, err := store.Create(Item{Name: "name1", Desc: "desc1", Status: "status1"})
+	assert.NoError(t, err)
+	assert.NoError(t, store.Undo())
+	assert.NoError(t, store.Redo())
+
+	items, err := store.Restore(time.Now().UTC())
+	assert.NoError(t, err)
+	assert.Equal(t, []Item{item}, items)
+
+	assert.NoError(t, store.Undo())
+	items, err = store.ReadAll()
+	assert.NoError(t, err)
+	assert.Empty(t, items)
+	assert.ErrorIs(t, store.Undo(), ErrNothingToUndo)
+}
+
+func Test_Undo_RevertsArchive(t *testing.T) {
+	t.Cleanup(setupTest())
+
+	store := newTestStore()
+
+	item, err := store.Create(Item{Name: "name1", Desc: "desc1", Status: "status1"})
+	assert.NoError(t, err)
+	_, err = store.Archive(ItemID(item.ID))
+	assert.NoError(t, err)
+
+	err = store.Undo()
+
+	assert.NoError(t, err)
+	actualItem, err := store.Read(ItemID(item.ID))
+	assert.NoError(t, err)
+	assert.Equal(t, item, actualItem)
+	archived, err := store.ReadArchived()
+	assert.NoError(t, err)
+	assert.Empty(t, archived)
+
+	assert.NoError(t, store.Redo())
+	_, err = store.Read(ItemID(item.ID))
+	assert.ErrorIs(t, err, ErrNotFound)
+	archived, err = store.ReadArchived()
+	assert.NoError(t, err)
+	assert.Len(t, archived, 1)
+	trash, err := store.ReadTrash()
+	assert.NoError(t, err)
+	assert.Empty(t, trash)
+}
+
+func Test_Undo_RevertsUnarchive(t *testing.T) {
+	t.Cleanup(setupTest())
+
+	store := newTestStore()
+
+	item, err := store.Create(Item{Name: "name1", Desc: "desc1", Status: "status1"})
+	assert.NoError(t, err)
+	archivedItem, err := store.Archive(ItemID(item.ID))
+	assert.NoError(t, err)
+	_, err = store.Unarchive(ItemID(item.ID))
+	assert.NoError(t, err)
+
+	err = store.Undo()
+
+	assert.NoError(t, err)
+	_, err = store.Read(ItemID(item.ID))
+	assert.ErrorIs(t, err, ErrNotFound)
+	archived, err := store.ReadArchived()
+	assert.NoError(t, err)
+	archivedItem.Version = 2
+	assert.Equal(t, []Item{archivedItem}, archived)
+}
+
+func Test_Restore_ReturnsArchivedItems(t *testing.T) {
+	t.Cleanup(setupTest())
+
+	store := newTestStore()
+
+	item, err := store.Create(Item{Name: "name1", Desc: "desc1", Status: "status1"})
+	assert.NoError(t, err)
+	time.Sleep(time.Millisecond)
+	at := time.Now().UTC()
+	time.Sleep(time.Millisecond)
+	_, err = store.Archive(ItemID(item.ID))
+	assert.NoError(t, err)
+
+	items, err := store.Restore(at)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []Item{item}, items)
+	archived, err := store.ReadArchived()
+	assert.NoError(t, err)
+	assert.Empty(t, archived)
+}
@@ -0,0 +1,228 @@
+package store
+
+import (
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"slices"
+	"time"
+)
+
+const (
+	WebhooksFilename   = "webhooks.json"
+	DeliveriesFilename = "deliveries.json"
+	MaxDeliveries      = 100
+)
+
+// Webhook is a subscription that receives item events at URL, signed with Secret. An empty Events list
+// receives every event type.
+type Webhook struct {
+	ID        string    `json:"id"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events,omitempty"`
+	Secret    string    `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Matches reports whether the webhook subscribes to the event type.
+func (webhook Webhook) Matches(eventType string) bool {
+	return len(webhook.Events) == 0 || slices.Contains(webhook.Events, eventType)
+}
+
+// Delivery is one attempt to deliver an event to a webhook.
+type Delivery struct {
+	WebhookID  string    `json:"webhook_id"`
+	EventID    uint64    `json:"event_id"`
+	EventType  string    `json:"event_type"`
+	Attempt    int       `json:"attempt"`
+	StatusCode int       `json:"status_code,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	Time       time.Time `json:"time"`
+}
+
+func (s *Store) addWebhook(webhook Webhook) response {
+	if err := s.backend.load(WebhooksFilename, &s.webhooks); err != nil {
+		return response{
+			err: errors.Wrap(err, "load webhooks"),
+		}
+	}
+
+	if webhook.ID == "" {
+		webhook.ID = uuid.NewString()
+	}
+	webhook.CreatedAt = time.Now().UTC()
+	s.webhooks[webhook.ID] = webhook
+
+	if err := s.backend.save(WebhooksFilename, s.webhooks); err != nil {
+		return response{
+			err: errors.Wrap(err, "save webhooks"),
+		}
+	}
+
+	return response{
+		webhook: webhook,
+	}
+}
+
+func (s *Store) AddWebhook(webhook Webhook) (Webhook, error) {
+	responseChan := make(chan response, 1)
+	req := request{
+		action:       "addWebhook",
+		responseChan: responseChan,
+		webhook:      webhook,
+	}
+	res := s.send(req)
+
+	return res.webhook, res.err
+}
+
+func (s *Store) readWebhooks() response {
+	if err := s.backend.load(WebhooksFilename, &s.webhooks); err != nil {
+		return response{
+			err: errors.Wrap(err, "load webhooks"),
+		}
+	}
+
+	webhooks := make([]Webhook, 0, len(s.webhooks))
+	for _, webhook := range s.webhooks {
+		webhooks = append(webhooks, webhook)
+	}
+	slices.SortFunc(webhooks, func(a, b Webhook) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+
+	return response{
+		webhooks: webhooks,
+	}
+}
+
+func (s *Store) ReadWebhooks() ([]Webhook, error) {
+	responseChan := make(chan response, 1)
+	req := request{
+		action:       "readWebhooks",
+		responseChan: responseChan,
+	}
+	res := s.send(req)
+
+	return res.webhooks, res.err
+}
+
+func (s *Store) deleteWebhook(id string) response {
+	if err := s.backend.load(WebhooksFilename, &s.webhooks); err != nil {
+		return response{
+			err: errors.Wrap(err, "load webhooks"),
+		}
+	}
+
+	if _, found := s.webhooks[id]; !found {
+		return response{
+			err: errors.Wrapf(ErrNotFound, "webhook '%s'", id),
+		}
+	}
+
+	delete(s.webhooks, id)
+
+	if err := s.backend.save(WebhooksFilename, s.webhooks); err != nil {
+		return response{
+			err: errors.Wrap(err, "save webhooks"),
+		}
+	}
+
+	if err := s.backend.load(DeliveriesFilename, &s.deliveries); err != nil {
+		return response{
+			err: errors.Wrap(err, "load deliveries"),
+		}
+	}
+
+	delete(s.deliveries, id)
+
+	if err := s.backend.save(DeliveriesFilename, s.deliveries); err != nil {
+		return response{
+			err: errors.Wrap(err, "save deliveries"),
+		}
+	}
+
+	return response{}
+}
+
+func (s *Store) DeleteWebhook(id string) error {
+	responseChan := make(chan response, 1)
+	req := request{
+		action:       "deleteWebhook",
+		responseChan: responseChan,
+		webhookID:    id,
+	}
+	res := s.send(req)
+
+	return res.err
+}
+
+// recordDelivery appends a delivery attempt, keeping the last MaxDeliveries attempts per webhook.
+func (s *Store) recordDelivery(delivery Delivery) response {
+	if err := s.backend.load(DeliveriesFilename, &s.deliveries); err != nil {
+		return response{
+			err: errors.Wrap(err, "load deliveries"),
+		}
+	}
+
+	deliveries := append(s.deliveries[delivery.WebhookID], delivery)
+	if len(deliveries) > MaxDeliveries {
+		deliveries = deliveries[len(deliveries)-MaxDeliveries:]
+	}
+	s.deliveries[delivery.WebhookID] = deliveries
+
+	if err := s.backend.save(DeliveriesFilename, s.deliveries); err != nil {
+		return response{
+			err: errors.Wrap(err, "save deliveries"),
+		}
+	}
+
+	return response{}
+}
+
+func (s *Store) RecordDelivery(delivery Delivery) error {
+	responseChan := make(chan response, 1)
+	req := request{
+		action:       "recordDelivery",
+		responseChan: responseChan,
+		delivery:     delivery,
+	}
+	res := s.send(req)
+
+	return res.err
+}
+
+func (s *Store) readDeliveries(id string) response {
+	if err := s.backend.load(WebhooksFilename, &s.webhooks); err != nil {
+		return response{
+			err: errors.Wrap(err, "load webhooks"),
+		}
+	}
+
+	if _, found := s.webhooks[id]; !found {
+		return response{
+			err: errors.Wrapf(ErrNotFound, "webhook '%s'", id),
+		}
+	}
+
+	if err := s.backend.load(DeliveriesFilename, &s.deliveries); err != nil {
+		return response{
+			err: errors.Wrap(err, "load deliveries"),
+		}
+	}
+
+	return response{
+		deliveries: slices.Clone(s.deliveries[id]),
+	}
+}
+
+func (s *Store) ReadDeliveries(id string) ([]Delivery, error) {
+	responseChan := make(chan response, 1)
+	req := request{
+		action:       "readDeliveries",
+		responseChan: responseChan,
+		webhookID:    id,
+	}
+	res := s.send(req)
+
+	return res.deliveries, res.err
+}
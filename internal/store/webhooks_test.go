@@ -0,0 +1,55 @@
+package store
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_AddWebhook_IsListed(t *testing.T) {
+	t.Cleanup(setupTest())
+
+	store := newTestStore()
+
+	webhook, err := store.AddWebhook(Webhook{URL: "http://localhost/hook", Secret: "secret"})
+
+	assert.NoError(t, err)
+	assert.NotEmpty(t, webhook.ID)
+	webhooks, err := store.ReadWebhooks()
+	assert.NoError(t, err)
+	assert.Equal(t, []Webhook{webhook}, webhooks)
+}
+
+func Test_DeleteWebhook_ReturnsErrorWhenNotFound(t *testing.T) {
+	t.Cleanup(setupTest())
+
+	store := newTestStore()
+
+	err := store.DeleteWebhook("id1")
+
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func Test_RecordDelivery_KeepsLastDeliveries(t *testing.T) {
+	t.Cleanup(setupTest())
+
+	store := newTestStore()
+	webhook, err := store.AddWebhook(Webhook{URL: "http://localhost/hook", Secret: "secret"})
+	assert.NoError(t, err)
+
+	for i := range MaxDeliveries + 1 {
+		assert.NoError(t, store.RecordDelivery(Delivery{WebhookID: webhook.ID, EventID: uint64(i), Attempt: 1}))
+	}
+
+	deliveries, err := store.ReadDeliveries(webhook.ID)
+	assert.NoError(t, err)
+	assert.Len(t, deliveries, MaxDeliveries)
+	assert.Equal(t, uint64(1), deliveries[0].EventID)
+}
+
+func Test_Webhook_MatchesFilteredEvents(t *testing.T) {
+	webhook := Webhook{Events: []string{EventCreate}}
+
+	assert.True(t, webhook.Matches(EventCreate))
+	assert.False(t, webhook.Matches(EventDelete))
+	assert.True(t, Webhook{}.Matches(EventDelete))
+}
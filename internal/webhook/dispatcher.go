@@ -0,0 +1,291 @@
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+	"to-do-app-v2/internal/store"
+)
+
+const (
+	SignatureHeader = "X-Webhook-Signature"
+	EventHeader     = "X-Webhook-Event"
+	DeliveryHeader  = "X-Webhook-Delivery"
+
+	DefaultMaxAttempts    = 5
+	DefaultInitialBackoff = time.Second
+	DefaultMaxBackoff     = time.Minute
+	DefaultTimeout        = 10 * time.Second
+)
+
+// Source is the part of the store the dispatcher reads webhooks from and records deliveries to.
+type Source interface {
+	Subscribe(lastEventID uint64) (*store.Subscription, error)
+	ReadWebhooks() ([]store.Webhook, error)
+	RecordDelivery(delivery store.Delivery) error
+}
+
+// Dispatcher delivers item events to the matching webhooks, retrying failed deliveries with exponential backoff.
+type Dispatcher struct {
+	source         Source
+	client         *http.Client
+	maxAttempts    int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+	done           chan struct{}
+	wg             sync.WaitGroup
+}
+
+type Option func(*Dispatcher)
+
+func WithClient(client *http.Client) Option {
+	return func(d *Dispatcher) {
+		d.client = client
+	}
+}
+
+func WithMaxAttempts(attempts int) Option {
+	return func(d *Dispatcher) {
+		d.maxAttempts = attempts
+	}
+}
+
+// WithBackoff sets the delay before the first retry, doubled for every further retry up to maxBackoff.
+func WithBackoff(initial, maxBackoff time.Duration) Option {
+	return func(d *Dispatcher) {
+		d.initialBackoff = initial
+		d.maxBackoff = maxBackoff
+	}
+}
+
+// NewDispatcher subscribes to the source and starts delivering its events until Close is called.
+func NewDispatcher(source Source, opts ...Option) (*Dispatcher, error) {
+	d := &Dispatcher{
+		source:         source,
+		client:         &http.Client{Timeout: DefaultTimeout},
+		maxAttempts:    DefaultMaxAttempts,
+		initialBackoff: DefaultInitialBackoff,
+		maxBackoff:     DefaultMaxBackoff,
+		done:           make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(d)
+	}
+
+	sub, err := source.Subscribe(0)
+	if err != nil {
+		return nil, err
+	}
+
+	d.wg.Add(1)
+	go d.run(sub)
+
+	return d, nil
+}
+
+// Close stops the dispatcher and waits for deliveries in progress to give up.
+func (d *Dispatcher) Close() {
+	close(d.done)
+	d.wg.Wait()
+}
+
+// backlog holds the events received but not dispatched yet, so that receiving never waits for the store.
+type backlog struct {
+	mu     sync.Mutex
+	events []store.Event
+	ready  chan struct{}
+}
+
+func (b *backlog) push(event store.Event) {
+	b.mu.Lock()
+	b.events = append(b.events, event)
+	b.mu.Unlock()
+
+	select {
+	case b.ready <- struct{}{}:
+	default:
+	}
+}
+
+func (b *backlog) take() []store.Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	events := b.events
+	b.events = nil
+	return events
+}
+
+// run receives the events of the subscription and resubscribes from the last event received when the store
+// closes the subscription for falling behind. Dispatching reads the webhooks from the store, so it is left to
+// another goroutine.
+func (d *Dispatcher) run(sub *store.Subscription) {
+	defer d.wg.Done()
+
+	events := &backlog{ready: make(chan struct{}, 1)}
+	d.wg.Add(1)
+	go d.work(events)
+
+	var lastEventID uint64
+	for {
+		select {
+		case <-d.done:
+			sub.Close()
+			return
+		case event, ok := <-sub.Events:
+			if ok {
+				lastEventID = event.ID
+				events.push(event)
+				continue
+			}
+
+			sub.Close()
+			if sub = d.resubscribe(lastEventID); sub == nil {
+				return
+			}
+		}
+	}
+}
+
+// resubscribe subscribes to the events after lastEventID, retrying until the dispatcher is closed, in which
+// case it returns nil.
+func (d *Dispatcher) resubscribe(lastEventID uint64) *store.Subscription {
+	backoff := d.initialBackoff
+	for {
+		sub, err := d.source.Subscribe(lastEventID)
+		if err == nil {
+			return sub
+		}
+		slog.Error(err.Error())
+
+		select {
+		case <-d.done:
+			return nil
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, d.maxBackoff)
+	}
+}
+
+func (d *Dispatcher) work(events *backlog) {
+	defer d.wg.Done()
+
+	for {
+		select {
+		case <-d.done:
+			return
+		case <-events.ready:
+			for _, event := range events.take() {
+				d.dispatch(event)
+			}
+		}
+	}
+}
+
+func (d *Dispatcher) dispatch(event store.Event) {
+	webhooks, err := d.source.ReadWebhooks()
+	if err != nil {
+		slog.Error(err.Error())
+		return
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		slog.Error(err.Error())
+		return
+	}
+
+	for _, webhook := range webhooks {
+		if !webhook.Matches(event.Type) {
+			continue
+		}
+		d.wg.Add(1)
+		go func() {
+			defer d.wg.Done()
+			d.deliver(webhook, event, payload)
+		}()
+	}
+}
+
+func (d *Dispatcher) deliver(webhook store.Webhook, event store.Event, payload []byte) {
+	backoff := d.initialBackoff
+	for attempt := 1; attempt <= d.maxAttempts; attempt++ {
+		delivery := d.attempt(webhook, event, payload)
+		delivery.Attempt = attempt
+
+		if err := d.source.RecordDelivery(delivery); err != nil {
+			slog.Error(err.Error())
+		}
+
+		if delivery.Error == "" {
+			return
+		}
+
+		if attempt == d.maxAttempts {
+			slog.Error(fmt.Sprintf("webhook '%s' gave up on event %d after %d attempts", webhook.ID, event.ID, attempt))
+			return
+		}
+
+		select {
+		case <-d.done:
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, d.maxBackoff)
+	}
+}
+
+func (d *Dispatcher) attempt(webhook store.Webhook, event store.Event, payload []byte) store.Delivery {
+	delivery := store.Delivery{
+		WebhookID: webhook.ID,
+		EventID:   event.ID,
+		EventType: event.Type,
+		Time:      time.Now().UTC(),
+	}
+
+	req, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewReader(payload))
+	if err != nil {
+		delivery.Error = err.Error()
+		return delivery
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(SignatureHeader, Sign(webhook.Secret, payload))
+	req.Header.Set(EventHeader, event.Type)
+	req.Header.Set(DeliveryHeader, strconv.FormatUint(event.ID, 10))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		delivery.Error = err.Error()
+		return delivery
+	}
+	_ = resp.Body.Close()
+
+	delivery.StatusCode = resp.StatusCode
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		delivery.Error = "unexpected status " + resp.Status
+	}
+
+	return delivery
+}
+
+// Sign returns the signature header value of a payload, the hex encoded HMAC-SHA256 of the payload keyed with
+// the webhook secret.
+func Sign(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature is the signature of payload for the secret.
+func Verify(secret string, payload []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, payload)), []byte(signature))
+}
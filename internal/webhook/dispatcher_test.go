@@ -0,0 +1,144 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+	"to-do-app-v2/internal/store"
+)
+
+type received struct {
+	header http.Header
+	body   []byte
+}
+
+func newTestStore(t *testing.T) *store.Store {
+	itemStore := store.NewStore(store.WithDir(t.TempDir()), store.WithJanitorInterval(0))
+	t.Cleanup(itemStore.Close)
+	return itemStore
+}
+
+// newReceiver returns a webhook receiver that fails the first failures requests.
+func newReceiver(t *testing.T, failures int32) (*httptest.Server, <-chan received) {
+	requests := make(chan received, 10)
+	var count atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		if count.Add(1) <= failures {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		requests <- received{header: r.Header, body: body}
+	}))
+	t.Cleanup(server.Close)
+	return server, requests
+}
+
+func newTestDispatcher(t *testing.T, itemStore *store.Store) {
+	dispatcher, err := NewDispatcher(itemStore, WithBackoff(time.Millisecond, 10*time.Millisecond))
+	require.NoError(t, err)
+	t.Cleanup(dispatcher.Close)
+}
+
+func waitFor(t *testing.T, requests <-chan received) received {
+	select {
+	case request := <-requests:
+		return request
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook was not delivered")
+		return received{}
+	}
+}
+
+func Test_Dispatcher_DeliversSignedEvent(t *testing.T) {
+	itemStore := newTestStore(t)
+	server, requests := newReceiver(t, 0)
+	webhook, err := itemStore.AddWebhook(store.Webhook{URL: server.URL, Secret: "secret"})
+	require.NoError(t, err)
+	newTestDispatcher(t, itemStore)
+
+	item, err := itemStore.Create(store.Item{Name: "name1"})
+	require.NoError(t, err)
+
+	request := waitFor(t, requests)
+	assert.True(t, Verify(webhook.Secret, request.body, request.header.Get(SignatureHeader)))
+	assert.Equal(t, store.EventCreate, request.header.Get(EventHeader))
+	var event store.Event
+	require.NoError(t, json.Unmarshal(request.body, &event))
+	assert.Equal(t, &item, event.Item)
+}
+
+func Test_Dispatcher_RetriesAndRecordsAttempts(t *testing.T) {
+	itemStore := newTestStore(t)
+	server, requests := newReceiver(t, 2)
+	webhook, err := itemStore.AddWebhook(store.Webhook{URL: server.URL, Secret: "secret"})
+	require.NoError(t, err)
+	newTestDispatcher(t, itemStore)
+
+	_, err = itemStore.Create(store.Item{Name: "name1"})
+	require.NoError(t, err)
+	waitFor(t, requests)
+
+	var deliveries []store.Delivery
+	assert.Eventually(t, func() bool {
+		deliveries, err = itemStore.ReadDeliveries(webhook.ID)
+		return err == nil && len(deliveries) == 3
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, []int{1, 2, 3}, []int{deliveries[0].Attempt, deliveries[1].Attempt, deliveries[2].Attempt})
+	assert.Equal(t, http.StatusServiceUnavailable, deliveries[0].StatusCode)
+	assert.NotEmpty(t, deliveries[0].Error)
+	assert.Equal(t, http.StatusOK, deliveries[2].StatusCode)
+	assert.Empty(t, deliveries[2].Error)
+}
+
+func Test_Dispatcher_SkipsFilteredEvents(t *testing.T) {
+	itemStore := newTestStore(t)
+	server, requests := newReceiver(t, 0)
+	_, err := itemStore.AddWebhook(store.Webhook{URL: server.URL, Events: []string{store.EventDelete}, Secret: "secret"})
+	require.NoError(t, err)
+	newTestDispatcher(t, itemStore)
+
+	item, err := itemStore.Create(store.Item{Name: "name1"})
+	require.NoError(t, err)
+	require.NoError(t, itemStore.Delete(store.ItemID(item.ID)))
+
+	request := waitFor(t, requests)
+	assert.Equal(t, store.EventDelete, request.header.Get(EventHeader))
+}
+
+func Test_Dispatcher_DeliversAfterFullBatch(t *testing.T) {
+	itemStore := newTestStore(t)
+	server, requests := newReceiver(t, 0)
+	_, err := itemStore.AddWebhook(store.Webhook{URL: server.URL, Events: []string{store.EventUpdate}, Secret: "secret"})
+	require.NoError(t, err)
+	newTestDispatcher(t, itemStore)
+
+	// A batch publishes more events at once than a subscription buffers
+	operations := make([]store.Operation, 100)
+	for i := range operations {
+		operations[i] = store.Operation{Op: store.OpCreate, Item: &store.Item{Name: fmt.Sprintf("name%d", i)}}
+	}
+	results, err := itemStore.Batch(operations)
+	require.NoError(t, err)
+	item := *results[0].Item
+	item.Name = "updated"
+	_, err = itemStore.Update(store.ItemID(item.ID), item)
+	require.NoError(t, err)
+
+	request := waitFor(t, requests)
+	assert.Equal(t, store.EventUpdate, request.header.Get(EventHeader))
+}
+
+func Test_Verify_RejectsWrongSecret(t *testing.T) {
+	payload := []byte(`{"id":1}`)
+
+	assert.False(t, Verify("other", payload, Sign("secret", payload)))
+}
@@ -0,0 +1,143 @@
+(function () {
+    const rows = document.getElementById("items");
+    const status = document.getElementById("status");
+    const newItem = document.getElementById("new-item");
+    const fields = ["name", "desc", "status", "assignee"];
+
+    let socket;
+    let requestID = 0;
+
+    function connect() {
+        const scheme = location.protocol === "https:" ? "wss:" : "ws:";
+        socket = new WebSocket(scheme + "//" + location.host + "/ws/items");
+        socket.onopen = () => setStatus("Live");
+        socket.onclose = () => {
+            setStatus("Disconnected, reconnecting...");
+            setTimeout(connect, 2000);
+        };
+        socket.onmessage = (message) => receive(JSON.parse(message.data));
+    }
+
+    function send(message) {
+        if (!socket || socket.readyState !== WebSocket.OPEN) {
+            setStatus("Not connected, the change was not saved");
+            return;
+        }
+        message.request_id = String(++requestID);
+        socket.send(JSON.stringify(message));
+    }
+
+    function receive(message) {
+        switch (message.type) {
+            case "snapshot":
+                rows.replaceChildren();
+                (message.items || []).forEach((item) => upsert(item, true));
+                break;
+            case "event":
+                applyEvent(message.event);
+                break;
+            case "ack":
+                if (message.item) {
+                    upsert(message.item, true);
+                }
+                break;
+            case "conflict":
+                upsert(message.item, true);
+                mark(rowFor(message.id), "conflict");
+                setStatus("Someone else changed \"" + message.item.name + "\" first, their version is shown");
+                break;
+            case "error":
+                setStatus(message.error || message.errors.map((e) => e.field + ": " + e.message).join(", "));
+                break;
+        }
+    }
+
+    function applyEvent(event) {
+        switch (event.type) {
+            case "create":
+            case "update":
+            case "unarchive":
+                if (upsert(event.item, false)) {
+                    mark(rowFor(event.item_id), "changed");
+                }
+                break;
+            case "delete":
+            case "archive": {
+                const row = rowFor(event.item_id);
+                if (row) {
+                    row.remove();
+                }
+                break;
+            }
+        }
+    }
+
+    // upsert shows the item unless the row already holds the same or a newer version.
+    function upsert(item, force) {
+        let row = rowFor(item.id);
+        if (row && !force && Number(row.dataset.version) >= (item.version || 0)) {
+            return false;
+        }
+        if (!row) {
+            row = newItem.cloneNode(true);
+            row.removeAttribute("id");
+            row.dataset.id = item.id;
+            const button = row.querySelector("button");
+            button.removeAttribute("id");
+            button.className = "delete";
+            button.textContent = "Delete";
+            rows.appendChild(row);
+        }
+        row.dataset.version = item.version || 0;
+        fields.forEach((field) => {
+            const input = row.querySelector("[name=" + field + "]");
+            input.value = item[field] || "";
+            input.removeAttribute("placeholder");
+        });
+        return true;
+    }
+
+    function rowFor(id) {
+        return rows.querySelector("tr[data-id=\"" + CSS.escape(id) + "\"]");
+    }
+
+    function itemFrom(row) {
+        const item = {};
+        fields.forEach((field) => {
+            item[field] = row.querySelector("[name=" + field + "]").value;
+        });
+        return item;
+    }
+
+    function mark(row, className) {
+        if (!row) {
+            return;
+        }
+        row.classList.add(className);
+        setTimeout(() => row.classList.remove(className), 1500);
+    }
+
+    function setStatus(text) {
+        status.textContent = text;
+    }
+
+    rows.addEventListener("change", (e) => {
+        const row = e.target.closest("tr");
+        const item = itemFrom(row);
+        item.version = Number(row.dataset.version);
+        send({type: "update", id: row.dataset.id, item: item});
+    });
+
+    rows.addEventListener("click", (e) => {
+        if (e.target.classList.contains("delete")) {
+            send({type: "delete", id: e.target.closest("tr").dataset.id});
+        }
+    });
+
+    document.getElementById("add").addEventListener("click", () => {
+        send({type: "create", item: itemFrom(newItem)});
+        newItem.querySelectorAll("input").forEach((input) => input.value = "");
+    });
+
+    connect();
+})();